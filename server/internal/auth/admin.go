@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"awesomeChat/package/logger"
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// AdminMiddleware пропускает только пользователей с флагом is_admin; права проверяются у владельца токена
+func AdminMiddleware(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, ok := tokenUser(c, db)
+		if !ok {
+			return
+		}
+		isAdmin, err := IsAdmin(username, db)
+		if err != nil {
+			logger.Log.Errorln("Admin check error:", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		if !isAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin rights required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// tokenUser владелец токена запроса. ?username= необязателен, но если передан, должен совпадать
+// с владельцем токена, иначе действие записалось бы на чужое имя. Имя кладется в контекст под
+// ключом "username"; если его уже положил AuthMiddleware, токен повторно не проверяется.
+// При ошибке сама отвечает клиенту
+func tokenUser(c *gin.Context, db *sql.DB) (string, bool) {
+	if username := c.GetString("username"); username != "" {
+		return username, true
+	}
+	username, err := TokenUsername(c.Request, db)
+	if errors.Is(err, ErrUnauthenticated) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return "", false
+	}
+	if err != nil {
+		logger.Log.Errorln("Token user query error:", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return "", false
+	}
+	if query := c.Query("username"); query != "" && query != username {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Token does not match username"})
+		return "", false
+	}
+	c.Set("username", username)
+	return username, true
+}
+
+func IsAdmin(username string, db *sql.DB) (bool, error) {
+	var isAdmin bool
+	err := db.QueryRow("SELECT is_admin FROM users WHERE username = $1", username).Scan(&isAdmin)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return isAdmin, err
+}
+
+// ResearcherMiddleware пропускает исследователей и администраторов; права проверяются у владельца токена
+func ResearcherMiddleware(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username, ok := tokenUser(c, db)
+		if !ok {
+			return
+		}
+		isResearcher, err := IsResearcher(username, db)
+		if err != nil {
+			logger.Log.Errorln("Researcher check error:", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			return
+		}
+		if !isResearcher {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Researcher rights required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func IsResearcher(username string, db *sql.DB) (bool, error) {
+	var isResearcher bool
+	err := db.QueryRow("SELECT is_researcher OR is_admin FROM users WHERE username = $1", username).Scan(&isResearcher)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return isResearcher, err
+}
@@ -1,12 +1,17 @@
 package auth
 
 import (
-	"awesomeChat/package/logger"
+	"database/sql"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware пропускает только запросы с действующим токеном. Владелец токена кладется в
+// контекст под ключом "username", и обработчики берут пользователя оттуда, а не из ?username=
+func AuthMiddleware(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		logger.Log.Infoln("HI IM censorship")
+		if _, ok := tokenUser(c, db); !ok {
+			return
+		}
+		c.Next()
 	}
 }
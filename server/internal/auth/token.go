@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"awesomeChat/package/tkn"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrUnauthenticated токена нет, он поддельный, просрочен или выдан удаленному пользователю
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// TokenUsername имя пользователя, которому выдан JWT запроса: из заголовка Authorization: Bearer
+// или cookie auth_token, которую ставит /login. Браузер не может передать заголовок при открытии
+// вебсокета, поэтому cookie нужна и там
+func TokenUsername(r *http.Request, db *sql.DB) (string, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		if cookie, err := r.Cookie("auth_token"); err == nil {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		return "", ErrUnauthenticated
+	}
+
+	claims, err := tkn.ParseJWT(token)
+	if err != nil {
+		return "", ErrUnauthenticated
+	}
+
+	var username string
+	err = db.QueryRow("SELECT username FROM users WHERE user_id = $1", claims.UserID).Scan(&username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrUnauthenticated
+	}
+	return username, err
+}
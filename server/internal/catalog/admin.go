@@ -0,0 +1,147 @@
+package catalog
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var ErrTooFewTheses = errors.New("subtopic needs at least two theses")
+
+func CreateTopic(db *sql.DB, name string) (int, error) {
+	var id int
+	if err := db.QueryRow(`INSERT INTO topics (name) VALUES ($1) RETURNING id`, name).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, Load(db)
+}
+
+func UpdateTopic(db *sql.DB, id int, name string) error {
+	res, err := db.Exec(`UPDATE topics SET name = $1 WHERE id = $2 AND deleted_at IS NULL`, name, id)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(res); err != nil {
+		return err
+	}
+	return Load(db)
+}
+
+// DeleteTopic мягко удаляет топик вместе с его сабтопиками: в архиве названия остаются
+func DeleteTopic(db *sql.DB, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE topics SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(res); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`UPDATE subtopics SET deleted_at = NOW() WHERE topic_id = $1 AND deleted_at IS NULL`, id); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return Load(db)
+}
+
+func CreateSubtopic(db *sql.DB, topicID int, name string, theses []string) (int, error) {
+	if len(theses) < 2 {
+		return 0, ErrTooFewTheses
+	}
+	if !TopicExists(topicID) {
+		return 0, ErrNotFound
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`INSERT INTO subtopics (topic_id, name) VALUES ($1, $2) RETURNING id`, topicID, name).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if err = insertTheses(tx, id, theses); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, Load(db)
+}
+
+// UpdateSubtopic заменяет название, топик и набор тезисов сабтопика
+func UpdateSubtopic(db *sql.DB, id int, topicID int, name string, theses []string) error {
+	if len(theses) < 2 {
+		return ErrTooFewTheses
+	}
+	if !TopicExists(topicID) {
+		return ErrNotFound
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE subtopics SET topic_id = $1, name = $2 WHERE id = $3 AND deleted_at IS NULL`,
+		topicID, name, id)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(res); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM theses WHERE subtopic_id = $1`, id); err != nil {
+		return err
+	}
+	if err = insertTheses(tx, id, theses); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return Load(db)
+}
+
+func DeleteSubtopic(db *sql.DB, id int) error {
+	res, err := db.Exec(`UPDATE subtopics SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(res); err != nil {
+		return err
+	}
+	return Load(db)
+}
+
+func insertTheses(tx *sql.Tx, subtopicID int, theses []string) error {
+	for i, content := range theses {
+		_, err := tx.Exec(`INSERT INTO theses (subtopic_id, position, content) VALUES ($1, $2, $3)`,
+			subtopicID, i, content)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func expectAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
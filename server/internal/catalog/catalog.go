@@ -0,0 +1,192 @@
+package catalog
+
+import (
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var ErrNotFound = errors.New("catalog entry not found")
+
+// каталог целиком держится в памяти: он небольшой и читается на каждом старте блица,
+// а после каждого изменения через админский API перечитывается из базы
+var (
+	mu        sync.RWMutex
+	topics    = make(map[int]*structures.CatalogTopic)
+	subtopics = make(map[int]*structures.CatalogSubtopic)
+)
+
+// Load перечитывает топики, сабтопики и тезисы из базы, включая удаленные
+func Load(db *sql.DB) error {
+	newTopics := make(map[int]*structures.CatalogTopic)
+	newSubtopics := make(map[int]*structures.CatalogSubtopic)
+
+	rows, err := db.Query(`SELECT id, name, deleted_at IS NOT NULL FROM topics`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		topic := &structures.CatalogTopic{Subtopics: []structures.CatalogSubtopic{}}
+		if err = rows.Scan(&topic.ID, &topic.Name, &topic.Deleted); err != nil {
+			rows.Close()
+			return err
+		}
+		newTopics[topic.ID] = topic
+	}
+	rows.Close()
+
+	rows, err = db.Query(`SELECT id, topic_id, name, deleted_at IS NOT NULL FROM subtopics`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		subtopic := &structures.CatalogSubtopic{Theses: []string{}}
+		if err = rows.Scan(&subtopic.ID, &subtopic.TopicID, &subtopic.Name, &subtopic.Deleted); err != nil {
+			rows.Close()
+			return err
+		}
+		newSubtopics[subtopic.ID] = subtopic
+	}
+	rows.Close()
+
+	rows, err = db.Query(`SELECT subtopic_id, content FROM theses ORDER BY subtopic_id, position`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var subtopicID int
+		var content string
+		if err = rows.Scan(&subtopicID, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		if subtopic, ok := newSubtopics[subtopicID]; ok {
+			subtopic.Theses = append(subtopic.Theses, content)
+		}
+	}
+	rows.Close()
+
+	mu.Lock()
+	topics, subtopics = newTopics, newSubtopics
+	mu.Unlock()
+
+	logger.Log.Infof("Catalog loaded: %d topics, %d subtopics", len(newTopics), len(newSubtopics))
+	return nil
+}
+
+// TopicName название топика, в том числе удаленного — чтобы архив продолжал его показывать
+func TopicName(id int) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if topic, ok := topics[id]; ok {
+		return topic.Name
+	}
+	return ""
+}
+
+// SubtopicName название сабтопика, в том числе удаленного
+func SubtopicName(id int) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if subtopic, ok := subtopics[id]; ok {
+		return subtopic.Name
+	}
+	return ""
+}
+
+// TopicOf топик активного сабтопика
+func TopicOf(subtopicID int) (int, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	subtopic, ok := subtopics[subtopicID]
+	if !ok || subtopic.Deleted {
+		return 0, false
+	}
+	return subtopic.TopicID, true
+}
+
+// TopicExists есть ли активный топик
+func TopicExists(id int) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	topic, ok := topics[id]
+	return ok && !topic.Deleted
+}
+
+// Theses копия тезисов активного сабтопика; для удаленного — nil, новые блицы по нему не стартуют
+func Theses(subtopicID int) []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	subtopic, ok := subtopics[subtopicID]
+	if !ok || subtopic.Deleted {
+		return nil
+	}
+	return append([]string(nil), subtopic.Theses...)
+}
+
+// PlayableSubtopics активные сабтопики с двумя и более тезисами; topicID == 0 — всех топиков
+func PlayableSubtopics(topicID int) []int {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	ids := make([]int, 0)
+	for id, subtopic := range subtopics {
+		if subtopic.Deleted || len(subtopic.Theses) < 2 {
+			continue
+		}
+		if topicID != 0 && subtopic.TopicID != topicID {
+			continue
+		}
+		if topic, ok := topics[subtopic.TopicID]; !ok || topic.Deleted {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Topics дерево каталога, отсортированное по id
+func Topics(includeDeleted bool) []structures.CatalogTopic {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	result := make([]structures.CatalogTopic, 0, len(topics))
+	for _, topic := range topics {
+		if topic.Deleted && !includeDeleted {
+			continue
+		}
+		item := *topic
+		item.Subtopics = []structures.CatalogSubtopic{}
+		for _, subtopic := range subtopics {
+			if subtopic.TopicID != topic.ID || (subtopic.Deleted && !includeDeleted) {
+				continue
+			}
+			item.Subtopics = append(item.Subtopics, *subtopic)
+		}
+		sort.Slice(item.Subtopics, func(i, j int) bool { return item.Subtopics[i].ID < item.Subtopics[j].ID })
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// Subtopic сабтопик по id, в том числе удаленный
+func Subtopic(id int) (structures.CatalogSubtopic, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	subtopic, ok := subtopics[id]
+	if !ok {
+		return structures.CatalogSubtopic{}, false
+	}
+	return *subtopic, true
+}
@@ -0,0 +1,128 @@
+package exports
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GenerateDelay пауза перед автоматической выгрузкой: участники успевают выставить оценки,
+// и они попадают в CSV и граф
+var GenerateDelay = 2 * time.Minute
+
+var ErrArtifactNotFound = errors.New("export artifact not found")
+
+// Schedule в фоне строит выгрузки kinds для сохраненной дискуссии, кладет их в discussion_exports
+// и передает в notify список готовых файлов
+func Schedule(db *sql.DB, repos *storage.Repos, discussionID int, kinds []string, notify func([]structures.ExportArtifact)) {
+	if discussionID <= 0 || len(kinds) == 0 {
+		return
+	}
+
+	go func() {
+		time.Sleep(GenerateDelay)
+
+		artifacts := make([]structures.ExportArtifact, 0, len(kinds))
+		for _, kind := range kinds {
+			artifact, err := Generate(db, repos, discussionID, kind)
+			if err != nil {
+				logger.Log.Errorf("Export %s for discussion %d failed: %v", kind, discussionID, err)
+				continue
+			}
+			artifacts = append(artifacts, artifact)
+		}
+
+		logger.Log.Tracef("Discussion %d: %d exports generated", discussionID, len(artifacts))
+		if notify != nil && len(artifacts) > 0 {
+			notify(artifacts)
+		}
+	}()
+}
+
+// Generate строит выгрузку и сохраняет ее, заменяя прежнюю того же вида
+func Generate(db *sql.DB, repos *storage.Repos, discussionID int, kind string) (structures.ExportArtifact, error) {
+	exporter, ok := Get(kind)
+	if !ok {
+		return structures.ExportArtifact{}, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	data, err := exporter.Build(db, repos, discussionID)
+	if err != nil {
+		return structures.ExportArtifact{}, err
+	}
+
+	artifact := structures.ExportArtifact{
+		Kind:        kind,
+		Filename:    Filename(exporter, discussionID),
+		ContentType: exporter.ContentType,
+		Size:        len(data),
+		URL:         ArtifactURL(discussionID, kind),
+	}
+
+	var createdAt time.Time
+	err = db.QueryRow(`
+		INSERT INTO discussion_exports (discussion_id, kind, content_type, filename, data)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (discussion_id, kind) DO UPDATE
+		SET content_type = EXCLUDED.content_type, filename = EXCLUDED.filename,
+			data = EXCLUDED.data, created_at = NOW()
+		RETURNING created_at`,
+		discussionID, kind, artifact.ContentType, artifact.Filename, data,
+	).Scan(&createdAt)
+	if err != nil {
+		return structures.ExportArtifact{}, err
+	}
+
+	artifact.CreatedAt = createdAt.Format(time.RFC3339)
+	return artifact, nil
+}
+
+// Artifacts сохраненные выгрузки дискуссии без содержимого
+func Artifacts(db *sql.DB, discussionID int) ([]structures.ExportArtifact, error) {
+	rows, err := db.Query(`
+		SELECT kind, filename, content_type, LENGTH(data), created_at
+		FROM discussion_exports
+		WHERE discussion_id = $1
+		ORDER BY kind`, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	artifacts := make([]structures.ExportArtifact, 0)
+	for rows.Next() {
+		var artifact structures.ExportArtifact
+		var createdAt time.Time
+		if err = rows.Scan(&artifact.Kind, &artifact.Filename, &artifact.ContentType, &artifact.Size, &createdAt); err != nil {
+			return nil, err
+		}
+		artifact.CreatedAt = createdAt.Format(time.RFC3339)
+		artifact.URL = ArtifactURL(discussionID, artifact.Kind)
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, rows.Err()
+}
+
+// Artifact содержимое сохраненной выгрузки
+func Artifact(db *sql.DB, discussionID int, kind string) (structures.ExportArtifact, []byte, error) {
+	var artifact structures.ExportArtifact
+	var data []byte
+	err := db.QueryRow(`
+		SELECT kind, filename, content_type, data
+		FROM discussion_exports
+		WHERE discussion_id = $1 AND kind = $2`, discussionID, kind).
+		Scan(&artifact.Kind, &artifact.Filename, &artifact.ContentType, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return artifact, nil, ErrArtifactNotFound
+	}
+	artifact.Size = len(data)
+	return artifact, data, err
+}
+
+func ArtifactURL(discussionID int, kind string) string {
+	return fmt.Sprintf("/discussion/%d/exports/%s", discussionID, kind)
+}
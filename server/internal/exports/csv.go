@@ -0,0 +1,166 @@
+package exports
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var ErrUnknownUser = errors.New("participant not found")
+
+func init() {
+	Register(Exporter{
+		Kind:        "csv",
+		ContentType: "text/csv",
+		Extension:   "csv",
+		Build: func(_ *sql.DB, repos *storage.Repos, discussionID int) ([]byte, error) {
+			return BuildStatsCSV(repos, discussionID)
+		},
+	})
+}
+
+// BuildStatsCSV сводная статистика участников: сообщения, реакции и оценки
+func BuildStatsCSV(repos *storage.Repos, discussionID int) ([]byte, error) {
+	participants, err := participantStats(repos, discussionID)
+	if err != nil {
+		return nil, err
+	}
+
+	csvData := [][]string{{
+		"UserID", "Username",
+		"MessagesSent",
+		"LikesReceived", "DislikesReceived",
+		"LikesGiven", "DislikesGiven",
+		"AvgProfessionalismReceived", "AvgArgumentsQualityReceived", "AvgPolitenessReceived",
+		"TotalRatingsReceived",
+		"AvgProfessionalismGiven", "AvgArgumentsQualityGiven", "AvgPolitenessGiven",
+		"TotalRatingsGiven",
+	}}
+
+	for _, stats := range participants {
+		csvData = append(csvData, []string{
+			strconv.Itoa(stats.UserID),
+			stats.Username,
+			strconv.Itoa(stats.MessagesSent),
+			strconv.Itoa(stats.LikesReceived),
+			strconv.Itoa(stats.DislikesReceived),
+			strconv.Itoa(stats.LikesGiven),
+			strconv.Itoa(stats.DislikesGiven),
+			fmt.Sprintf("%.2f", average(stats.ProfessionalismReceived)),
+			fmt.Sprintf("%.2f", average(stats.ArgumentsQualityReceived)),
+			fmt.Sprintf("%.2f", average(stats.PolitenessReceived)),
+			strconv.Itoa(len(stats.ProfessionalismReceived)),
+			fmt.Sprintf("%.2f", average(stats.ProfessionalismGiven)),
+			fmt.Sprintf("%.2f", average(stats.ArgumentsQualityGiven)),
+			fmt.Sprintf("%.2f", average(stats.PolitenessGiven)),
+			strconv.Itoa(len(stats.ProfessionalismGiven)),
+		})
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err = writer.WriteAll(csvData); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// participantStats статистика участников в порядке списка participants; ее же выводит PDF-отчет
+func participantStats(repos *storage.Repos, discussionID int) ([]*structures.UserStats, error) {
+	discussion, err := loadDiscussion(repos, discussionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, username := range discussion.Participants {
+		if _, ok := discussion.UsernameToUserID[username]; !ok {
+			logger.Log.Errorf("User %s not found in database", username)
+			return nil, fmt.Errorf("%w: %s", ErrUnknownUser, username)
+		}
+	}
+
+	userStats := make(map[int]*structures.UserStats)
+	for username, userID := range discussion.UsernameToUserID {
+		userStats[userID] = &structures.UserStats{
+			UserID:                   userID,
+			Username:                 username,
+			ProfessionalismReceived:  []int{},
+			ArgumentsQualityReceived: []int{},
+			PolitenessReceived:       []int{},
+			ProfessionalismGiven:     []int{},
+			ArgumentsQualityGiven:    []int{},
+			PolitenessGiven:          []int{},
+		}
+	}
+
+	for _, msg := range discussion.Messages {
+		if msg.Type != "usual" {
+			continue
+		}
+
+		userID, ok := discussion.UsernameToUserID[msg.Username]
+		if !ok {
+			logger.Log.Errorf("User %s not found for message: %s", msg.Username, msg.ID)
+			continue
+		}
+		stats := userStats[userID]
+
+		stats.MessagesSent++
+
+		stats.LikesReceived += len(msg.LikedBy)
+		stats.DislikesReceived += len(msg.DislikedBy)
+
+		for _, likedUser := range msg.LikedBy {
+			if likerID, ok := discussion.UsernameToUserID[likedUser]; ok {
+				userStats[likerID].LikesGiven++
+			}
+		}
+
+		for _, dislikedUser := range msg.DislikedBy {
+			if dislikerID, ok := discussion.UsernameToUserID[dislikedUser]; ok {
+				userStats[dislikerID].DislikesGiven++
+			}
+		}
+	}
+
+	ratings, err := loadRatings(repos, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range ratings {
+		if stats, ok := userStats[r.RatedID]; ok {
+			stats.ProfessionalismReceived = append(stats.ProfessionalismReceived, r.Prof)
+			stats.ArgumentsQualityReceived = append(stats.ArgumentsQualityReceived, r.Arg)
+			stats.PolitenessReceived = append(stats.PolitenessReceived, r.Pol)
+		}
+
+		if stats, ok := userStats[r.RaterID]; ok {
+			stats.ProfessionalismGiven = append(stats.ProfessionalismGiven, r.Prof)
+			stats.ArgumentsQualityGiven = append(stats.ArgumentsQualityGiven, r.Arg)
+			stats.PolitenessGiven = append(stats.PolitenessGiven, r.Pol)
+		}
+	}
+
+	participants := make([]*structures.UserStats, 0, len(discussion.Participants))
+	for _, username := range discussion.Participants {
+		participants = append(participants, userStats[discussion.UsernameToUserID[username]])
+	}
+	return participants, nil
+}
+
+func average(nums []int) float64 {
+	if len(nums) == 0 {
+		return 0.0
+	}
+	sum := 0
+	for _, n := range nums {
+		sum += n
+	}
+	return float64(sum) / float64(len(nums))
+}
@@ -0,0 +1,411 @@
+package exports
+
+import (
+	"archive/zip"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrUnknownDatasetFormat = errors.New("unknown dataset format")
+
+// DatasetFormats форматы исследовательской выгрузки и их Content-Type
+var DatasetFormats = map[string]string{
+	"zip":    "application/zip",
+	"ndjson": "application/x-ndjson",
+}
+
+// DatasetFilter отбор дискуссий для исследователей. В выборку попадают только публичные дискуссии
+// вне организаций: закрытые обсуждения участники не соглашались раскрывать
+type DatasetFilter struct {
+	From            time.Time `json:"from"` // нулевое значение — без границы; дискуссия должна начаться в [From, To)
+	To              time.Time `json:"to"`
+	Mode            string    `json:"mode,omitempty"`
+	TopicIDs        []int     `json:"topic_ids,omitempty"`
+	Tags            []string  `json:"tags,omitempty"` // дискуссия должна иметь все теги
+	MinParticipants int       `json:"min_participants,omitempty"`
+}
+
+// DatasetOptions фильтр и обезличивание выгрузки
+type DatasetOptions struct {
+	Filter       DatasetFilter
+	Pseudonymize bool // имена заменяются псевдонимами, id пользователей не выводятся
+	StripPII     bool // почта, ссылки, телефоны и номера карт вырезаются из текстов
+}
+
+// DatasetDiscussion описание дискуссии в наборе данных
+type DatasetDiscussion struct {
+	Record          string                `json:"record,omitempty"`
+	ID              int                   `json:"id"`
+	Mode            string                `json:"mode"`
+	SubType         string                `json:"subtype"`
+	TopicID         *int                  `json:"topic_id"`
+	SubtopicID      *int                  `json:"subtopic_id"`
+	Topic           string                `json:"topic"`
+	Subtopic        string                `json:"subtopic"`
+	Description     string                `json:"description"`
+	Purpose         string                `json:"purpose"`
+	Start           time.Time             `json:"start_time"`
+	End             time.Time             `json:"end_time"`
+	DurationSeconds float64               `json:"duration_seconds"`
+	Participants    []string              `json:"participants"`
+	Tags            []string              `json:"tags"`
+	KeyQuestions    []string              `json:"key_questions"`
+	Teams           []structures.TeamInfo `json:"teams"`
+}
+
+// DatasetMessage сообщение дискуссии; поля те же, что в построчной выгрузке сообщений
+type DatasetMessage struct {
+	Record       string `json:"record,omitempty"`
+	DiscussionID int    `json:"discussion_id"`
+	MessageRow
+}
+
+// DatasetRating итоговая оценка одного участника другим
+type DatasetRating struct {
+	Record           string `json:"record,omitempty"`
+	DiscussionID     int    `json:"discussion_id"`
+	RaterID          *int   `json:"rater_id"`
+	Rater            string `json:"rater"`
+	RatedID          *int   `json:"rated_id"`
+	Rated            string `json:"rated"`
+	Professionalism  int    `json:"professionalism"`
+	ArgumentsQuality int    `json:"arguments_quality"`
+	Politeness       int    `json:"politeness"`
+}
+
+// datasetManifest описание архива: по нему видно, с какими параметрами собран набор
+type datasetManifest struct {
+	GeneratedAt  time.Time     `json:"generated_at"`
+	Filter       DatasetFilter `json:"filter"`
+	Pseudonymize bool          `json:"pseudonymized"`
+	StripPII     bool          `json:"pii_removed"`
+	Discussions  int           `json:"discussions"`
+	Messages     int           `json:"messages"`
+	Ratings      int           `json:"ratings"`
+}
+
+// Dataset отобранные дискуссии; записи читаются из базы по одной дискуссии во время записи
+type Dataset struct {
+	db      *sql.DB
+	repos   *storage.Repos
+	options DatasetOptions
+	ids     []int
+	names   *pseudonymizer
+}
+
+// NewDataset отбирает дискуссии под фильтр; ошибка возвращается до начала записи ответа
+func NewDataset(db *sql.DB, repos *storage.Repos, options DatasetOptions) (*Dataset, error) {
+	ids, err := datasetDiscussions(db, options.Filter)
+	if err != nil {
+		return nil, err
+	}
+	dataset := &Dataset{db: db, repos: repos, options: options, ids: ids}
+	if options.Pseudonymize {
+		if dataset.names, err = newPseudonymizer(); err != nil {
+			return nil, err
+		}
+	}
+	return dataset, nil
+}
+
+func datasetDiscussions(db *sql.DB, filter DatasetFilter) ([]int, error) {
+	query := `SELECT id FROM discussions WHERE public = true AND organization_id IS NULL`
+	var args []any
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		query += fmt.Sprintf(` AND start_time >= $%d`, len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		query += fmt.Sprintf(` AND start_time < $%d`, len(args))
+	}
+	if filter.Mode != "" {
+		args = append(args, filter.Mode)
+		query += fmt.Sprintf(` AND mode = $%d`, len(args))
+	}
+	if len(filter.TopicIDs) > 0 {
+		args = append(args, pq.Array(filter.TopicIDs))
+		query += fmt.Sprintf(` AND topic_id = ANY($%d)`, len(args))
+	}
+	if len(filter.Tags) > 0 {
+		tagsJSON, err := json.Marshal(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, tagsJSON)
+		query += fmt.Sprintf(` AND tags @> $%d::jsonb`, len(args))
+	}
+	if filter.MinParticipants > 0 {
+		args = append(args, filter.MinParticipants)
+		query += fmt.Sprintf(` AND jsonb_array_length(participants) >= $%d`, len(args))
+	}
+
+	rows, err := db.Query(query+` ORDER BY start_time, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Write пишет набор в формате format: zip или ndjson
+func (d *Dataset) Write(w io.Writer, format string) error {
+	switch format {
+	case "zip":
+		return d.WriteZIP(w)
+	case "ndjson":
+		return d.WriteNDJSON(w)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownDatasetFormat, format)
+}
+
+// WriteNDJSON пишет один поток: за каждой дискуссией следуют ее сообщения и оценки,
+// вид строки указан в поле record
+func (d *Dataset) WriteNDJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, id := range d.ids {
+		discussion, err := d.discussion(id)
+		if err != nil {
+			return err
+		}
+		discussion.Record = "discussion"
+		if err = encoder.Encode(discussion); err != nil {
+			return err
+		}
+		err = d.messages(discussion, func(msg DatasetMessage) error {
+			msg.Record = "message"
+			return encoder.Encode(msg)
+		})
+		if err != nil {
+			return err
+		}
+		err = d.ratings(discussion, func(r DatasetRating) error {
+			r.Record = "rating"
+			return encoder.Encode(r)
+		})
+		if err != nil {
+			return err
+		}
+		flush(w)
+	}
+	return nil
+}
+
+// WriteZIP пишет архив из discussions.ndjson, messages.ndjson, ratings.ndjson и manifest.json.
+// Файлы архива пишутся последовательно, поэтому дискуссии проходятся трижды
+func (d *Dataset) WriteZIP(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	manifest := datasetManifest{
+		GeneratedAt:  time.Now().UTC(),
+		Filter:       d.options.Filter,
+		Pseudonymize: d.options.Pseudonymize,
+		StripPII:     d.options.StripPII,
+	}
+
+	for _, file := range []struct {
+		name  string
+		count *int
+		write func(*DatasetDiscussion, func(any) error) error
+	}{
+		{"discussions.ndjson", &manifest.Discussions, func(discussion *DatasetDiscussion, emit func(any) error) error {
+			return emit(discussion)
+		}},
+		{"messages.ndjson", &manifest.Messages, func(discussion *DatasetDiscussion, emit func(any) error) error {
+			return d.messages(discussion, func(msg DatasetMessage) error { return emit(msg) })
+		}},
+		{"ratings.ndjson", &manifest.Ratings, func(discussion *DatasetDiscussion, emit func(any) error) error {
+			return d.ratings(discussion, func(r DatasetRating) error { return emit(r) })
+		}},
+	} {
+		entry, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetEscapeHTML(false)
+		emit := func(record any) error {
+			*file.count++
+			return encoder.Encode(record)
+		}
+		for _, id := range d.ids {
+			discussion, err := d.discussion(id)
+			if err != nil {
+				return err
+			}
+			if err = file.write(discussion, emit); err != nil {
+				return err
+			}
+		}
+		if err = archive.Flush(); err != nil {
+			return err
+		}
+		flush(w)
+	}
+
+	entry, err := archive.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(manifest); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func (d *Dataset) discussion(id int) (*DatasetDiscussion, error) {
+	discussion := &DatasetDiscussion{ID: id}
+	var participantsJSON, tagsJSON, keyQuestionsJSON, teamsJSON []byte
+	var subtype, customTopic, customSubtopic, description, purpose sql.NullString
+	var topicID, subtopicID sql.NullInt64
+
+	err := d.db.QueryRow(`
+		SELECT mode, subtype, topic_id, subtopic_id, custom_topic, custom_subtopic,
+			description, purpose, start_time, end_time, EXTRACT(EPOCH FROM duration),
+			participants, tags, key_questions, teams
+		FROM discussions
+		WHERE id = $1`, id).Scan(
+		&discussion.Mode, &subtype, &topicID, &subtopicID, &customTopic, &customSubtopic,
+		&description, &purpose, &discussion.Start, &discussion.End, &discussion.DurationSeconds,
+		&participantsJSON, &tagsJSON, &keyQuestionsJSON, &teamsJSON,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("discussion %d: %w", id, ErrDiscussionNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		data   []byte
+		target any
+		name   string
+	}{
+		{participantsJSON, &discussion.Participants, "participants"},
+		{tagsJSON, &discussion.Tags, "tags"},
+		{keyQuestionsJSON, &discussion.KeyQuestions, "key questions"},
+		{teamsJSON, &discussion.Teams, "teams"},
+	} {
+		if field.data == nil {
+			continue
+		}
+		if err = json.Unmarshal(field.data, field.target); err != nil {
+			return nil, fmt.Errorf("discussion %d: parse %s: %w", id, field.name, err)
+		}
+	}
+
+	discussion.SubType = subtype.String
+	if topicID.Valid {
+		value := int(topicID.Int64)
+		discussion.TopicID = &value
+	}
+	if subtopicID.Valid {
+		value := int(subtopicID.Int64)
+		discussion.SubtopicID = &value
+	}
+	discussion.Topic, discussion.Subtopic = customTopic.String, customSubtopic.String
+	if mode, ok := modes.Get(discussion.Mode, discussion.SubType); ok {
+		discussion.Topic, discussion.Subtopic = mode.TopicNames(int(topicID.Int64), int(subtopicID.Int64), customTopic.String, customSubtopic.String)
+	}
+
+	// свободный текст пишут участники: в нем могут оказаться их имена и контакты
+	participants := discussion.Participants
+	discussion.Topic = d.text(discussion.Topic, participants)
+	discussion.Subtopic = d.text(discussion.Subtopic, participants)
+	discussion.Description = d.text(description.String, participants)
+	discussion.Purpose = d.text(purpose.String, participants)
+	for i, question := range discussion.KeyQuestions {
+		discussion.KeyQuestions[i] = d.text(question, participants)
+	}
+	for i := range discussion.Teams {
+		discussion.Teams[i].Members = d.names.names(discussion.Teams[i].Members)
+	}
+	discussion.Participants = d.names.names(participants)
+	return discussion, nil
+}
+
+// messages вызывает fn для сообщений дискуссии. discussion.Participants к этому моменту
+// уже могут быть псевдонимами, поэтому исходные имена берутся из истории
+func (d *Dataset) messages(discussion *DatasetDiscussion, fn func(DatasetMessage) error) error {
+	stream, err := OpenMessageStream(d.db, d.repos, discussion.ID)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	usernames := make([]string, 0, len(stream.userIDs))
+	for username := range stream.userIDs {
+		usernames = append(usernames, username)
+	}
+	return stream.each(func(row MessageRow) error {
+		row.Content = d.text(row.Content, usernames)
+		if d.names != nil {
+			row.UserID = nil
+			row.Username = d.names.name(row.Username)
+			row.LikedBy = d.names.names(row.LikedBy)
+			row.DislikedBy = d.names.names(row.DislikedBy)
+		}
+		return fn(DatasetMessage{DiscussionID: discussion.ID, MessageRow: row})
+	})
+}
+
+func (d *Dataset) ratings(discussion *DatasetDiscussion, fn func(DatasetRating) error) error {
+	rows, err := d.db.Query(`
+		SELECT r.rater_user_id, rater.username, r.rated_user_id, rated.username,
+			r.professionalism, r.arguments_quality, r.politeness
+		FROM ratings r
+		JOIN users rater ON rater.user_id = r.rater_user_id
+		JOIN users rated ON rated.user_id = r.rated_user_id
+		WHERE r.discussion_id = $1
+		ORDER BY r.id`, discussion.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raterID, ratedID int
+		r := DatasetRating{DiscussionID: discussion.ID}
+		err = rows.Scan(&raterID, &r.Rater, &ratedID, &r.Rated, &r.Professionalism, &r.ArgumentsQuality, &r.Politeness)
+		if err != nil {
+			return err
+		}
+		if d.names != nil {
+			r.Rater, r.Rated = d.names.name(r.Rater), d.names.name(r.Rated)
+		} else {
+			r.RaterID, r.RatedID = &raterID, &ratedID
+		}
+		if err = fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// text обезличивает свободный текст: сначала контакты, затем имена участников
+func (d *Dataset) text(s string, usernames []string) string {
+	if d.options.StripPII {
+		s = stripPII(s)
+	}
+	return d.names.mentions(s, usernames)
+}
@@ -0,0 +1,129 @@
+package exports
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	ErrDiscussionNotFound = errors.New("discussion not found")
+	ErrUnknownKind        = errors.New("unknown export kind")
+)
+
+// Exporter один вид выгрузки дискуссии; Kind совпадает со значением в Room.ExportOptions.
+// Build читает дискуссию через repos, а то, чего в хранилищах нет, — прямо из db
+type Exporter struct {
+	Kind        string
+	ContentType string
+	Extension   string
+	Build       func(db *sql.DB, repos *storage.Repos, discussionID int) ([]byte, error)
+}
+
+var registry = make(map[string]Exporter)
+
+func Register(exporter Exporter) {
+	if _, exists := registry[exporter.Kind]; exists {
+		panic("exports: exporter " + exporter.Kind + " registered twice")
+	}
+	registry[exporter.Kind] = exporter
+}
+
+func Get(kind string) (Exporter, bool) {
+	exporter, ok := registry[kind]
+	return exporter, ok
+}
+
+// Kinds доступные виды выгрузок по алфавиту
+func Kinds() []string {
+	kinds := make([]string, 0, len(registry))
+	for kind := range registry {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Validate проверяет, что все опции выгрузки из запроса известны серверу
+func Validate(options []string) error {
+	for _, option := range options {
+		if _, ok := registry[option]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownKind, option)
+		}
+	}
+	return nil
+}
+
+func Filename(exporter Exporter, discussionID int) string {
+	return fmt.Sprintf("discussion_%d_%s.%s", discussionID, exporter.Kind, exporter.Extension)
+}
+
+// discussionData то, что нужно большинству выгрузок: участники, сообщения и их id в users
+type discussionData struct {
+	ID               int
+	Participants     []string
+	Messages         []structures.Message
+	UsernameToUserID map[string]int
+	UserIDToUsername map[int]string
+}
+
+func loadDiscussion(repos *storage.Repos, discussionID int) (*discussionData, error) {
+	discussion, err := repos.Discussions.Get(discussionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrDiscussionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &discussionData{
+		ID:               discussion.ID,
+		Participants:     discussion.Participants,
+		UserIDToUsername: make(map[int]string),
+	}
+	if data.Messages, err = repos.Discussions.Messages(discussionID); err != nil {
+		return nil, err
+	}
+
+	if data.UsernameToUserID, err = repos.Users.IDs(data.Participants); err != nil {
+		return nil, err
+	}
+	for username, userID := range data.UsernameToUserID {
+		data.UserIDToUsername[userID] = username
+	}
+
+	return data, nil
+}
+
+// loadUserIDs id пользователей по именам; удаленных пользователей в ответе нет
+func loadUserIDs(repos *storage.Repos, usernames []string) (map[string]int, error) {
+	return repos.Users.IDs(usernames)
+}
+
+// rating оценка из таблицы ratings
+type rating struct {
+	RaterID, RatedID int
+	Prof, Arg, Pol   int
+}
+
+func loadRatings(repos *storage.Repos, discussionID int) ([]rating, error) {
+	stored, err := repos.Ratings.ForDiscussion(discussionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make([]rating, len(stored))
+	for i, r := range stored {
+		ratings[i] = rating{
+			RaterID: r.RaterID,
+			RatedID: r.RatedID,
+			Prof:    r.Professionalism,
+			Arg:     r.ArgumentsQuality,
+			Pol:     r.Politeness,
+		}
+	}
+	return ratings, nil
+}
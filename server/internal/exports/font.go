@@ -0,0 +1,548 @@
+package exports
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// стандартные шрифты PDF не содержат кириллицы, поэтому в отчет встраивается DejaVu Sans (лицензия в fonts/LICENSE)
+//
+//go:embed fonts/DejaVuSans.ttf
+var dejaVuSans []byte
+
+var (
+	reportFont     *trueTypeFont
+	reportFontErr  error
+	reportFontOnce sync.Once
+)
+
+func loadReportFont() (*trueTypeFont, error) {
+	reportFontOnce.Do(func() {
+		reportFont, reportFontErr = parseTrueType(dejaVuSans)
+	})
+	return reportFont, reportFontErr
+}
+
+var errBadFont = errors.New("malformed TrueType font")
+
+// trueTypeFont то немногое из TrueType, что нужно для PDF: метрики, cmap и глифы для подмножества
+type trueTypeFont struct {
+	tables     map[string][]byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	numGlyphs  int
+	advances   []int
+	glyphs     map[rune]uint16
+	loca       []int
+}
+
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errBadFont
+	}
+	f := &trueTypeFont{tables: make(map[string][]byte)}
+
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, errBadFont
+		}
+		tag := string(data[record : record+4])
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("%w: table %s out of range", errBadFont, tag)
+		}
+		f.tables[tag] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "loca", "glyf", "cmap"} {
+		if _, ok := f.tables[tag]; !ok {
+			return nil, fmt.Errorf("%w: no %s table", errBadFont, tag)
+		}
+	}
+
+	head := f.tables["head"]
+	if len(head) < 54 {
+		return nil, errBadFont
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	longLoca := binary.BigEndian.Uint16(head[50:]) == 1
+
+	hhea := f.tables["hhea"]
+	if len(hhea) < 36 {
+		return nil, errBadFont
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.capHeight = f.ascent
+	if os2 := f.tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+
+	f.numGlyphs = int(binary.BigEndian.Uint16(f.tables["maxp"][4:]))
+
+	// у глифов после numberOfHMetrics ширина последней записи
+	metrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if metrics == 0 || len(hmtx) < 4*metrics {
+		return nil, errBadFont
+	}
+	f.advances = make([]int, f.numGlyphs)
+	for i := range f.advances {
+		if i < metrics {
+			f.advances[i] = int(binary.BigEndian.Uint16(hmtx[4*i:]))
+		} else {
+			f.advances[i] = f.advances[metrics-1]
+		}
+	}
+
+	loca := f.tables["loca"]
+	f.loca = make([]int, f.numGlyphs+1)
+	for i := range f.loca {
+		if longLoca {
+			if 4*i+4 > len(loca) {
+				return nil, errBadFont
+			}
+			f.loca[i] = int(binary.BigEndian.Uint32(loca[4*i:]))
+		} else {
+			if 2*i+2 > len(loca) {
+				return nil, errBadFont
+			}
+			f.loca[i] = 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		}
+	}
+
+	var err error
+	if f.glyphs, err = parseCmap(f.tables["cmap"]); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// parseCmap читает юникодную таблицу символов: формат 12 (вся Unicode) или 4 (BMP)
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errBadFont
+	}
+	var format4, format12 []byte
+	count := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < count; i++ {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			return nil, errBadFont
+		}
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+4 > len(cmap) || (platform != 0 && !(platform == 3 && (encoding == 1 || encoding == 10))) {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[offset:]) {
+		case 4:
+			format4 = cmap[offset:]
+		case 12:
+			format12 = cmap[offset:]
+		}
+	}
+
+	glyphs := make(map[rune]uint16)
+	switch {
+	case len(format12) >= 16:
+		groups := int(binary.BigEndian.Uint32(format12[12:]))
+		if 16+12*groups > len(format12) {
+			return nil, errBadFont
+		}
+		for i := 0; i < groups; i++ {
+			group := format12[16+12*i:]
+			start := rune(binary.BigEndian.Uint32(group))
+			end := rune(binary.BigEndian.Uint32(group[4:]))
+			glyph := binary.BigEndian.Uint32(group[8:])
+			for r := start; r <= end && r <= 0x10FFFF; r++ {
+				glyphs[r] = uint16(glyph + uint32(r-start))
+			}
+		}
+	case len(format4) >= 14:
+		segments := int(binary.BigEndian.Uint16(format4[6:])) / 2
+		ends, starts := 14, 16+2*segments
+		deltas, rangeOffsets := starts+2*segments, starts+4*segments
+		if rangeOffsets+2*segments > len(format4) {
+			return nil, errBadFont
+		}
+		for i := 0; i < segments; i++ {
+			end := int(binary.BigEndian.Uint16(format4[ends+2*i:]))
+			start := int(binary.BigEndian.Uint16(format4[starts+2*i:]))
+			delta := int(binary.BigEndian.Uint16(format4[deltas+2*i:]))
+			rangeOffset := int(binary.BigEndian.Uint16(format4[rangeOffsets+2*i:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				glyph := (c + delta) & 0xFFFF
+				if rangeOffset != 0 {
+					at := rangeOffsets + 2*i + rangeOffset + 2*(c-start)
+					if at+2 > len(format4) {
+						continue
+					}
+					if glyph = int(binary.BigEndian.Uint16(format4[at:])); glyph != 0 {
+						glyph = (glyph + delta) & 0xFFFF
+					}
+				}
+				if glyph != 0 {
+					glyphs[rune(c)] = uint16(glyph)
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: no unicode cmap", errBadFont)
+	}
+	return glyphs, nil
+}
+
+// glyph номер глифа для символа; 0 — .notdef, если символа в шрифте нет
+func (f *trueTypeFont) glyph(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// advance ширина глифа в тысячных долях кегля, как ее ожидает PDF
+func (f *trueTypeFont) advance(glyph uint16) float64 {
+	if int(glyph) >= len(f.advances) {
+		return 0
+	}
+	return float64(f.advances[glyph]) * 1000 / float64(f.unitsPerEm)
+}
+
+func (f *trueTypeFont) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
+
+// subset копия шрифта, в которой оставлены только нужные глифы: так отчет весит десятки килобайт, а не мегабайт.
+// Номера глифов не меняются, поэтому текст можно кодировать номерами глифов исходного шрифта
+func (f *trueTypeFont) subset(used map[uint16]rune) []byte {
+	keep := map[uint16]bool{0: true}
+	queue := make([]uint16, 0, len(used))
+	for glyph := range used {
+		queue = append(queue, glyph)
+	}
+	// составные глифы (буквы с диакритикой) ссылаются на другие глифы — их тоже нужно сохранить
+	for len(queue) > 0 {
+		glyph := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if keep[glyph] || int(glyph) >= f.numGlyphs {
+			continue
+		}
+		keep[glyph] = true
+		queue = append(queue, f.components(glyph)...)
+	}
+
+	glyf := f.tables["glyf"]
+	var newGlyf bytes.Buffer
+	newLoca := make([]byte, 4*(f.numGlyphs+1))
+	for glyph := 0; glyph < f.numGlyphs; glyph++ {
+		binary.BigEndian.PutUint32(newLoca[4*glyph:], uint32(newGlyf.Len()))
+		if keep[uint16(glyph)] {
+			newGlyf.Write(glyf[f.loca[glyph]:f.loca[glyph+1]])
+			for newGlyf.Len()%4 != 0 {
+				newGlyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*f.numGlyphs:], uint32(newGlyf.Len()))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint32(head[8:], 0) // checkSumAdjustment пересчитывает writeFontFile
+	binary.BigEndian.PutUint16(head[50:], 1)
+
+	tables := map[string][]byte{
+		"head": head,
+		"hhea": f.tables["hhea"],
+		"hmtx": f.tables["hmtx"],
+		"maxp": f.tables["maxp"],
+		"loca": newLoca,
+		"glyf": newGlyf.Bytes(),
+	}
+	// таблицы хинтинга нужны, чтобы глифы рисовались так же, как в исходном шрифте; cmap ждут некоторые просмотрщики
+	for _, tag := range []string{"cmap", "cvt ", "fpgm", "prep"} {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+
+	return writeFontFile(tables)
+}
+
+// флаги компонента составного глифа
+const (
+	componentArgsAreWords    = 0x0001
+	componentArgsAreXYValues = 0x0002
+	componentHaveScale       = 0x0008
+	componentMore            = 0x0020
+	componentHaveXYScale     = 0x0040
+	componentHaveTwoByTwo    = 0x0080
+)
+
+// components глифы, из которых собран составной глиф
+func (f *trueTypeFont) components(glyph uint16) []uint16 {
+	data := f.tables["glyf"][f.loca[glyph]:f.loca[glyph+1]]
+	if len(data) < 10 || int16(binary.BigEndian.Uint16(data)) >= 0 {
+		return nil
+	}
+
+	var components []uint16
+	for at := 10; at+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[at:])
+		components = append(components, binary.BigEndian.Uint16(data[at+2:]))
+		at += 4
+		if flags&componentArgsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&componentHaveScale != 0:
+			at += 2
+		case flags&componentHaveXYScale != 0:
+			at += 4
+		case flags&componentHaveTwoByTwo != 0:
+			at += 8
+		}
+		if flags&componentMore == 0 {
+			break
+		}
+	}
+	return components
+}
+
+func writeFontFile(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	searchRange, entrySelector := 1, 0
+	for searchRange*2 <= len(tags) {
+		searchRange *= 2
+		entrySelector++
+	}
+
+	var buf bytes.Buffer
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(len(tags)))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange*16))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16((len(tags)-searchRange)*16))
+	buf.Write(header)
+
+	offset, headAt := 12+16*len(tags), 0
+	for _, tag := range tags {
+		if tag == "head" {
+			headAt = offset
+		}
+		table := tables[tag]
+		record := make([]byte, 16)
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], tableChecksum(table))
+		binary.BigEndian.PutUint32(record[8:], uint32(offset))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table)))
+		buf.Write(record)
+		offset += (len(table) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		buf.Write(tables[tag])
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+
+	font := buf.Bytes()
+	binary.BigEndian.PutUint32(font[headAt+8:], 0xB1B0AFBA-tableChecksum(font))
+	return font
+}
+
+func tableChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// fontPoint точка контура глифа в единицах шрифта; On — точка на кривой, иначе контрольная
+type fontPoint struct {
+	X, Y float64
+	On   bool
+}
+
+// outline контуры глифа; составные глифы раскрываются с их смещениями и масштабом
+func (f *trueTypeFont) outline(glyph uint16) [][]fontPoint {
+	return f.outlineDepth(glyph, 0)
+}
+
+func (f *trueTypeFont) outlineDepth(glyph uint16, depth int) [][]fontPoint {
+	if int(glyph) >= f.numGlyphs || depth > 8 {
+		return nil
+	}
+	data := f.tables["glyf"][f.loca[glyph]:f.loca[glyph+1]]
+	if len(data) < 10 {
+		return nil
+	}
+	contours := int(int16(binary.BigEndian.Uint16(data)))
+	if contours < 0 {
+		return f.compositeOutline(data, depth)
+	}
+
+	at := 10 + 2*contours
+	if at+2 > len(data) {
+		return nil
+	}
+	ends := make([]int, contours)
+	for i := range ends {
+		ends[i] = int(binary.BigEndian.Uint16(data[10+2*i:]))
+	}
+	if contours == 0 {
+		return nil
+	}
+	points := ends[contours-1] + 1
+	at += 2 + int(binary.BigEndian.Uint16(data[at:])) // инструкции хинтинга пропускаются
+
+	const (
+		onCurve     = 0x01
+		xShort      = 0x02
+		yShort      = 0x04
+		repeat      = 0x08
+		xSameOrPlus = 0x10
+		ySameOrPlus = 0x20
+	)
+	flags := make([]byte, 0, points)
+	for len(flags) < points && at < len(data) {
+		flag := data[at]
+		at++
+		flags = append(flags, flag)
+		if flag&repeat != 0 && at < len(data) {
+			for n := data[at]; n > 0 && len(flags) < points; n-- {
+				flags = append(flags, flag)
+			}
+			at++
+		}
+	}
+	if len(flags) < points {
+		return nil
+	}
+
+	coordinates := func(short, sameOrPlus byte) []float64 {
+		values := make([]float64, points)
+		value := 0
+		for i, flag := range flags {
+			switch {
+			case flag&short != 0:
+				if at >= len(data) {
+					return nil
+				}
+				if flag&sameOrPlus != 0 {
+					value += int(data[at])
+				} else {
+					value -= int(data[at])
+				}
+				at++
+			case flag&sameOrPlus == 0:
+				if at+2 > len(data) {
+					return nil
+				}
+				value += int(int16(binary.BigEndian.Uint16(data[at:])))
+				at += 2
+			}
+			values[i] = float64(value)
+		}
+		return values
+	}
+	xs := coordinates(xShort, xSameOrPlus)
+	ys := coordinates(yShort, ySameOrPlus)
+	if xs == nil || ys == nil {
+		return nil
+	}
+
+	result := make([][]fontPoint, 0, contours)
+	start := 0
+	for _, end := range ends {
+		if end < start || end >= points {
+			return nil
+		}
+		contour := make([]fontPoint, 0, end-start+1)
+		for i := start; i <= end; i++ {
+			contour = append(contour, fontPoint{X: xs[i], Y: ys[i], On: flags[i]&onCurve != 0})
+		}
+		result = append(result, contour)
+		start = end + 1
+	}
+	return result
+}
+
+func (f *trueTypeFont) compositeOutline(data []byte, depth int) [][]fontPoint {
+	f2dot14 := func(b []byte) float64 {
+		return float64(int16(binary.BigEndian.Uint16(b))) / 16384
+	}
+
+	var result [][]fontPoint
+	for at := 10; at+4 <= len(data); {
+		flags := binary.BigEndian.Uint16(data[at:])
+		component := binary.BigEndian.Uint16(data[at+2:])
+		at += 4
+
+		var dx, dy float64
+		if flags&componentArgsAreWords != 0 {
+			if at+4 > len(data) {
+				break
+			}
+			dx, dy = float64(int16(binary.BigEndian.Uint16(data[at:]))), float64(int16(binary.BigEndian.Uint16(data[at+2:])))
+			at += 4
+		} else {
+			if at+2 > len(data) {
+				break
+			}
+			dx, dy = float64(int8(data[at])), float64(int8(data[at+1]))
+			at += 2
+		}
+		// привязка компонента по номерам точек встречается редко, такой компонент ставится без смещения
+		if flags&componentArgsAreXYValues == 0 {
+			dx, dy = 0, 0
+		}
+
+		a, b, c, d := 1.0, 0.0, 0.0, 1.0
+		switch {
+		case flags&componentHaveScale != 0 && at+2 <= len(data):
+			a = f2dot14(data[at:])
+			d = a
+			at += 2
+		case flags&componentHaveXYScale != 0 && at+4 <= len(data):
+			a, d = f2dot14(data[at:]), f2dot14(data[at+2:])
+			at += 4
+		case flags&componentHaveTwoByTwo != 0 && at+8 <= len(data):
+			a, b, c, d = f2dot14(data[at:]), f2dot14(data[at+2:]), f2dot14(data[at+4:]), f2dot14(data[at+6:])
+			at += 8
+		}
+
+		for _, contour := range f.outlineDepth(component, depth+1) {
+			transformed := make([]fontPoint, len(contour))
+			for i, p := range contour {
+				transformed[i] = fontPoint{X: a*p.X + c*p.Y + dx, Y: b*p.X + d*p.Y + dy, On: p.On}
+			}
+			result = append(result, transformed)
+		}
+		if flags&componentMore == 0 {
+			break
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package exports
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFontSubset(t *testing.T) {
+	font, err := loadReportFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ё, й и é в DejaVu Sans составные: их части должны попасть в подмножество
+	used := make(map[uint16]rune)
+	for _, r := range "Привет, world! ёйé" {
+		used[font.glyph(r)] = r
+	}
+	data := font.subset(used)
+	if sum := tableChecksum(data); sum != 0xB1B0AFBA {
+		t.Fatalf("font checksum = %#x, want 0xB1B0AFBA", sum)
+	}
+
+	subset, err := parseTrueType(data)
+	if err != nil {
+		t.Fatalf("subset does not parse: %v", err)
+	}
+	if subset.numGlyphs != font.numGlyphs {
+		t.Fatalf("subset has %d glyphs, want %d: glyph ids must not change", subset.numGlyphs, font.numGlyphs)
+	}
+
+	kept := func(glyph uint16) []byte {
+		return subset.tables["glyf"][subset.loca[glyph]:subset.loca[glyph+1]]
+	}
+	original := func(glyph uint16) []byte {
+		return font.tables["glyf"][font.loca[glyph]:font.loca[glyph+1]]
+	}
+
+	composites := 0
+	for glyph, r := range used {
+		if got := subset.glyph(r); got != glyph {
+			t.Errorf("cmap maps %q to glyph %d, want %d", r, got, glyph)
+		}
+		if got, want := kept(glyph), original(glyph); !bytes.HasPrefix(got, want) || len(got)-len(want) >= 4 {
+			t.Errorf("glyph %d for %q: got %d bytes, want %d", glyph, r, len(got), len(want))
+		}
+		if subset.advance(glyph) != font.advance(glyph) {
+			t.Errorf("advance of %q changed", r)
+		}
+		for _, component := range font.components(glyph) {
+			composites++
+			if !bytes.HasPrefix(kept(component), original(component)) {
+				t.Errorf("component %d of %q is dropped", component, r)
+			}
+		}
+	}
+	if composites == 0 {
+		t.Fatal("no composite glyphs in the sample")
+	}
+
+	unused := font.glyph('Ж')
+	if len(original(unused)) == 0 || len(kept(unused)) != 0 {
+		t.Fatalf("unused glyph %d is kept with %d bytes", unused, len(kept(unused)))
+	}
+}
@@ -0,0 +1,326 @@
+package exports
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/package/logger"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownGraphFormat = errors.New("unknown graph format")
+
+func init() {
+	Register(Exporter{
+		Kind:        "graph",
+		ContentType: "image/png",
+		Extension:   "png",
+		Build: func(_ *sql.DB, repos *storage.Repos, discussionID int) ([]byte, error) {
+			return BuildGraphPNG(repos, discussionID)
+		},
+	})
+}
+
+type InteractionEdge struct {
+	Source string  // имя пользователя, осуществившего действие
+	Target string  // имя пользователя, которому адресовано действие
+	Type   string  // тип действия: "like", "dislike", "rating"
+	Count  int     // количество взаимодействий
+	Avg    float64 // среднее значение рейтинга
+}
+
+type UserMetrics struct {
+	Username         string
+	MessagesSent     int
+	LikesReceived    int
+	DislikesReceived int
+	LikesGiven       int
+	DislikesGiven    int
+	TotalRating      float64
+	RatingCount      int
+	Discussions      int // в скольких дискуссиях участвовал; больше 1 только в объединенной сети
+}
+
+// InteractionGraph участники и их взаимодействия: реакции на сообщения и взаимные оценки
+type InteractionGraph struct {
+	Nodes []*UserMetrics     // в порядке списка участников
+	Edges []*InteractionEdge // по источнику, получателю и типу
+}
+
+// GraphFormat способ отрисовки графа, выбирается параметром ?format=
+type GraphFormat struct {
+	ContentType string
+	Render      func(graph *InteractionGraph) ([]byte, error)
+}
+
+var graphFormats = map[string]GraphFormat{
+	"png": {ContentType: "image/png", Render: renderGraphPNG},
+	"svg": {ContentType: "image/svg+xml", Render: renderGraphSVG},
+	"dot": {ContentType: "text/vnd.graphviz; charset=utf-8", Render: renderGraphDOT},
+}
+
+// BuildGraph граф взаимодействий дискуссии в одном из форматов graphFormats
+func BuildGraph(repos *storage.Repos, discussionID int, format string) ([]byte, GraphFormat, error) {
+	graphFormat, ok := graphFormats[format]
+	if !ok {
+		return nil, GraphFormat{}, fmt.Errorf("%w: %s", ErrUnknownGraphFormat, format)
+	}
+	graph, err := LoadInteractionGraph(repos, discussionID)
+	if err != nil {
+		return nil, graphFormat, err
+	}
+	data, err := graphFormat.Render(graph)
+	return data, graphFormat, err
+}
+
+// BuildGraphPNG граф взаимодействий участников: реакции и взаимные оценки
+func BuildGraphPNG(repos *storage.Repos, discussionID int) ([]byte, error) {
+	data, _, err := BuildGraph(repos, discussionID, "png")
+	return data, err
+}
+
+func LoadInteractionGraph(repos *storage.Repos, discussionID int) (*InteractionGraph, error) {
+	discussion, err := loadDiscussion(repos, discussionID)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &InteractionGraph{}
+	userMetrics := make(map[string]*UserMetrics)
+	for _, username := range discussion.Participants {
+		if _, exists := userMetrics[username]; exists {
+			continue
+		}
+		userMetrics[username] = &UserMetrics{Username: username, Discussions: 1}
+		graph.Nodes = append(graph.Nodes, userMetrics[username])
+	}
+
+	edgesMap := make(map[string]*InteractionEdge)
+
+	for _, msg := range discussion.Messages {
+		if msg.Type != "usual" {
+			continue
+		}
+		author := msg.Username
+		if um, ok := userMetrics[author]; ok {
+			um.MessagesSent++
+		}
+
+		for _, liker := range msg.LikedBy {
+			key := fmt.Sprintf("%s|%s|like", liker, author)
+			if edge, exists := edgesMap[key]; exists {
+				edge.Count++
+			} else {
+				edgesMap[key] = &InteractionEdge{
+					Source: liker,
+					Target: author,
+					Type:   "like",
+					Count:  1,
+				}
+			}
+			if um, ok := userMetrics[author]; ok {
+				um.LikesReceived++
+			}
+			if um, ok := userMetrics[liker]; ok {
+				um.LikesGiven++
+			}
+		}
+
+		for _, disliker := range msg.DislikedBy {
+			key := fmt.Sprintf("%s|%s|dislike", disliker, author)
+			if edge, exists := edgesMap[key]; exists {
+				edge.Count++
+			} else {
+				edgesMap[key] = &InteractionEdge{
+					Source: disliker,
+					Target: author,
+					Type:   "dislike",
+					Count:  1,
+				}
+			}
+			if um, ok := userMetrics[author]; ok {
+				um.DislikesReceived++
+			}
+			if um, ok := userMetrics[disliker]; ok {
+				um.DislikesGiven++
+			}
+		}
+	}
+
+	ratings, err := loadRatings(repos, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range ratings {
+		raterID, ratedID, prof, arg, pol := r.RaterID, r.RatedID, r.Prof, r.Arg, r.Pol
+		raterUsername, ok1 := discussion.UserIDToUsername[raterID]
+		ratedUsername, ok2 := discussion.UserIDToUsername[ratedID]
+		if !ok1 || !ok2 {
+			logger.Log.Errorf("User IDs %d or %d not found", raterID, ratedID)
+			continue
+		}
+		avgRating := float64(prof+arg+pol) / 3.0
+		key := fmt.Sprintf("%s|%s|rating", raterUsername, ratedUsername)
+		if edge, exists := edgesMap[key]; exists {
+			totalRating := edge.Avg*float64(edge.Count) + avgRating
+			edge.Count++
+			edge.Avg = totalRating / float64(edge.Count)
+		} else {
+			edgesMap[key] = &InteractionEdge{
+				Source: raterUsername,
+				Target: ratedUsername,
+				Type:   "rating",
+				Count:  1,
+				Avg:    avgRating,
+			}
+		}
+		if um, ok := userMetrics[ratedUsername]; ok {
+			um.TotalRating += avgRating
+			um.RatingCount++
+		}
+	}
+
+	for _, edge := range edgesMap {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sortEdges(graph.Edges)
+
+	// реагировать могли и те, кого нет в списке участников; как и Graphviz, рисуем их узлами без статистики
+	for _, edge := range graph.Edges {
+		for _, username := range []string{edge.Source, edge.Target} {
+			if _, exists := userMetrics[username]; !exists {
+				userMetrics[username] = &UserMetrics{Username: username, Discussions: 1}
+				graph.Nodes = append(graph.Nodes, userMetrics[username])
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+func sortEdges(edges []*InteractionEdge) {
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		return a.Type < b.Type
+	})
+}
+
+// именованные цвета X11, как в прежнем выводе Graphviz; в PNG и SVG те же значения
+var graphColors = map[string]color.RGBA{
+	"black":     {0x00, 0x00, 0x00, 0xff},
+	"blue":      {0x00, 0x00, 0xff, 0xff},
+	"green":     {0x00, 0xff, 0x00, 0xff},
+	"red":       {0xff, 0x00, 0x00, 0xff},
+	"lightgray": {0xd3, 0xd3, 0xd3, 0xff},
+	"palegreen": {0x98, 0xfb, 0x98, 0xff},
+}
+
+// nodeStyle оформление участника одинаково во всех форматах
+type nodeStyle struct {
+	FontSize    float64 // в пунктах
+	FillColor   string
+	BorderColor string
+}
+
+func (g *InteractionGraph) nodeStyle(um *UserMetrics) nodeStyle {
+	maxMessages := 1
+	for _, node := range g.Nodes {
+		if node.MessagesSent > maxMessages {
+			maxMessages = node.MessagesSent
+		}
+	}
+
+	// размер шрифта пропорционален количеству сообщений
+	style := nodeStyle{
+		FontSize:    12 + (float64(um.MessagesSent)/float64(maxMessages))*8,
+		FillColor:   "lightgray",
+		BorderColor: "black",
+	}
+
+	// изменяем цвет узла на основе позитивности (лайки - дизлайки)
+	if um.LikesReceived-um.DislikesReceived > 0 {
+		style.FillColor = "palegreen"
+	}
+
+	// если среднее значение рейтинга высокое, изменяем цвет рамки
+	if um.RatingCount > 0 && um.TotalRating/float64(um.RatingCount) > 4.0 {
+		style.BorderColor = "blue"
+	}
+	return style
+}
+
+type edgeStyle struct {
+	Color    string
+	PenWidth int
+	Dashed   bool
+	Label    string
+}
+
+func (edge *InteractionEdge) style() edgeStyle {
+	style := edgeStyle{PenWidth: 2} // базовая толщина линии
+
+	// для лайков и дизлайков увеличиваем толщину стрелки, если их много.
+	switch edge.Type {
+	case "like":
+		if edge.Count > 3 {
+			style.PenWidth = 2 + edge.Count/3
+		}
+		style.Color = "green"
+		style.Label = fmt.Sprintf("Likes: %d", edge.Count)
+	case "dislike":
+		if edge.Count > 3 {
+			style.PenWidth = 2 + edge.Count/3
+		}
+		style.Color = "red"
+		style.Label = fmt.Sprintf("Dislikes: %d", edge.Count)
+	case "rating":
+		style.Color = "blue"
+		style.Dashed = true
+		style.Label = fmt.Sprintf("Rating: %.1f (%d)", edge.Avg, edge.Count)
+	}
+	return style
+}
+
+// dotEscaper экранирует строку в кавычках DOT; обратная косая черта удваивается,
+// иначе Graphviz прочитает \N или \G в имени как подстановку
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// renderGraphDOT исходник для Graphviz — для тех, кто хочет доработать граф сам
+func renderGraphDOT(g *InteractionGraph) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("digraph DiscussionGraph {\n")
+	buf.WriteString("\trankdir=LR;\n")
+	buf.WriteString("\tnode [shape=ellipse, style=filled];\n")
+
+	for _, um := range g.Nodes {
+		style := g.nodeStyle(um)
+		fmt.Fprintf(&buf, "\t%s [fontsize=%.1f, fillcolor=%s, color=%s];\n",
+			dotQuote(um.Username), style.FontSize, style.FillColor, style.BorderColor)
+	}
+
+	for _, edge := range g.Edges {
+		style := edge.style()
+		attributes := fmt.Sprintf("color=%s, penwidth=%d", style.Color, style.PenWidth)
+		if style.Dashed {
+			attributes = fmt.Sprintf("color=%s, style=dashed, penwidth=%d", style.Color, style.PenWidth)
+		}
+		fmt.Fprintf(&buf, "\t%s -> %s [label=%s, %s];\n",
+			dotQuote(edge.Source), dotQuote(edge.Target), dotQuote(style.Label), attributes)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
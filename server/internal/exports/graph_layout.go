@@ -0,0 +1,420 @@
+package exports
+
+import (
+	"math"
+	"sort"
+)
+
+// pxPerPt пунктов Graphviz в пикселе: узлы и подписи того же размера, что и в прежних PNG
+const pxPerPt = 4.0 / 3.0
+
+// graphScene граф, разложенный на плоскости: общий для PNG и SVG, чтобы картинки совпадали
+type graphScene struct {
+	Width, Height float64
+	Nodes         []sceneNode
+	Edges         []sceneEdge
+}
+
+type sceneNode struct {
+	Center    point
+	RX, RY    float64
+	FontSize  float64 // в пикселях
+	TextWidth float64
+	Label     string
+	Fill      string
+	Border    string
+}
+
+type sceneEdge struct {
+	Path       []point // линия до основания стрелки
+	Arrow      []point // треугольник наконечника
+	Color      string
+	Width      float64
+	Dashed     bool
+	Label      string
+	LabelAt    point // центр подписи
+	LabelWidth float64
+	LabelSize  float64
+}
+
+const (
+	edgeLabelSize  = 11 * pxPerPt
+	edgeSpacing    = 30.0 // расстояние между параллельными ребрами одной пары участников
+	loopSpacing    = 28.0
+	layoutRounds   = 300
+	overlapRounds  = 100
+	sceneMargin    = 20.0
+	nodeClearance  = 24.0
+	labelPadding   = 3.0
+	curveSegments  = 24
+	layoutGravity  = 0.05
+	minimumSpacing = 0.01
+	componentGap   = 40.0 // между компонентами связности
+)
+
+// layoutGraph раскладывает граф силовым методом Фрюхтермана — Рейнгольда: узлы отталкиваются,
+// связанные притягиваются. Начальная расстановка по кругу делает результат детерминированным
+func layoutGraph(g *InteractionGraph, font *trueTypeFont) *graphScene {
+	scene := &graphScene{}
+	index := make(map[string]int, len(g.Nodes))
+	maxRX := 0.0
+	for i, um := range g.Nodes {
+		style := g.nodeStyle(um)
+		size := style.FontSize * pxPerPt
+		width := textWidth(font, um.Username, size)
+		// эллипс описан вокруг прямоугольника подписи, как у Graphviz
+		ry := size*0.6*math.Sqrt2 + 4
+		rx := math.Max(width/2*math.Sqrt2+6, ry*1.4)
+		scene.Nodes = append(scene.Nodes, sceneNode{
+			RX: rx, RY: ry, FontSize: size, TextWidth: width,
+			Label: um.Username, Fill: style.FillColor, Border: style.BorderColor,
+		})
+		index[um.Username] = i
+		maxRX = math.Max(maxRX, rx)
+	}
+
+	positions := componentLayout(scene.Nodes, graphLinks(g, index), 160+maxRX)
+	for i := range scene.Nodes {
+		scene.Nodes[i].Center = positions[i]
+	}
+
+	// ребра одной пары участников разводятся дугами в разные стороны от прямой
+	pairs := make(map[[2]int][]*InteractionEdge)
+	var pairOrder [][2]int
+	for _, edge := range g.Edges {
+		from, to := index[edge.Source], index[edge.Target]
+		key := [2]int{from, to}
+		if from > to {
+			key = [2]int{to, from}
+		}
+		if _, exists := pairs[key]; !exists {
+			pairOrder = append(pairOrder, key)
+		}
+		pairs[key] = append(pairs[key], edge)
+	}
+	for _, key := range pairOrder {
+		for i, edge := range pairs[key] {
+			scene.Edges = append(scene.Edges, sceneEdgeFor(scene, edge, index, i, len(pairs[key]), font))
+		}
+	}
+
+	scene.fit()
+	return scene
+}
+
+// graphLinks пары связанных узлов без петель и повторов: для раскладки важна только связность
+func graphLinks(g *InteractionGraph, index map[string]int) [][2]int {
+	var links [][2]int
+	linked := make(map[[2]int]bool)
+	for _, edge := range g.Edges {
+		from, to := index[edge.Source], index[edge.Target]
+		if from == to {
+			continue
+		}
+		if from > to {
+			from, to = to, from
+		}
+		if !linked[[2]int{from, to}] {
+			linked[[2]int{from, to}] = true
+			links = append(links, [2]int{from, to})
+		}
+	}
+	return links
+}
+
+// componentLayout раскладывает каждую компоненту связности отдельно и укладывает их рядами,
+// крупные первыми. Вместе силовой метод отталкивает несвязанных участников далеко от центра,
+// и картинка выходит почти пустой
+func componentLayout(nodes []sceneNode, links [][2]int, k float64) []point {
+	parent := make([]int, len(nodes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+	for _, link := range links {
+		parent[root(link[0])] = root(link[1])
+	}
+
+	var components [][]int
+	componentOf := make(map[int]int)
+	for i := range nodes {
+		r := root(i)
+		if _, ok := componentOf[r]; !ok {
+			componentOf[r] = len(components)
+			components = append(components, nil)
+		}
+		components[componentOf[r]] = append(components[componentOf[r]], i)
+	}
+	sort.SliceStable(components, func(i, j int) bool { return len(components[i]) > len(components[j]) })
+
+	type block struct {
+		members   []int
+		positions []point
+		low, high point
+	}
+	blocks := make([]block, len(components))
+	area, widest := 0.0, 0.0
+	for c, members := range components {
+		local := make(map[int]int, len(members))
+		for i, member := range members {
+			local[member] = i
+		}
+		var localLinks [][2]int
+		for _, link := range links {
+			if from, ok := local[link[0]]; ok {
+				localLinks = append(localLinks, [2]int{from, local[link[1]]})
+			}
+		}
+		localNodes := make([]sceneNode, len(members))
+		for i, member := range members {
+			localNodes[i] = nodes[member]
+		}
+
+		positions := forceLayout(len(members), localLinks, k)
+		separateNodes(localNodes, positions)
+
+		b := block{members: members, positions: positions,
+			low: point{math.Inf(1), math.Inf(1)}, high: point{math.Inf(-1), math.Inf(-1)}}
+		for i, node := range localNodes {
+			b.low = point{math.Min(b.low.X, positions[i].X-node.RX), math.Min(b.low.Y, positions[i].Y-node.RY)}
+			b.high = point{math.Max(b.high.X, positions[i].X+node.RX), math.Max(b.high.Y, positions[i].Y+node.RY)}
+		}
+		size := b.high.sub(b.low)
+		area += (size.X + componentGap) * (size.Y + componentGap)
+		widest = math.Max(widest, size.X)
+		blocks[c] = b
+	}
+
+	// ряды примерно квадратной картинки, но не уже самой широкой компоненты
+	rowWidth := math.Max(widest, math.Sqrt(area))
+	result := make([]point, len(nodes))
+	x, y, rowHeight := 0.0, 0.0, 0.0
+	for _, b := range blocks {
+		size := b.high.sub(b.low)
+		if x > 0 && x+size.X > rowWidth {
+			x, y, rowHeight = 0, y+rowHeight+componentGap, 0
+		}
+		shift := point{x, y}.sub(b.low)
+		for i, member := range b.members {
+			result[member] = b.positions[i].add(shift)
+		}
+		x += size.X + componentGap
+		rowHeight = math.Max(rowHeight, size.Y)
+	}
+	return result
+}
+
+func forceLayout(n int, links [][2]int, k float64) []point {
+	positions := make([]point, n)
+	radius := math.Max(k/2, k*float64(n)/(2*math.Pi))
+	for i := range positions {
+		if n == 1 {
+			break
+		}
+		angle := 2*math.Pi*float64(i)/float64(n) - math.Pi/2
+		positions[i] = point{radius * math.Cos(angle), radius * math.Sin(angle)}
+	}
+
+	displacement := make([]point, n)
+	for round := 0; round < layoutRounds; round++ {
+		temperature := k*(1-float64(round)/layoutRounds) + 1
+		for i := range displacement {
+			displacement[i] = positions[i].scale(-layoutGravity)
+		}
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				delta := positions[i].sub(positions[j])
+				distance := delta.length()
+				if distance < minimumSpacing {
+					delta, distance = point{float64(j - i), 0}, float64(j-i)
+				}
+				push := delta.scale(k * k / distance / distance)
+				displacement[i] = displacement[i].add(push)
+				displacement[j] = displacement[j].sub(push)
+			}
+		}
+		for _, link := range links {
+			delta := positions[link[0]].sub(positions[link[1]])
+			pull := delta.scale(delta.length() / k)
+			displacement[link[0]] = displacement[link[0]].sub(pull)
+			displacement[link[1]] = displacement[link[1]].add(pull)
+		}
+		for i := range positions {
+			step := math.Min(displacement[i].length(), temperature)
+			positions[i] = positions[i].add(displacement[i].unit().scale(step))
+		}
+	}
+	return positions
+}
+
+// separateNodes раздвигает узлы, эллипсы которых перекрываются: силовой метод не знает их размеров
+func separateNodes(nodes []sceneNode, positions []point) {
+	for round := 0; round < overlapRounds; round++ {
+		moved := false
+		for i := range nodes {
+			for j := i + 1; j < len(nodes); j++ {
+				delta := positions[j].sub(positions[i])
+				overlapX := nodes[i].RX + nodes[j].RX + nodeClearance - math.Abs(delta.X)
+				overlapY := nodes[i].RY + nodes[j].RY + nodeClearance - math.Abs(delta.Y)
+				if overlapX <= 0 || overlapY <= 0 {
+					continue
+				}
+				moved = true
+				shift := point{0, overlapY / 2}
+				if overlapX < overlapY {
+					shift = point{overlapX / 2, 0}
+				}
+				if (shift.X != 0 && delta.X < 0) || (shift.Y != 0 && delta.Y < 0) {
+					shift = shift.scale(-1)
+				}
+				positions[i] = positions[i].sub(shift)
+				positions[j] = positions[j].add(shift)
+			}
+		}
+		if !moved {
+			return
+		}
+	}
+}
+
+func sceneEdgeFor(scene *graphScene, edge *InteractionEdge, index map[string]int, order, count int, font *trueTypeFont) sceneEdge {
+	style := edge.style()
+	result := sceneEdge{
+		Color:     style.Color,
+		Width:     float64(style.PenWidth) * pxPerPt,
+		Dashed:    style.Dashed,
+		Label:     style.Label,
+		LabelSize: edgeLabelSize,
+	}
+	result.LabelWidth = textWidth(font, result.Label, result.LabelSize)
+
+	source, target := scene.Nodes[index[edge.Source]], scene.Nodes[index[edge.Target]]
+	var curve [4]point
+	if edge.Source == edge.Target {
+		// петля над узлом; несколько петель вложены друг в друга
+		lift := source.RY + 30 + loopSpacing*float64(order)
+		start := ellipsePoint(source, -125)
+		end := ellipsePoint(source, -55)
+		curve = [4]point{start, start.add(point{-lift * 0.6, -lift * 1.3}), end.add(point{lift * 0.6, -lift * 1.3}), end}
+	} else {
+		from, to := source.Center, target.Center
+		if index[edge.Source] > index[edge.Target] {
+			from, to = to, from
+		}
+		offset := (float64(order) - float64(count-1)/2) * edgeSpacing
+		control := lerp(from, to, 0.5).add(to.sub(from).unit().normal().scale(2 * offset))
+		start := ellipseBoundary(source, control)
+		end := ellipseBoundary(target, control)
+		// квадратичная кривая, записанная кубической
+		curve = [4]point{start, lerp(start, control, 2.0/3), lerp(end, control, 2.0/3), end}
+	}
+
+	path := make([]point, 0, curveSegments+1)
+	for i := 0; i <= curveSegments; i++ {
+		path = append(path, cubicAt(curve, float64(i)/curveSegments))
+	}
+
+	arrowLength := 10 + result.Width*1.5
+	direction := curve[3].sub(curve[2]).unit()
+	if direction == (point{}) {
+		direction = curve[3].sub(curve[0]).unit()
+	}
+	base := curve[3].sub(direction.scale(arrowLength))
+	wing := direction.normal().scale(arrowLength * 0.45)
+	result.Arrow = []point{curve[3], base.add(wing), base.sub(wing)}
+	path[len(path)-1] = base
+	result.Path = path
+
+	result.LabelAt = cubicAt(curve, 0.5)
+	if edge.Source == edge.Target {
+		result.LabelAt = result.LabelAt.sub(point{0, result.LabelSize})
+	}
+	return result
+}
+
+func cubicAt(curve [4]point, t float64) point {
+	a, b, c := lerp(curve[0], curve[1], t), lerp(curve[1], curve[2], t), lerp(curve[2], curve[3], t)
+	return quadAt(a, b, c, t)
+}
+
+// ellipseBoundary точка на границе узла в сторону toward
+func ellipseBoundary(node sceneNode, toward point) point {
+	direction := toward.sub(node.Center)
+	if direction == (point{}) {
+		return node.Center
+	}
+	t := 1 / math.Sqrt(math.Pow(direction.X/node.RX, 2)+math.Pow(direction.Y/node.RY, 2))
+	return node.Center.add(direction.scale(t))
+}
+
+// ellipsePoint точка на границе узла под углом в градусах; отрицательные углы — верх
+func ellipsePoint(node sceneNode, degrees float64) point {
+	angle := degrees * math.Pi / 180
+	return node.Center.add(point{node.RX * math.Cos(angle), node.RY * math.Sin(angle)})
+}
+
+func (e sceneEdge) labelBox() (point, point) {
+	half := point{e.LabelWidth/2 + labelPadding, e.LabelSize*0.6 + labelPadding}
+	return e.LabelAt.sub(half), e.LabelAt.add(half)
+}
+
+// fit сдвигает сцену в положительные координаты и вычисляет размер холста по всем элементам
+func (s *graphScene) fit() {
+	if len(s.Nodes) == 0 {
+		s.Width, s.Height = 2*sceneMargin, 2*sceneMargin
+		return
+	}
+	minimum := point{math.Inf(1), math.Inf(1)}
+	maximum := point{math.Inf(-1), math.Inf(-1)}
+	include := func(p point) {
+		minimum = point{math.Min(minimum.X, p.X), math.Min(minimum.Y, p.Y)}
+		maximum = point{math.Max(maximum.X, p.X), math.Max(maximum.Y, p.Y)}
+	}
+	for _, node := range s.Nodes {
+		include(node.Center.sub(point{node.RX, node.RY}))
+		include(node.Center.add(point{node.RX, node.RY}))
+	}
+	for _, edge := range s.Edges {
+		for _, p := range edge.Path {
+			include(p)
+		}
+		for _, p := range edge.Arrow {
+			include(p)
+		}
+		low, high := edge.labelBox()
+		include(low)
+		include(high)
+	}
+
+	shift := point{sceneMargin, sceneMargin}.sub(minimum)
+	for i := range s.Nodes {
+		s.Nodes[i].Center = s.Nodes[i].Center.add(shift)
+	}
+	for i := range s.Edges {
+		edge := &s.Edges[i]
+		for j := range edge.Path {
+			edge.Path[j] = edge.Path[j].add(shift)
+		}
+		for j := range edge.Arrow {
+			edge.Arrow[j] = edge.Arrow[j].add(shift)
+		}
+		edge.LabelAt = edge.LabelAt.add(shift)
+	}
+	s.Width = math.Ceil(maximum.X - minimum.X + 2*sceneMargin)
+	s.Height = math.Ceil(maximum.Y - minimum.Y + 2*sceneMargin)
+}
+
+// textWidth ширина строки в единицах кегля size: пикселях на холсте, пунктах в PDF
+func textWidth(font *trueTypeFont, s string, size float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		width += font.advance(font.glyph(r))
+	}
+	return width * size / 1000
+}
@@ -0,0 +1,106 @@
+package exports
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func isolatedGraph() *InteractionGraph {
+	return &InteractionGraph{
+		Nodes: []*UserMetrics{{Username: "alice"}, {Username: "bob"}, {Username: "carol"}},
+		Edges: []*InteractionEdge{
+			{Source: "alice", Target: "bob", Type: "like", Count: 2},
+			{Source: "bob", Target: "alice", Type: "dislike", Count: 1},
+		},
+	}
+}
+
+func TestLayoutIsolatedNode(t *testing.T) {
+	font, err := loadReportFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scene := layoutGraph(isolatedGraph(), font)
+
+	// прежде carol уносило силой отталкивания за тысячу пикселей от остальных
+	if scene.Width > 400 || scene.Height > 400 {
+		t.Fatalf("scene is %.0fx%.0f, want both sides within 400px", scene.Width, scene.Height)
+	}
+	for i, node := range scene.Nodes {
+		if node.Center.X-node.RX < 0 || node.Center.X+node.RX > scene.Width ||
+			node.Center.Y-node.RY < 0 || node.Center.Y+node.RY > scene.Height {
+			t.Errorf("node %d at %v is outside the %.0fx%.0f scene", i, node.Center, scene.Width, scene.Height)
+		}
+		for _, other := range scene.Nodes[i+1:] {
+			gap := other.Center.sub(node.Center)
+			if math.Abs(gap.X) < node.RX+other.RX && math.Abs(gap.Y) < node.RY+other.RY {
+				t.Errorf("nodes at %v and %v overlap", node.Center, other.Center)
+			}
+		}
+	}
+}
+
+func TestCanvasFill(t *testing.T) {
+	c := newCanvas(20, 20)
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	c.fill([][]point{{{5, 5}, {15, 5}, {15, 15}, {5, 15}}}, red)
+
+	for _, p := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{10, 10, red},
+		{5, 5, red},
+		{14, 14, red},
+		{4, 10, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{15, 10, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+		{10, 2, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+	} {
+		if got := c.img.RGBAAt(p.x, p.y); got != p.want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", p.x, p.y, got, p.want)
+		}
+	}
+
+	// дыра: контур обратного направления вырезается
+	c = newCanvas(20, 20)
+	c.fill([][]point{
+		{{2, 2}, {18, 2}, {18, 18}, {2, 18}},
+		{{6, 6}, {6, 14}, {14, 14}, {14, 6}},
+	}, red)
+	if got := c.img.RGBAAt(10, 10); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("hole pixel = %v, want white", got)
+	}
+	if got := c.img.RGBAAt(3, 10); got != red {
+		t.Errorf("ring pixel = %v, want red", got)
+	}
+}
+
+func TestRenderGraphPNG(t *testing.T) {
+	data, err := renderGraphPNG(isolatedGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := loadReportFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scene := layoutGraph(isolatedGraph(), font)
+	if size := img.Bounds().Size(); size.X != int(scene.Width) || size.Y != int(scene.Height) {
+		t.Fatalf("png is %v, want %.0fx%.0f", size, scene.Width, scene.Height)
+	}
+
+	// в центре каждого узла — заливка, а не фон
+	for _, node := range scene.Nodes {
+		r, g, b, _ := img.At(int(node.Center.X), int(node.Center.Y-node.RY/2)).RGBA()
+		if r == 0xffff && g == 0xffff && b == 0xffff {
+			t.Errorf("node at %v is not drawn", node.Center)
+		}
+	}
+}
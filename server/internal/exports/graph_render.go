@@ -0,0 +1,125 @@
+package exports
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+const nodeBorderWidth = 1.5
+
+var labelBackground = color.RGBA{0xff, 0xff, 0xff, 0xd9}
+
+// renderGraphPNG растеризует граф без внешних программ; подписи рисуются встроенным шрифтом отчета
+func renderGraphPNG(g *InteractionGraph) ([]byte, error) {
+	font, err := loadReportFont()
+	if err != nil {
+		return nil, err
+	}
+	scene := layoutGraph(g, font)
+	c := newCanvas(int(scene.Width), int(scene.Height))
+
+	for _, edge := range scene.Edges {
+		var dash []float64
+		if edge.Dashed {
+			dash = []float64{4 * edge.Width, 2.5 * edge.Width}
+		}
+		c.stroke(edge.Path, edge.Width, graphColors[edge.Color], dash)
+		c.fill([][]point{edge.Arrow}, graphColors[edge.Color])
+	}
+
+	for _, node := range scene.Nodes {
+		c.fill([][]point{ellipse(node.Center, node.RX, node.RY)}, graphColors[node.Fill])
+		c.fill([][]point{
+			ellipse(node.Center, node.RX+nodeBorderWidth/2, node.RY+nodeBorderWidth/2),
+			reversed(ellipse(node.Center, node.RX-nodeBorderWidth/2, node.RY-nodeBorderWidth/2)),
+		}, graphColors[node.Border])
+		c.text(font, node.Label, node.Center.X-node.TextWidth/2, node.Center.Y+node.FontSize*0.35, node.FontSize, graphColors["black"])
+	}
+
+	// подписи поверх всего, чтобы их не перекрывали соседние ребра и узлы
+	for _, edge := range scene.Edges {
+		low, high := edge.labelBox()
+		c.fill([][]point{rect(low.X, low.Y, high.X-low.X, high.Y-low.Y)}, labelBackground)
+		c.text(font, edge.Label, edge.LabelAt.X-edge.LabelWidth/2, edge.LabelAt.Y+edge.LabelSize*0.35, edge.LabelSize, graphColors["black"])
+	}
+
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderGraphSVG та же сцена, что и в PNG, векторно; текст остается текстом и доступен для поиска
+func renderGraphSVG(g *InteractionGraph) ([]byte, error) {
+	font, err := loadReportFont()
+	if err != nil {
+		return nil, err
+	}
+	scene := layoutGraph(g, font)
+
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" font-family="DejaVu Sans, Verdana, sans-serif">`+"\n",
+		scene.Width, scene.Height, scene.Width, scene.Height)
+	buf.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>` + "\n")
+
+	for _, edge := range scene.Edges {
+		dash := ""
+		if edge.Dashed {
+			dash = fmt.Sprintf(` stroke-dasharray="%.1f %.1f"`, 4*edge.Width, 2.5*edge.Width)
+		}
+		fmt.Fprintf(&buf, `<path d="%s" fill="none" stroke="%s" stroke-width="%.2f" stroke-linejoin="round"%s/>`+"\n",
+			svgPath(edge.Path, false), hexColor(edge.Color), edge.Width, dash)
+		fmt.Fprintf(&buf, `<path d="%s" fill="%s"/>`+"\n", svgPath(edge.Arrow, true), hexColor(edge.Color))
+	}
+
+	for _, node := range scene.Nodes {
+		fmt.Fprintf(&buf, `<ellipse cx="%.2f" cy="%.2f" rx="%.2f" ry="%.2f" fill="%s" stroke="%s" stroke-width="%.1f"/>`+"\n",
+			node.Center.X, node.Center.Y, node.RX, node.RY, hexColor(node.Fill), hexColor(node.Border), nodeBorderWidth)
+		fmt.Fprintf(&buf, `<text x="%.2f" y="%.2f" font-size="%.2f" text-anchor="middle">%s</text>`+"\n",
+			node.Center.X, node.Center.Y+node.FontSize*0.35, node.FontSize, svgText(node.Label))
+	}
+
+	for _, edge := range scene.Edges {
+		low, high := edge.labelBox()
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#ffffff" fill-opacity="%.2f"/>`+"\n",
+			low.X, low.Y, high.X-low.X, high.Y-low.Y, float64(labelBackground.A)/255)
+		fmt.Fprintf(&buf, `<text x="%.2f" y="%.2f" font-size="%.2f" text-anchor="middle">%s</text>`+"\n",
+			edge.LabelAt.X, edge.LabelAt.Y+edge.LabelSize*0.35, edge.LabelSize, svgText(edge.Label))
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+func svgPath(points []point, closed bool) string {
+	var path strings.Builder
+	for i, p := range points {
+		command := "L"
+		if i == 0 {
+			command = "M"
+		}
+		fmt.Fprintf(&path, "%s%.2f %.2f ", command, p.X, p.Y)
+	}
+	if closed {
+		path.WriteString("Z")
+	}
+	return strings.TrimSpace(path.String())
+}
+
+// svgText экранирует имя пользователя: кавычки и угловые скобки не должны ломать разметку
+func svgText(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func hexColor(name string) string {
+	c := graphColors[name]
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,116 @@
+package exports
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/storage"
+	"bytes"
+	"database/sql"
+	"html/template"
+)
+
+func init() {
+	Register(Exporter{
+		Kind:        "html",
+		ContentType: "text/html; charset=utf-8",
+		Extension:   "html",
+		Build: func(db *sql.DB, _ *storage.Repos, discussionID int) ([]byte, error) {
+			return BuildHTML(db, discussionID, i18n.Default)
+		},
+	})
+}
+
+// стили встроены в документ, чтобы файл открывался и печатался без сервера
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+<meta charset="utf-8">
+<title>{{tr "transcript_title" "name" .T.RoomName}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; max-width: 820px; margin: 2em auto; padding: 0 1em; color: #222; line-height: 1.45; }
+  h1 { font-size: 1.6em; border-bottom: 2px solid #444; padding-bottom: .3em; }
+  h2 { font-size: 1.2em; margin-top: 1.6em; border-bottom: 1px solid #ccc; }
+  dl.meta { display: grid; grid-template-columns: max-content 1fr; gap: .2em 1em; }
+  dl.meta dt { font-weight: 600; }
+  dl.meta dd { margin: 0; }
+  .msg { margin: .6em 0; padding: .4em .7em; border-left: 3px solid #8ab; background: #f7f9fa; page-break-inside: avoid; }
+  .msg .head { font-size: .85em; color: #555; }
+  .msg .author { font-weight: 600; color: #222; }
+  .msg .body { white-space: pre-wrap; margin-top: .2em; }
+  .event { margin: .6em 0; font-style: italic; color: #666; font-size: .9em; }
+  table { border-collapse: collapse; width: 100%; margin-top: .5em; }
+  th, td { border: 1px solid #ccc; padding: .3em .6em; text-align: left; }
+  th { background: #eee; }
+  @media print { body { margin: 0; max-width: none; } .msg { background: none; } }
+</style>
+</head>
+<body>
+<h1>{{tr "transcript_title" "name" .T.RoomName}}</h1>
+{{with .T}}
+<dl class="meta">
+  <dt>{{tr "transcript_mode"}}</dt><dd>{{.Mode}}{{with .SubType}} / {{.}}{{end}}</dd>
+  {{with .Topic}}<dt>{{tr "transcript_topic"}}</dt><dd>{{.}}</dd>{{end}}
+  {{with .Subtopic}}<dt>{{tr "transcript_subtopic"}}</dt><dd>{{.}}</dd>{{end}}
+  {{with .Purpose}}<dt>{{tr "transcript_purpose"}}</dt><dd>{{.}}</dd>{{end}}
+  {{with .Description}}<dt>{{tr "transcript_description"}}</dt><dd>{{.}}</dd>{{end}}
+  <dt>{{tr "transcript_participants"}}</dt><dd>{{range $i, $p := .Participants}}{{if $i}}, {{end}}{{$p}}{{end}}</dd>
+  <dt>{{tr "transcript_start"}}</dt><dd>{{datetime .Start}}</dd>
+  <dt>{{tr "transcript_end"}}</dt><dd>{{datetime .End}}</dd>
+  <dt>{{tr "transcript_duration"}}</dt><dd>{{duration .Duration}}</dd>
+</dl>
+{{if .KeyQuestions}}
+<h2>{{tr "transcript_key_questions"}}</h2>
+<ol>{{range .KeyQuestions}}<li>{{.}}</li>{{end}}</ol>
+{{end}}
+{{if .Teams}}
+<h2>{{tr "transcript_theses"}}</h2>
+<ul>{{range .Teams}}<li><strong>{{tr "transcript_team" "team" (inc .Team)}}</strong> ({{range $i, $m := .Members}}{{if $i}}, {{end}}{{$m}}{{end}}): {{.Thesis}}</li>{{end}}</ul>
+{{end}}
+<h2>{{tr "transcript_messages"}}</h2>
+{{range .Entries}}
+{{if .System}}<div class="event">{{clock .Time}} — {{.Content}}</div>
+{{else}}<div class="msg"><div class="head"><span class="author">{{.Username}}</span> · {{clock .Time}} · 👍 {{.Likes}} · 👎 {{.Dislikes}}</div><div class="body">{{.Content}}</div></div>
+{{end}}
+{{else}}<p>{{tr "transcript_no_messages"}}</p>
+{{end}}
+<h2>{{tr "transcript_ratings"}}</h2>
+{{if .Ratings}}
+<table>
+  <tr><th>{{tr "transcript_participant"}}</th><th>{{tr "transcript_professionalism"}}</th><th>{{tr "transcript_arguments_quality"}}</th><th>{{tr "transcript_politeness"}}</th><th>{{tr "transcript_rating_count"}}</th></tr>
+  {{range .Ratings}}<tr><td>{{.Username}}</td><td>{{printf "%.2f" .Professionalism}}</td><td>{{printf "%.2f" .ArgumentsQuality}}</td><td>{{printf "%.2f" .Politeness}}</td><td>{{.Count}}</td></tr>{{end}}
+</table>
+{{else}}<p>{{tr "transcript_no_ratings"}}</p>
+{{end}}
+{{with $vote := .AudienceVote}}
+<h2>{{tr "transcript_audience"}}</h2>
+<table>
+  <tr><th>{{tr "transcript_thesis"}}</th><th>{{tr "transcript_before"}}</th><th>{{tr "transcript_after"}}</th></tr>
+  {{range $i, $thesis := .Theses}}<tr><td>{{$thesis}}</td><td>{{index $vote.Before $i}}</td><td>{{index $vote.After $i}}</td></tr>{{end}}
+</table>
+{{end}}
+{{end}}
+</body>
+</html>
+`
+
+// BuildHTML самодостаточная HTML-стенограмма для просмотра и печати
+func BuildHTML(db *sql.DB, discussionID int, locale string) ([]byte, error) {
+	transcript, err := LoadTranscript(db, discussionID, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("html").Funcs(transcriptFuncs(locale)).Parse(htmlTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		T      *Transcript
+		Locale string
+	}{transcript, locale})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,130 @@
+package exports
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/storage"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+func init() {
+	Register(Exporter{
+		Kind:        "markdown",
+		ContentType: "text/markdown; charset=utf-8",
+		Extension:   "md",
+		Build: func(db *sql.DB, _ *storage.Repos, discussionID int) ([]byte, error) {
+			return BuildMarkdown(db, discussionID, i18n.Default)
+		},
+	})
+}
+
+const markdownTemplate = `# {{tr "transcript_title" "name" (md .RoomName)}}
+
+- **{{tr "transcript_mode"}}:** {{.Mode}}{{with .SubType}} / {{.}}{{end}}
+{{- with .Topic}}
+- **{{tr "transcript_topic"}}:** {{md .}}{{end}}
+{{- with .Subtopic}}
+- **{{tr "transcript_subtopic"}}:** {{md .}}{{end}}
+{{- with .Purpose}}
+- **{{tr "transcript_purpose"}}:** {{md .}}{{end}}
+{{- with .Description}}
+- **{{tr "transcript_description"}}:** {{md .}}{{end}}
+- **{{tr "transcript_participants"}}:** {{range $i, $p := .Participants}}{{if $i}}, {{end}}{{md $p}}{{end}}
+- **{{tr "transcript_start"}}:** {{datetime .Start}}
+- **{{tr "transcript_end"}}:** {{datetime .End}}
+- **{{tr "transcript_duration"}}:** {{duration .Duration}}
+{{- if .KeyQuestions}}
+
+## {{tr "transcript_key_questions"}}
+{{range $i, $q := .KeyQuestions}}
+{{inc $i}}. {{md $q}}{{end}}
+{{- end}}
+{{- if .Teams}}
+
+## {{tr "transcript_theses"}}
+{{range .Teams}}
+- **{{tr "transcript_team" "team" (inc .Team)}}** ({{range $i, $m := .Members}}{{if $i}}, {{end}}{{md $m}}{{end}}): {{md .Thesis}}{{end}}
+{{- end}}
+
+## {{tr "transcript_messages"}}
+{{range .Entries}}
+{{if .System}}> _{{clock .Time}} — {{md .Content}}_
+{{else}}**{{md .Username}}** · {{clock .Time}} · 👍 {{.Likes}} · 👎 {{.Dislikes}}
+
+{{md .Content}}
+{{end}}{{else}}
+{{tr "transcript_no_messages"}}
+{{end}}
+## {{tr "transcript_ratings"}}
+{{if .Ratings}}
+| {{tr "transcript_participant"}} | {{tr "transcript_professionalism"}} | {{tr "transcript_arguments_quality"}} | {{tr "transcript_politeness"}} | {{tr "transcript_rating_count"}} |
+|---|---|---|---|---|
+{{range .Ratings}}| {{md .Username}} | {{printf "%.2f" .Professionalism}} | {{printf "%.2f" .ArgumentsQuality}} | {{printf "%.2f" .Politeness}} | {{.Count}} |
+{{end}}{{else}}
+{{tr "transcript_no_ratings"}}
+{{end}}
+{{- with .AudienceVote}}
+## {{tr "transcript_audience"}}
+
+| {{tr "transcript_thesis"}} | {{tr "transcript_before"}} | {{tr "transcript_after"}} |
+|---|---|---|
+{{range $i, $thesis := .Theses}}| {{md $thesis}} | {{index $.AudienceVote.Before $i}} | {{index $.AudienceVote.After $i}} |
+{{end}}{{end}}`
+
+// BuildMarkdown стенограмма в Markdown
+func BuildMarkdown(db *sql.DB, discussionID int, locale string) ([]byte, error) {
+	transcript, err := LoadTranscript(db, discussionID, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New("markdown").Funcs(transcriptFuncs(locale)).Funcs(template.FuncMap{
+		"md": escapeMarkdown,
+	}).Parse(markdownTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, transcript); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// transcriptFuncs общие для Markdown и HTML функции шаблонов
+func transcriptFuncs(locale string) map[string]any {
+	return map[string]any{
+		"tr": func(key string, pairs ...any) string {
+			params := i18n.Params{}
+			for i := 0; i+1 < len(pairs); i += 2 {
+				params[fmt.Sprint(pairs[i])] = pairs[i+1]
+			}
+			return i18n.T(locale, key, params)
+		},
+		"inc":      func(i int) int { return i + 1 },
+		"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+		"clock":    func(t time.Time) string { return t.Format("15:04:05") },
+		"duration": formatDuration,
+	}
+}
+
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", "&lt;", ">", "&gt;", "#", `\#`, "|", `\|`,
+)
+
+// escapeMarkdown экранирует разметку в пользовательском тексте, чтобы сообщение не ломало документ
+func escapeMarkdown(s string) string {
+	// перенос строки в сообщении должен остаться переносом, а не слиться в абзац
+	return strings.ReplaceAll(markdownEscaper.Replace(s), "\n", "  \n")
+}
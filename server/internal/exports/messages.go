@@ -0,0 +1,229 @@
+package exports
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// messageFlushEvery строк между сбросами буфера клиенту при потоковой выгрузке
+const messageFlushEvery = 500
+
+var ErrUnknownMessageFormat = errors.New("unknown message export format")
+
+// MessageFormats форматы построчной выгрузки сообщений и их Content-Type
+var MessageFormats = map[string]string{
+	"csv":    "text/csv",
+	"ndjson": "application/x-ndjson",
+}
+
+// MessageRow одно сообщение истории для статистического анализа
+type MessageRow struct {
+	MessageID        string    `json:"message_id"`
+	Sequence         int       `json:"sequence"` // номер в истории с 1, включая системные сообщения
+	Timestamp        time.Time `json:"timestamp"`
+	SecondsFromStart float64   `json:"seconds_from_start"`
+	UserID           *int      `json:"user_id"`
+	Username         string    `json:"username"`
+	Type             string    `json:"type"`
+	Content          string    `json:"content"`
+	Likes            int       `json:"likes"`
+	Dislikes         int       `json:"dislikes"`
+	LikedBy          []string  `json:"liked_by"`
+	DislikedBy       []string  `json:"disliked_by"`
+	ReplyTo          string    `json:"reply_to"`
+	Team             *int      `json:"team"` // индекс команды, как в teams дискуссии
+	Thesis           string    `json:"thesis"`
+}
+
+// MessageStream курсор по сообщениям дискуссии: история читается из базы по одной строке,
+// поэтому длинные дискуссии не занимают память
+type MessageStream struct {
+	cursor  *storage.MessageCursor
+	start   time.Time
+	userIDs map[string]int
+	teams   map[string]structures.TeamInfo
+}
+
+// OpenMessageStream проверяет дискуссию и открывает курсор; ошибка возвращается до первой строки,
+// так что обработчик еще может ответить кодом ошибки. Курсор нужно закрыть
+func OpenMessageStream(db *sql.DB, repos *storage.Repos, discussionID int) (*MessageStream, error) {
+	var participantsJSON, teamsJSON []byte
+	stream := &MessageStream{teams: make(map[string]structures.TeamInfo)}
+
+	err := db.QueryRow(`
+		SELECT start_time, participants, teams
+		FROM discussions
+		WHERE id = $1`, discussionID).Scan(&stream.start, &participantsJSON, &teamsJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDiscussionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []string
+	if err = json.Unmarshal(participantsJSON, &participants); err != nil {
+		return nil, fmt.Errorf("parse participants: %w", err)
+	}
+	if teamsJSON != nil {
+		var teams []structures.TeamInfo
+		if err = json.Unmarshal(teamsJSON, &teams); err != nil {
+			return nil, fmt.Errorf("parse teams: %w", err)
+		}
+		for _, team := range teams {
+			for _, member := range team.Members {
+				stream.teams[member] = team
+			}
+		}
+	}
+	if stream.userIDs, err = loadUserIDs(repos, participants); err != nil {
+		return nil, err
+	}
+
+	if stream.cursor, err = storage.OpenMessages(db, discussionID); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (s *MessageStream) Close() error {
+	return s.cursor.Close()
+}
+
+// each вызывает fn для каждого сообщения по порядку
+func (s *MessageStream) each(fn func(MessageRow) error) error {
+	for s.cursor.Next() {
+		msg, sequence := s.cursor.Message()
+		row := MessageRow{
+			MessageID:        msg.ID,
+			Sequence:         sequence,
+			Timestamp:        msg.Timestamp,
+			SecondsFromStart: msg.Timestamp.Sub(s.start).Seconds(),
+			Username:         msg.Username,
+			Type:             msg.Type,
+			Content:          msg.Content,
+			Likes:            len(msg.LikedBy),
+			Dislikes:         len(msg.DislikedBy),
+			LikedBy:          msg.LikedBy,
+			DislikedBy:       msg.DislikedBy,
+			ReplyTo:          msg.ReplyTo,
+		}
+		if userID, ok := s.userIDs[msg.Username]; ok {
+			row.UserID = &userID
+		}
+		if team, ok := s.teams[msg.Username]; ok {
+			row.Team = &team.Team
+			row.Thesis = team.Thesis
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return s.cursor.Err()
+}
+
+// WriteCSV пишет заголовок и по строке на сообщение; списки оценивших разделены «;»
+func (s *MessageStream) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"MessageID", "Sequence", "Timestamp", "SecondsFromStart",
+		"UserID", "Username", "Type", "Content",
+		"Likes", "Dislikes", "LikedBy", "DislikedBy",
+		"ReplyTo", "Team", "Thesis",
+	})
+	if err != nil {
+		return err
+	}
+
+	written := 0
+	err = s.each(func(row MessageRow) error {
+		record := []string{
+			row.MessageID,
+			strconv.Itoa(row.Sequence),
+			row.Timestamp.UTC().Format(time.RFC3339),
+			fmt.Sprintf("%.0f", row.SecondsFromStart),
+			optionalInt(row.UserID),
+			row.Username,
+			row.Type,
+			row.Content,
+			strconv.Itoa(row.Likes),
+			strconv.Itoa(row.Dislikes),
+			strings.Join(row.LikedBy, ";"),
+			strings.Join(row.DislikedBy, ";"),
+			row.ReplyTo,
+			optionalInt(row.Team),
+			row.Thesis,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		if written++; written%messageFlushEvery == 0 {
+			return flushCSV(writer, w)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flushCSV(writer, w)
+}
+
+// WriteNDJSON пишет по JSON-объекту на строку
+func (s *MessageStream) WriteNDJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	written := 0
+	return s.each(func(row MessageRow) error {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+		if written++; written%messageFlushEvery == 0 {
+			flush(w)
+		}
+		return nil
+	})
+}
+
+// Write пишет выгрузку в формате format: csv или ndjson
+func (s *MessageStream) Write(w io.Writer, format string) error {
+	switch format {
+	case "csv":
+		return s.WriteCSV(w)
+	case "ndjson":
+		return s.WriteNDJSON(w)
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownMessageFormat, format)
+}
+
+func flushCSV(writer *csv.Writer, w io.Writer) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	flush(w)
+	return nil
+}
+
+// flush отправляет накопленное клиенту, если w — ответ HTTP
+func flush(w io.Writer) {
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+}
+
+func optionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
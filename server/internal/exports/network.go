@@ -0,0 +1,364 @@
+package exports
+
+import (
+	"awesomeChat/internal/storage"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+func init() {
+	graphFormats["graphml"] = GraphFormat{ContentType: "application/graphml+xml", Render: renderGraphML}
+	graphFormats["gexf"] = GraphFormat{ContentType: "application/gexf+xml", Render: renderGEXF}
+	graphFormats["json"] = GraphFormat{ContentType: "application/json", Render: renderNodeLinkJSON}
+}
+
+// graphAttribute метрика узла или ребра; один список на GraphML, GEXF и JSON, чтобы форматы не расходились
+type graphAttribute[T any] struct {
+	Name  string
+	Type  string // "int", "double" или "string"
+	Value func(T) any
+}
+
+var nodeAttributes = []graphAttribute[*UserMetrics]{
+	{"messages_sent", "int", func(um *UserMetrics) any { return um.MessagesSent }},
+	{"likes_received", "int", func(um *UserMetrics) any { return um.LikesReceived }},
+	{"dislikes_received", "int", func(um *UserMetrics) any { return um.DislikesReceived }},
+	{"likes_given", "int", func(um *UserMetrics) any { return um.LikesGiven }},
+	{"dislikes_given", "int", func(um *UserMetrics) any { return um.DislikesGiven }},
+	{"rating_count", "int", func(um *UserMetrics) any { return um.RatingCount }},
+	{"total_rating", "double", func(um *UserMetrics) any { return um.TotalRating }},
+	{"avg_rating", "double", func(um *UserMetrics) any { return um.AvgRating() }},
+	{"discussions", "int", func(um *UserMetrics) any { return um.Discussions }},
+}
+
+var edgeAttributes = []graphAttribute[*InteractionEdge]{
+	{"type", "string", func(e *InteractionEdge) any { return e.Type }},
+	{"count", "int", func(e *InteractionEdge) any { return e.Count }},
+	{"avg_rating", "double", func(e *InteractionEdge) any { return e.Avg }},
+}
+
+// AvgRating средняя полученная оценка; 0, если оценок не было
+func (um *UserMetrics) AvgRating() float64 {
+	if um.RatingCount == 0 {
+		return 0
+	}
+	return um.TotalRating / float64(um.RatingCount)
+}
+
+func attributeString(value any) string {
+	if f, ok := value.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// NetworkFilter какие дискуссии архива объединяются в одну сеть
+type NetworkFilter struct {
+	Username       string    // кто запрашивает: без организации видны публичные дискуссии и свои
+	OrganizationID int       // 0 — общий архив, иначе все дискуссии организации
+	Tag            string    // пусто — без фильтра по тегу
+	From, To       time.Time // нулевое значение — без границы; дискуссия должна начаться в [From, To)
+}
+
+// NetworkDiscussions дискуссии под фильтром, видимые так же, как в архиве
+func NetworkDiscussions(db *sql.DB, filter NetworkFilter) ([]int, error) {
+	query := `SELECT id FROM discussions WHERE ((public = true AND organization_id IS NULL) OR participants @> jsonb_build_array($1::text))`
+	args := []any{filter.Username}
+	if filter.OrganizationID != 0 {
+		query = `SELECT id FROM discussions WHERE organization_id = $1`
+		args[0] = filter.OrganizationID
+	}
+	if filter.Tag != "" {
+		args = append(args, filter.Tag)
+		query += fmt.Sprintf(` AND tags @> jsonb_build_array($%d::text)`, len(args))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		query += fmt.Sprintf(` AND start_time >= $%d`, len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		query += fmt.Sprintf(` AND start_time < $%d`, len(args))
+	}
+
+	rows, err := db.Query(query+` ORDER BY start_time`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// BuildNetwork объединяет графы отобранных дискуссий в одну сеть и отрисовывает ее в формате format
+func BuildNetwork(db *sql.DB, repos *storage.Repos, filter NetworkFilter, format string) ([]byte, GraphFormat, error) {
+	graphFormat, ok := graphFormats[format]
+	if !ok {
+		return nil, GraphFormat{}, fmt.Errorf("%w: %s", ErrUnknownGraphFormat, format)
+	}
+	ids, err := NetworkDiscussions(db, filter)
+	if err != nil {
+		return nil, graphFormat, err
+	}
+
+	graphs := make([]*InteractionGraph, 0, len(ids))
+	for _, id := range ids {
+		graph, err := LoadInteractionGraph(repos, id)
+		if err != nil {
+			return nil, graphFormat, fmt.Errorf("discussion %d: %w", id, err)
+		}
+		graphs = append(graphs, graph)
+	}
+
+	data, err := graphFormat.Render(MergeGraphs(graphs...))
+	return data, graphFormat, err
+}
+
+// MergeGraphs сливает графы по именам пользователей: метрики и количества складываются,
+// средняя оценка ребра пересчитывается с учетом числа оценок
+func MergeGraphs(graphs ...*InteractionGraph) *InteractionGraph {
+	merged := &InteractionGraph{}
+	nodes := make(map[string]*UserMetrics)
+	edges := make(map[string]*InteractionEdge)
+	for _, graph := range graphs {
+		for _, um := range graph.Nodes {
+			node, exists := nodes[um.Username]
+			if !exists {
+				node = &UserMetrics{Username: um.Username}
+				nodes[um.Username] = node
+				merged.Nodes = append(merged.Nodes, node)
+			}
+			node.MessagesSent += um.MessagesSent
+			node.LikesReceived += um.LikesReceived
+			node.DislikesReceived += um.DislikesReceived
+			node.LikesGiven += um.LikesGiven
+			node.DislikesGiven += um.DislikesGiven
+			node.TotalRating += um.TotalRating
+			node.RatingCount += um.RatingCount
+			node.Discussions += um.Discussions
+		}
+		for _, e := range graph.Edges {
+			key := fmt.Sprintf("%s|%s|%s", e.Source, e.Target, e.Type)
+			edge, exists := edges[key]
+			if !exists {
+				edge = &InteractionEdge{Source: e.Source, Target: e.Target, Type: e.Type}
+				edges[key] = edge
+				merged.Edges = append(merged.Edges, edge)
+			}
+			total := edge.Avg*float64(edge.Count) + e.Avg*float64(e.Count)
+			edge.Count += e.Count
+			if edge.Count > 0 {
+				edge.Avg = total / float64(edge.Count)
+			}
+		}
+	}
+	sortEdges(merged.Edges)
+	return merged
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// renderGraphML GraphML для Gephi, yEd и networkx.read_graphml; id узла — имя пользователя
+func renderGraphML(g *InteractionGraph) ([]byte, error) {
+	doc := graphMLDocument{Xmlns: "http://graphml.graphdrawing.org/xmlns"}
+	doc.Keys = append(doc.Keys, graphMLKey{ID: "label", For: "node", AttrName: "label", AttrType: "string"})
+	for _, attribute := range nodeAttributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "n_" + attribute.Name, For: "node", AttrName: attribute.Name, AttrType: attribute.Type})
+	}
+	for _, attribute := range edgeAttributes {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: "e_" + attribute.Name, For: "edge", AttrName: attribute.Name, AttrType: attribute.Type})
+	}
+	doc.Keys = append(doc.Keys, graphMLKey{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"})
+
+	doc.Graph.ID = "interactions"
+	doc.Graph.EdgeDefault = "directed"
+	for _, um := range g.Nodes {
+		node := graphMLNode{ID: um.Username, Data: []graphMLData{{Key: "label", Value: um.Username}}}
+		for _, attribute := range nodeAttributes {
+			node.Data = append(node.Data, graphMLData{Key: "n_" + attribute.Name, Value: attributeString(attribute.Value(um))})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for i, e := range g.Edges {
+		edge := graphMLEdge{ID: "e" + strconv.Itoa(i), Source: e.Source, Target: e.Target}
+		for _, attribute := range edgeAttributes {
+			edge.Data = append(edge.Data, graphMLData{Key: "e_" + attribute.Name, Value: attributeString(attribute.Value(e))})
+		}
+		edge.Data = append(edge.Data, graphMLData{Key: "weight", Value: strconv.Itoa(e.Count)})
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+	return marshalXML(doc)
+}
+
+type gexfDocument struct {
+	XMLName xml.Name `xml:"gexf"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Meta    struct {
+		Creator     string `xml:"creator"`
+		Description string `xml:"description"`
+	} `xml:"meta"`
+	Graph struct {
+		DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+		Mode            string           `xml:"mode,attr"`
+		Attributes      []gexfAttributes `xml:"attributes"`
+		Nodes           []gexfNode       `xml:"nodes>node"`
+		Edges           []gexfEdge       `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+type gexfEdge struct {
+	ID     string      `xml:"id,attr"`
+	Source string      `xml:"source,attr"`
+	Target string      `xml:"target,attr"`
+	Kind   string      `xml:"kind,attr"` // параллельные ребра разных типов в GEXF различаются по kind
+	Weight int         `xml:"weight,attr"`
+	Label  string      `xml:"label,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+}
+
+// renderGEXF GEXF 1.3 — родной формат Gephi
+func renderGEXF(g *InteractionGraph) ([]byte, error) {
+	gexfType := map[string]string{"int": "integer", "double": "double", "string": "string"}
+
+	doc := gexfDocument{Xmlns: "http://gexf.net/1.3", Version: "1.3"}
+	doc.Meta.Creator = "awesomeChat"
+	doc.Meta.Description = "Discussion interaction network"
+	doc.Graph.DefaultEdgeType = "directed"
+	doc.Graph.Mode = "static"
+
+	nodeClass := gexfAttributes{Class: "node"}
+	for _, attribute := range nodeAttributes {
+		nodeClass.Attributes = append(nodeClass.Attributes, gexfAttribute{ID: attribute.Name, Title: attribute.Name, Type: gexfType[attribute.Type]})
+	}
+	edgeClass := gexfAttributes{Class: "edge"}
+	for _, attribute := range edgeAttributes {
+		edgeClass.Attributes = append(edgeClass.Attributes, gexfAttribute{ID: attribute.Name, Title: attribute.Name, Type: gexfType[attribute.Type]})
+	}
+	doc.Graph.Attributes = []gexfAttributes{nodeClass, edgeClass}
+
+	for _, um := range g.Nodes {
+		node := gexfNode{ID: um.Username, Label: um.Username}
+		for _, attribute := range nodeAttributes {
+			node.Values = append(node.Values, gexfValue{For: attribute.Name, Value: attributeString(attribute.Value(um))})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for i, e := range g.Edges {
+		edge := gexfEdge{ID: strconv.Itoa(i), Source: e.Source, Target: e.Target, Kind: e.Type, Weight: e.Count, Label: e.style().Label}
+		for _, attribute := range edgeAttributes {
+			edge.Values = append(edge.Values, gexfValue{For: attribute.Name, Value: attributeString(attribute.Value(e))})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+	return marshalXML(doc)
+}
+
+func marshalXML(doc any) ([]byte, error) {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// renderNodeLinkJSON node-link JSON в формате networkx.node_link_graph: мультиграф, ключ ребра — его тип
+func renderNodeLinkJSON(g *InteractionGraph) ([]byte, error) {
+	nodes := make([]map[string]any, 0, len(g.Nodes))
+	for _, um := range g.Nodes {
+		node := map[string]any{"id": um.Username}
+		for _, attribute := range nodeAttributes {
+			node[attribute.Name] = attribute.Value(um)
+		}
+		nodes = append(nodes, node)
+	}
+	links := make([]map[string]any, 0, len(g.Edges))
+	for _, e := range g.Edges {
+		link := map[string]any{"source": e.Source, "target": e.Target, "key": e.Type, "weight": e.Count}
+		for _, attribute := range edgeAttributes {
+			link[attribute.Name] = attribute.Value(e)
+		}
+		links = append(links, link)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(map[string]any{
+		"directed":   true,
+		"multigraph": true,
+		"graph":      map[string]any{},
+		"nodes":      nodes,
+		"links":      links,
+	})
+	return buf.Bytes(), err
+}
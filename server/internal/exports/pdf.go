@@ -0,0 +1,190 @@
+package exports
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/storage"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	Register(Exporter{
+		Kind:        "pdf",
+		ContentType: "application/pdf",
+		Extension:   "pdf",
+		Build: func(db *sql.DB, repos *storage.Repos, discussionID int) ([]byte, error) {
+			return BuildPDF(db, repos, discussionID, i18n.Default)
+		},
+	})
+}
+
+// BuildPDF отчет одним файлом: шапка дискуссии, статистика участников (те же числа, что в CSV),
+// средние оценки, граф взаимодействий и стенограмма
+func BuildPDF(db *sql.DB, repos *storage.Repos, discussionID int, locale string) ([]byte, error) {
+	font, err := loadReportFont()
+	if err != nil {
+		return nil, err
+	}
+	transcript, err := LoadTranscript(db, discussionID, locale)
+	if err != nil {
+		return nil, err
+	}
+	participants, err := participantStats(repos, discussionID)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := func(key string, params i18n.Params) string {
+		return i18n.T(locale, key, params)
+	}
+
+	title := tr("transcript_title", i18n.Params{"name": transcript.RoomName})
+	doc := newPDFDocument(font, title)
+	doc.paragraph(title, 16, 0, colorAccent)
+	doc.space(6)
+
+	mode := transcript.Mode
+	if transcript.SubType != "" {
+		mode += " / " + transcript.SubType
+	}
+	for _, field := range [][2]string{
+		{"transcript_mode", mode},
+		{"transcript_topic", transcript.Topic},
+		{"transcript_subtopic", transcript.Subtopic},
+		{"transcript_purpose", transcript.Purpose},
+		{"transcript_description", transcript.Description},
+		{"transcript_participants", strings.Join(transcript.Participants, ", ")},
+		{"transcript_start", transcript.Start.Format("2006-01-02 15:04:05")},
+		{"transcript_end", transcript.End.Format("2006-01-02 15:04:05")},
+		{"transcript_duration", formatDuration(transcript.Duration)},
+	} {
+		if field[1] != "" {
+			doc.paragraph(tr(field[0], nil)+": "+field[1], 10, 0, colorText)
+		}
+	}
+
+	if len(transcript.KeyQuestions) > 0 {
+		doc.heading(tr("transcript_key_questions", nil), 13)
+		for i, question := range transcript.KeyQuestions {
+			doc.paragraph(fmt.Sprintf("%d. %s", i+1, question), 10, 0, colorText)
+		}
+	}
+
+	if len(transcript.Teams) > 0 {
+		doc.heading(tr("transcript_theses", nil), 13)
+		for _, team := range transcript.Teams {
+			doc.paragraph(fmt.Sprintf("%s (%s): %s", tr("transcript_team", i18n.Params{"team": team.Team + 1}),
+				strings.Join(team.Members, ", "), team.Thesis), 10, 0, colorText)
+		}
+	}
+
+	doc.heading(tr("report_statistics", nil), 13)
+	activity := make([][]string, 0, len(participants))
+	for _, stats := range participants {
+		activity = append(activity, []string{
+			stats.Username,
+			strconv.Itoa(stats.MessagesSent),
+			strconv.Itoa(stats.LikesReceived),
+			strconv.Itoa(stats.DislikesReceived),
+			strconv.Itoa(stats.LikesGiven),
+			strconv.Itoa(stats.DislikesGiven),
+		})
+	}
+	doc.table([]float64{0.3, 0.14, 0.14, 0.14, 0.14, 0.14}, []string{
+		tr("transcript_participant", nil),
+		tr("report_messages_sent", nil),
+		tr("report_likes_received", nil),
+		tr("report_dislikes_received", nil),
+		tr("report_likes_given", nil),
+		tr("report_dislikes_given", nil),
+	}, activity, 9)
+
+	doc.heading(tr("transcript_ratings", nil), 13)
+	ratingsHeader := []string{
+		tr("transcript_participant", nil),
+		tr("transcript_professionalism", nil),
+		tr("transcript_arguments_quality", nil),
+		tr("transcript_politeness", nil),
+		tr("transcript_rating_count", nil),
+	}
+	ratingsWidths := []float64{0.3, 0.175, 0.175, 0.175, 0.175}
+	received := make([][]string, 0, len(participants))
+	given := make([][]string, 0, len(participants))
+	for _, stats := range participants {
+		received = append(received, []string{
+			stats.Username,
+			fmt.Sprintf("%.2f", average(stats.ProfessionalismReceived)),
+			fmt.Sprintf("%.2f", average(stats.ArgumentsQualityReceived)),
+			fmt.Sprintf("%.2f", average(stats.PolitenessReceived)),
+			strconv.Itoa(len(stats.ProfessionalismReceived)),
+		})
+		given = append(given, []string{
+			stats.Username,
+			fmt.Sprintf("%.2f", average(stats.ProfessionalismGiven)),
+			fmt.Sprintf("%.2f", average(stats.ArgumentsQualityGiven)),
+			fmt.Sprintf("%.2f", average(stats.PolitenessGiven)),
+			strconv.Itoa(len(stats.ProfessionalismGiven)),
+		})
+	}
+	doc.paragraph(tr("report_ratings_received", nil), 10, 0, colorMuted)
+	doc.space(3)
+	doc.table(ratingsWidths, ratingsHeader, received, 9)
+	doc.paragraph(tr("report_ratings_given", nil), 10, 0, colorMuted)
+	doc.space(3)
+	doc.table(ratingsWidths, ratingsHeader, given, 9)
+
+	if vote := transcript.AudienceVote; vote != nil {
+		doc.heading(tr("transcript_audience", nil), 13)
+		rows := make([][]string, 0, len(vote.Theses))
+		for i, thesis := range vote.Theses {
+			row := []string{thesis, "0", "0"}
+			if i < len(vote.Before) {
+				row[1] = strconv.Itoa(vote.Before[i])
+			}
+			if i < len(vote.After) {
+				row[2] = strconv.Itoa(vote.After[i])
+			}
+			rows = append(rows, row)
+		}
+		doc.table([]float64{0.6, 0.2, 0.2}, []string{
+			tr("transcript_thesis", nil),
+			tr("transcript_before", nil),
+			tr("transcript_after", nil),
+		}, rows, 9)
+	}
+
+	// без графа отчет все равно полезен, поэтому ошибка отрисовки не прерывает выгрузку
+	doc.heading(tr("report_graph", nil), 13)
+	graph, err := BuildGraphPNG(repos, discussionID)
+	if err == nil {
+		err = doc.image(graph, 420)
+	}
+	if err != nil {
+		logger.Log.Warnf("Interaction graph for PDF report of discussion %d: %v", discussionID, err)
+		doc.paragraph(tr("report_graph_unavailable", nil), 10, 0, colorMuted)
+	}
+
+	doc.heading(tr("transcript_messages", nil), 13)
+	if len(transcript.Entries) == 0 {
+		doc.paragraph(tr("transcript_no_messages", nil), 10, 0, colorMuted)
+	}
+	for _, entry := range transcript.Entries {
+		if entry.System {
+			doc.paragraph(entry.Time.Format("15:04:05")+" — "+entry.Content, 9, 0, colorMuted)
+			doc.space(3)
+			continue
+		}
+		doc.ensure(2 * 10 * lineSpacing)
+		doc.paragraph(fmt.Sprintf("%s · %s · +%d / −%d", entry.Username, entry.Time.Format("15:04:05"), entry.Likes, entry.Dislikes), 9, 0, colorAccent)
+		doc.paragraph(entry.Content, 10, 10, colorText)
+		doc.space(4)
+	}
+
+	doc.footer(func(page, pages int) string {
+		return tr("report_page", i18n.Params{"page": page, "pages": pages})
+	})
+	return doc.bytes()
+}
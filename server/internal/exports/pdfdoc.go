@@ -0,0 +1,411 @@
+package exports
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// размеры A4 и поля страницы в пунктах
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	pageMargin   = 50.0
+	contentWidth = pageWidth - 2*pageMargin
+	lineSpacing  = 1.35
+	cellPadding  = 4.0
+)
+
+type pdfColor [3]float64
+
+var (
+	colorText   = pdfColor{0.13, 0.13, 0.13}
+	colorMuted  = pdfColor{0.4, 0.4, 0.4}
+	colorAccent = pdfColor{0.12, 0.3, 0.5}
+	colorBorder = pdfColor{0.75, 0.75, 0.75}
+	colorHeader = pdfColor{0.92, 0.94, 0.95}
+)
+
+// pdfImage растровое изображение, уже разжатое в RGB и сжатое заново для потока PDF
+type pdfImage struct {
+	width, height int
+	data          []byte
+}
+
+// pdfDocument минимальный генератор PDF: текст встроенным TrueType-шрифтом, таблицы и PNG-изображения.
+// Страницы верстаются сверху вниз, y — базовая линия следующей строки
+type pdfDocument struct {
+	title  string
+	font   *trueTypeFont
+	used   map[uint16]rune // глифы в тексте документа: для подмножества шрифта, ширин и ToUnicode
+	pages  []*bytes.Buffer
+	page   *bytes.Buffer
+	y      float64
+	images []pdfImage
+}
+
+func newPDFDocument(font *trueTypeFont, title string) *pdfDocument {
+	d := &pdfDocument{title: title, font: font, used: make(map[uint16]rune)}
+	d.newPage()
+	return d
+}
+
+func (d *pdfDocument) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pageHeight - pageMargin
+}
+
+// ensure начинает новую страницу, если блок высотой height не помещается на текущей
+func (d *pdfDocument) ensure(height float64) {
+	if d.y-height < pageMargin {
+		d.newPage()
+	}
+}
+
+func (d *pdfDocument) space(height float64) {
+	d.y -= height
+}
+
+func (d *pdfDocument) textWidth(s string, size float64) float64 {
+	return textWidth(d.font, s, size)
+}
+
+// text выводит одну строку с базовой линией в точке (x, y)
+func (d *pdfDocument) text(x, y float64, s string, size float64, c pdfColor) {
+	var glyphs strings.Builder
+	for _, r := range s {
+		glyph := d.font.glyph(r)
+		if _, ok := d.used[glyph]; !ok {
+			d.used[glyph] = r
+		}
+		fmt.Fprintf(&glyphs, "%04X", glyph)
+	}
+	fmt.Fprintf(d.page, "BT /F1 %.2f Tf %.3f %.3f %.3f rg %.2f %.2f Td <%s> Tj ET\n",
+		size, c[0], c[1], c[2], x, y, glyphs.String())
+}
+
+// wrap разбивает текст на строки не шире width; переносы строк в тексте сохраняются
+func (d *pdfDocument) wrap(s string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r", ""), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if d.textWidth(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// слово длиннее строки (ссылка, длинное число) режется по символам
+			for d.textWidth(word, size) > width {
+				runes := []rune(word)
+				cut := 1
+				for cut < len(runes) && d.textWidth(string(runes[:cut+1]), size) <= width {
+					cut++
+				}
+				lines = append(lines, string(runes[:cut]))
+				word = string(runes[cut:])
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// paragraph выводит текст с переносами и разрывами страниц между строками
+func (d *pdfDocument) paragraph(s string, size, indent float64, c pdfColor) {
+	lineHeight := size * lineSpacing
+	for _, line := range d.wrap(s, size, contentWidth-indent) {
+		d.ensure(lineHeight)
+		d.y -= lineHeight
+		d.text(pageMargin+indent, d.y+size*0.3, line, size, c)
+	}
+}
+
+// heading заголовок раздела; не остается последней строкой страницы
+func (d *pdfDocument) heading(s string, size float64) {
+	d.ensure(size*lineSpacing + 40)
+	d.space(size * 0.6)
+	d.paragraph(s, size, 0, colorAccent)
+	d.space(size * 0.3)
+}
+
+// table таблица во всю ширину страницы; widths — доли ширины колонок.
+// Текст в ячейках переносится, шапка повторяется на каждой странице
+func (d *pdfDocument) table(widths []float64, header []string, rows [][]string, size float64) {
+	d.ensure(2 * (size*lineSpacing*2 + 2*cellPadding))
+	d.tableRow(widths, header, size, true)
+	for _, row := range rows {
+		if d.y-d.rowHeight(widths, row, size) < pageMargin {
+			d.newPage()
+			d.tableRow(widths, header, size, true)
+		}
+		d.tableRow(widths, row, size, false)
+	}
+	d.space(size)
+}
+
+func (d *pdfDocument) rowHeight(widths []float64, cells []string, size float64) float64 {
+	lines := 1
+	for i, cell := range cells {
+		if n := len(d.wrap(cell, size, widths[i]*contentWidth-2*cellPadding)); n > lines {
+			lines = n
+		}
+	}
+	return float64(lines)*size*lineSpacing + 2*cellPadding
+}
+
+func (d *pdfDocument) tableRow(widths []float64, cells []string, size float64, header bool) {
+	height := d.rowHeight(widths, cells, size)
+	top := d.y
+	x := pageMargin
+	for i, cell := range cells {
+		width := widths[i] * contentWidth
+		if header {
+			fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n",
+				colorHeader[0], colorHeader[1], colorHeader[2], x, top-height, width, height)
+		}
+		fmt.Fprintf(d.page, "0.5 w %.3f %.3f %.3f RG %.2f %.2f %.2f %.2f re S\n",
+			colorBorder[0], colorBorder[1], colorBorder[2], x, top-height, width, height)
+		textColor := colorText
+		if header {
+			textColor = colorAccent
+		}
+		y := top - cellPadding
+		for _, line := range d.wrap(cell, size, width-2*cellPadding) {
+			y -= size * lineSpacing
+			d.text(x+cellPadding, y+size*0.3, line, size, textColor)
+		}
+		x += width
+	}
+	d.y = top - height
+}
+
+// image вписывает PNG в ширину страницы и высоту maxHeight, не увеличивая его
+func (d *pdfDocument) image(data []byte, maxHeight float64) error {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	bounds := img.Bounds()
+
+	// PDF без альфа-канала: прозрачные пиксели смешиваются с белым фоном
+	rgb := make([]byte, 0, 3*bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			alpha := int(c.A)
+			for _, channel := range []uint8{c.R, c.G, c.B} {
+				rgb = append(rgb, uint8((int(channel)*alpha+255*(255-alpha))/255))
+			}
+		}
+	}
+	compressed, err := deflate(rgb)
+	if err != nil {
+		return err
+	}
+	d.images = append(d.images, pdfImage{width: bounds.Dx(), height: bounds.Dy(), data: compressed})
+
+	scale := 1.0
+	if w := contentWidth / float64(bounds.Dx()); w < scale {
+		scale = w
+	}
+	if h := maxHeight / float64(bounds.Dy()); h < scale {
+		scale = h
+	}
+	width, height := float64(bounds.Dx())*scale, float64(bounds.Dy())*scale
+
+	d.ensure(height)
+	d.y -= height
+	fmt.Fprintf(d.page, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n",
+		width, height, pageMargin+(contentWidth-width)/2, d.y, len(d.images))
+	return nil
+}
+
+// footer подписывает номера страниц; вызывается, когда известно их общее число
+func (d *pdfDocument) footer(label func(page, pages int) string) {
+	for i, page := range d.pages {
+		d.page = page
+		text := label(i+1, len(d.pages))
+		d.text(pageWidth-pageMargin-d.textWidth(text, 8), pageMargin/2, text, 8, colorMuted)
+	}
+}
+
+// bytes собирает файл: каталог, шрифт, изображения и страницы, затем таблицу xref
+func (d *pdfDocument) bytes() ([]byte, error) {
+	const (
+		catalogID = iota + 1
+		pagesID
+		infoID
+		fontID
+		cidFontID
+		descriptorID
+		fontFileID
+		toUnicodeID
+		firstImageID
+	)
+	firstPageID := firstImageID + len(d.images)
+
+	out := &pdfWriter{}
+	out.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageID+2*i)
+	}
+	out.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	out.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	out.object(infoID, fmt.Sprintf("<< /Title %s /Producer (awesomeChat) >>", pdfTextString(d.title)))
+
+	glyphs := make([]int, 0, len(d.used))
+	for glyph := range d.used {
+		glyphs = append(glyphs, int(glyph))
+	}
+	sort.Ints(glyphs)
+
+	var widths strings.Builder
+	for _, glyph := range glyphs {
+		fmt.Fprintf(&widths, "%d [%.0f] ", glyph, d.font.advance(uint16(glyph)))
+	}
+
+	// префикс из шести букв помечает встроенное подмножество шрифта
+	const fontName = "/AWSMCH+DejaVuSans"
+	out.object(fontID, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont %s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		fontName, cidFontID, toUnicodeID))
+	out.object(cidFontID, fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont %s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+		fontName, descriptorID, widths.String()))
+	f := d.font
+	out.object(descriptorID, fmt.Sprintf("<< /Type /FontDescriptor /FontName %s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		fontName, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight), fontFileID))
+
+	fontFile := f.subset(d.used)
+	if err := out.stream(fontFileID, fmt.Sprintf("/Length1 %d", len(fontFile)), fontFile); err != nil {
+		return nil, err
+	}
+	if err := out.stream(toUnicodeID, "", toUnicodeCMap(glyphs, d.used)); err != nil {
+		return nil, err
+	}
+
+	var xObjects strings.Builder
+	for i, img := range d.images {
+		id := firstImageID + i
+		out.object(id, fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			img.width, img.height, len(img.data), img.data))
+		fmt.Fprintf(&xObjects, "/Im%d %d 0 R ", i+1, id)
+	}
+
+	for i, page := range d.pages {
+		pageID := firstPageID + 2*i
+		out.object(pageID, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R >> /XObject << %s>> >> /Contents %d 0 R >>",
+			pagesID, pageWidth, pageHeight, fontID, xObjects.String(), pageID+1))
+		if err := out.stream(pageID+1, "", page.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	return out.finish(catalogID, infoID), nil
+}
+
+// pdfWriter пишет пронумерованные объекты и запоминает их смещения для xref
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *pdfWriter) object(id int, body string) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream сжатый поток; extra — дополнительные записи словаря
+func (w *pdfWriter) stream(id int, extra string, data []byte) error {
+	compressed, err := deflate(data)
+	if err != nil {
+		return err
+	}
+	w.object(id, fmt.Sprintf("<< /Filter /FlateDecode /Length %d %s>>\nstream\n%s\nendstream", len(compressed), extra, compressed))
+	return nil
+}
+
+func (w *pdfWriter) finish(rootID, infoID int) []byte {
+	size := 0
+	for id := range w.offsets {
+		if id > size {
+			size = id
+		}
+	}
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", size+1)
+	for id := 1; id <= size; id++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[id])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size+1, rootID, infoID, xref)
+	return w.buf.Bytes()
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfTextString строка метаданных в UTF-16BE, чтобы название на кириллице читалось в просмотрщиках
+func pdfTextString(s string) string {
+	var hex strings.Builder
+	hex.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&hex, "%04X", unit)
+	}
+	hex.WriteString(">")
+	return hex.String()
+}
+
+// toUnicodeCMap сопоставляет глифы символам, чтобы текст из отчета можно было искать и копировать
+func toUnicodeCMap(glyphs []int, used map[uint16]rune) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// в одном блоке bfchar не больше 100 записей
+	for start := 0; start < len(glyphs); start += 100 {
+		end := start + 100
+		if end > len(glyphs) {
+			end = len(glyphs)
+		}
+		fmt.Fprintf(&buf, "%d beginbfchar\n", end-start)
+		for _, glyph := range glyphs[start:end] {
+			fmt.Fprintf(&buf, "<%04X> <", glyph)
+			for _, unit := range utf16.Encode([]rune{used[uint16(glyph)]}) {
+				fmt.Fprintf(&buf, "%04X", unit)
+			}
+			buf.WriteString(">\n")
+		}
+		buf.WriteString("endbfchar\n")
+	}
+	buf.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return buf.Bytes()
+}
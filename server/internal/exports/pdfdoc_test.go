@@ -0,0 +1,69 @@
+package exports
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDFDocumentXref(t *testing.T) {
+	font, err := loadReportFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := newPDFDocument(font, "Отчет")
+	doc.heading("Стенограмма", 16)
+	for len(doc.pages) < 3 {
+		doc.paragraph(strings.Repeat("Ключевой вопрос дискуссии и ответ на него. ", 20), 10, 0, colorText)
+	}
+	doc.table([]float64{0.3, 0.7}, []string{"Участник", "Сообщение"}, [][]string{{"alice", "Привет"}}, 9)
+	doc.footer(func(page, pages int) string { return fmt.Sprintf("%d / %d", page, pages) })
+
+	data, err := doc.bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	match := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if match == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+
+	lines := strings.Split(string(data[xref:]), "\n")
+	var first, size int
+	if _, err = fmt.Sscanf(lines[1], "%d %d", &first, &size); err != nil || first != 0 {
+		t.Fatalf("bad xref subsection %q", lines[1])
+	}
+	if !strings.Contains(string(data), fmt.Sprintf("/Size %d ", size)) {
+		t.Fatalf("trailer /Size does not match xref size %d", size)
+	}
+	for id := 1; id < size; id++ {
+		entry := lines[2+id]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("xref entry %d is malformed: %q", id, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj\n", id); !bytes.HasPrefix(data[offset:], []byte(want)) {
+			t.Fatalf("xref offset %d of object %d points at %q", offset, id, data[offset:offset+20])
+		}
+	}
+
+	pages := regexp.MustCompile(`/Type /Pages /Kids \[[^\]]*\] /Count (\d+)`).FindSubmatch(data)
+	if pages == nil || string(pages[1]) != strconv.Itoa(len(doc.pages)) {
+		t.Fatalf("page tree does not list %d pages", len(doc.pages))
+	}
+	if got := bytes.Count(data, []byte("/Type /Page ")); got != len(doc.pages) {
+		t.Fatalf("%d page objects, want %d", got, len(doc.pages))
+	}
+}
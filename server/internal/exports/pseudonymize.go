@@ -0,0 +1,171 @@
+package exports
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pseudonymizer заменяет имена пользователей псевдонимами. Ключ случайный на каждую выгрузку:
+// внутри набора псевдоним одного человека одинаков во всех дискуссиях, а между выгрузками их не сопоставить.
+// Методы nil-получателя возвращают имена без изменений
+type pseudonymizer struct {
+	key []byte
+
+	mu       sync.Mutex
+	patterns map[string]*mentionPattern // по набору имен: у всех сообщений дискуссии он один
+}
+
+// mentionPattern выражение для поиска упоминаний набора имен и исходное написание каждого имени
+type mentionPattern struct {
+	pattern   *regexp.Regexp
+	canonical map[string]string
+}
+
+func newPseudonymizer() (*pseudonymizer, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &pseudonymizer{key: key, patterns: make(map[string]*mentionPattern)}, nil
+}
+
+func (p *pseudonymizer) name(username string) string {
+	if p == nil || username == "" {
+		return username
+	}
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(username))
+	return "user_" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+func (p *pseudonymizer) names(usernames []string) []string {
+	if p == nil {
+		return usernames
+	}
+	result := make([]string, len(usernames))
+	for i, username := range usernames {
+		result[i] = p.name(username)
+	}
+	return result
+}
+
+// mentions заменяет упоминания usernames в тексте, в том числе с @; имя должно стоять отдельным словом,
+// чтобы короткое имя не испортило слова, в которые оно входит
+func (p *pseudonymizer) mentions(s string, usernames []string) string {
+	if p == nil || s == "" || len(usernames) == 0 {
+		return s
+	}
+	m := p.mentionPattern(usernames)
+	if m == nil {
+		return s
+	}
+
+	var result strings.Builder
+	last := 0
+	for _, match := range m.pattern.FindAllStringIndex(s, -1) {
+		if !wordBoundary(s, match[0], match[1]) {
+			continue
+		}
+		result.WriteString(s[last:match[0]])
+		result.WriteString(p.name(m.canonical[strings.ToLower(s[match[0]:match[1]])]))
+		last = match[1]
+	}
+	result.WriteString(s[last:])
+	return result.String()
+}
+
+// mentionPattern собирает выражение один раз на набор имен за выгрузку; nil, если имен нет
+func (p *pseudonymizer) mentionPattern(usernames []string) *mentionPattern {
+	// длинные имена раньше коротких, чтобы «anna» не перехватила «anna_k»
+	sorted := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		if username != "" {
+			sorted = append(sorted, username)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	key := strings.Join(sorted, "\x00")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if m, ok := p.patterns[key]; ok {
+		return m
+	}
+	m := &mentionPattern{canonical: make(map[string]string, len(sorted))}
+	quoted := make([]string, 0, len(sorted))
+	for _, username := range sorted {
+		m.canonical[strings.ToLower(username)] = username
+		quoted = append(quoted, regexp.QuoteMeta(username))
+	}
+	m.pattern = regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+	p.patterns[key] = m
+	return m
+}
+
+func wordBoundary(s string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(s[:start])
+	after, _ := utf8.DecodeRuneInString(s[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[\p{L}\p{N}._%+-]+@[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)*\.\p{L}{2,}`)
+	urlPattern    = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+	numberPattern = regexp.MustCompile(`\+?\(?\d[\d\s().-]{8,}\d`)
+)
+
+// stripPII вырезает из свободного текста почту, ссылки, номера телефонов и банковских карт
+func stripPII(s string) string {
+	s = emailPattern.ReplaceAllString(s, "[email]")
+	s = urlPattern.ReplaceAllString(s, "[url]")
+	return numberPattern.ReplaceAllStringFunc(s, func(match string) string {
+		digits := make([]byte, 0, len(match))
+		for i := 0; i < len(match); i++ {
+			if match[i] >= '0' && match[i] <= '9' {
+				digits = append(digits, match[i])
+			}
+		}
+		switch {
+		case len(digits) >= 13 && len(digits) <= 19 && luhnValid(digits):
+			return "[card]"
+		case len(digits) >= 10 && len(digits) <= 15:
+			return "[phone]"
+		}
+		// годы через пробел, суммы и прочие числа оставляем
+		return match
+	})
+}
+
+func luhnValid(digits []byte) bool {
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
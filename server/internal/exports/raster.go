@@ -0,0 +1,314 @@
+package exports
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+type point struct {
+	X, Y float64
+}
+
+func (p point) add(q point) point           { return point{p.X + q.X, p.Y + q.Y} }
+func (p point) sub(q point) point           { return point{p.X - q.X, p.Y - q.Y} }
+func (p point) scale(k float64) point       { return point{p.X * k, p.Y * k} }
+func (p point) length() float64             { return math.Hypot(p.X, p.Y) }
+func (p point) normal() point               { return point{-p.Y, p.X} }
+func lerp(p, q point, t float64) point      { return p.add(q.sub(p).scale(t)) }
+func quadAt(a, b, c point, t float64) point { return lerp(lerp(a, b, t), lerp(b, c, t), t) }
+
+// unit вектор единичной длины; нулевой остается нулевым
+func (p point) unit() point {
+	if l := p.length(); l > 0 {
+		return p.scale(1 / l)
+	}
+	return p
+}
+
+// rasterSubsamples строк выборки на пиксель по вертикали; по горизонтали покрытие считается точно
+const rasterSubsamples = 5
+
+// canvas растровый холст со сглаживанием: фигуры заливаются по правилу ненулевой обмотки
+type canvas struct {
+	img   *image.RGBA
+	cover []float64
+}
+
+func newCanvas(width, height int) *canvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	return &canvas{img: img, cover: make([]float64, width+1)}
+}
+
+type rasterEdge struct {
+	x0, y0, y1, slope float64
+	winding           int
+}
+
+type crossing struct {
+	x       float64
+	winding int
+}
+
+// fill заливает замкнутые контуры; пересекающиеся контуры одного направления сливаются,
+// контур обратного направления вырезает дыру
+func (c *canvas) fill(contours [][]point, col color.RGBA) {
+	width, height := c.img.Bounds().Dx(), c.img.Bounds().Dy()
+	var edges []rasterEdge
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, contour := range contours {
+		for i := range contour {
+			p, q := contour[i], contour[(i+1)%len(contour)]
+			if p.Y == q.Y {
+				continue
+			}
+			edge := rasterEdge{winding: 1}
+			if p.Y > q.Y {
+				p, q = q, p
+				edge.winding = -1
+			}
+			edge.x0, edge.y0, edge.y1 = p.X, p.Y, q.Y
+			edge.slope = (q.X - p.X) / (q.Y - p.Y)
+			edges = append(edges, edge)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, q.Y)
+		}
+	}
+	if len(edges) == 0 {
+		return
+	}
+
+	firstRow := int(math.Max(0, math.Floor(minY)))
+	lastRow := int(math.Min(float64(height-1), math.Ceil(maxY)))
+	var crossings []crossing
+	for y := firstRow; y <= lastRow; y++ {
+		left, right := width, -1
+		for s := 0; s < rasterSubsamples; s++ {
+			sampleY := float64(y) + (float64(s)+0.5)/rasterSubsamples
+			crossings = crossings[:0]
+			for _, edge := range edges {
+				if edge.y0 <= sampleY && sampleY < edge.y1 {
+					crossings = append(crossings, crossing{edge.x0 + (sampleY-edge.y0)*edge.slope, edge.winding})
+				}
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding, spanStart := 0, 0.0
+			for _, cr := range crossings {
+				before := winding
+				winding += cr.winding
+				switch {
+				case before == 0 && winding != 0:
+					spanStart = cr.x
+				case before != 0 && winding == 0:
+					from, to := c.span(spanStart, cr.x, 1.0/rasterSubsamples)
+					if from < left {
+						left = from
+					}
+					if to > right {
+						right = to
+					}
+				}
+			}
+		}
+		for x := left; x <= right; x++ {
+			if coverage := math.Min(c.cover[x], 1); coverage > 0 && x < width {
+				c.blend(x, y, col, coverage)
+			}
+			c.cover[x] = 0
+		}
+	}
+}
+
+// span добавляет покрытие отрезка [x0, x1) строки; дробные края дают сглаживание
+func (c *canvas) span(x0, x1, weight float64) (int, int) {
+	limit := float64(len(c.cover) - 1)
+	x0, x1 = math.Max(0, math.Min(x0, limit)), math.Max(0, math.Min(x1, limit))
+	if x1 <= x0 {
+		return len(c.cover), -1
+	}
+	first, last := int(x0), int(x1)
+	if first == last {
+		c.cover[first] += (x1 - x0) * weight
+		return first, last
+	}
+	c.cover[first] += (float64(first+1) - x0) * weight
+	for x := first + 1; x < last; x++ {
+		c.cover[x] += weight
+	}
+	c.cover[last] += (x1 - float64(last)) * weight
+	return first, last
+}
+
+func (c *canvas) blend(x, y int, col color.RGBA, coverage float64) {
+	alpha := coverage * float64(col.A) / 255
+	i := c.img.PixOffset(x, y)
+	for channel, value := range []uint8{col.R, col.G, col.B} {
+		dst := float64(c.img.Pix[i+channel])
+		c.img.Pix[i+channel] = uint8(dst + (float64(value)-dst)*alpha + 0.5)
+	}
+}
+
+// ellipse контур эллипса против часовой стрелки (в экранных координатах — по часовой)
+func ellipse(center point, rx, ry float64) []point {
+	const segments = 72
+	contour := make([]point, segments)
+	for i := range contour {
+		angle := 2 * math.Pi * float64(i) / segments
+		contour[i] = point{center.X + rx*math.Cos(angle), center.Y + ry*math.Sin(angle)}
+	}
+	return contour
+}
+
+func reversed(contour []point) []point {
+	result := make([]point, len(contour))
+	for i, p := range contour {
+		result[len(contour)-1-i] = p
+	}
+	return result
+}
+
+func rect(x, y, width, height float64) []point {
+	return []point{{x, y}, {x + width, y}, {x + width, y + height}, {x, y + height}}
+}
+
+// stroke обводит ломаную линией толщины width; dash — длины штриха и пробела, nil — сплошная
+func (c *canvas) stroke(line []point, width float64, col color.RGBA, dash []float64) {
+	var contours [][]point
+	for _, part := range dashes(line, dash) {
+		for i := 0; i+1 < len(part); i++ {
+			p, q := part[i], part[i+1]
+			offset := q.sub(p).unit().normal().scale(width / 2)
+			contours = append(contours, []point{p.add(offset), q.add(offset), q.sub(offset), p.sub(offset)})
+			// скругленные стыки, чтобы на изгибах не было щелей
+			if i > 0 {
+				contours = append(contours, ellipse(p, width/2, width/2))
+			}
+		}
+	}
+	// все части обходятся в одном направлении, иначе перекрытия вырезали бы дыры
+	for i, contour := range contours {
+		if signedArea(contour) < 0 {
+			contours[i] = reversed(contour)
+		}
+	}
+	c.fill(contours, col)
+}
+
+func signedArea(contour []point) float64 {
+	area := 0.0
+	for i, p := range contour {
+		q := contour[(i+1)%len(contour)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	return area / 2
+}
+
+// dashes режет ломаную на штрихи заданной длины
+func dashes(line []point, pattern []float64) [][]point {
+	if len(pattern) < 2 {
+		return [][]point{line}
+	}
+	var parts [][]point
+	var current []point
+	on, left := true, pattern[0]
+	step := 0
+	for i := 0; i+1 < len(line); i++ {
+		p, q := line[i], line[i+1]
+		segment := q.sub(p).length()
+		for done := 0.0; done < segment; {
+			advance := math.Min(left, segment-done)
+			from, to := lerp(p, q, done/segment), lerp(p, q, (done+advance)/segment)
+			if on {
+				if len(current) == 0 {
+					current = append(current, from)
+				}
+				current = append(current, to)
+			}
+			done += advance
+			left -= advance
+			if left <= 0 {
+				if on && len(current) > 1 {
+					parts = append(parts, current)
+				}
+				current = nil
+				on = !on
+				step++
+				left = pattern[step%len(pattern)]
+			}
+		}
+	}
+	if len(current) > 1 {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// text рисует строку шрифтом отчета; (x, y) — начало базовой линии, size — кегль в пикселях
+func (c *canvas) text(font *trueTypeFont, s string, x, y, size float64, col color.RGBA) {
+	var contours [][]point
+	scale := size / float64(font.unitsPerEm)
+	for _, r := range s {
+		glyph := font.glyph(r)
+		for _, contour := range font.outline(glyph) {
+			contours = append(contours, flattenContour(contour, func(p fontPoint) point {
+				return point{x + p.X*scale, y - p.Y*scale}
+			}))
+		}
+		x += font.advance(glyph) * size / 1000
+	}
+	c.fill(contours, col)
+}
+
+// flattenContour переводит контур из квадратичных кривых TrueType в ломаную
+func flattenContour(contour []fontPoint, transform func(fontPoint) point) []point {
+	if len(contour) == 0 {
+		return nil
+	}
+	midpoint := func(a, b fontPoint) fontPoint {
+		return fontPoint{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2, On: true}
+	}
+
+	// обход начинается с точки на кривой; если их нет, с подразумеваемой середины между контрольными
+	var sequence []fontPoint
+	start := -1
+	for i, p := range contour {
+		if p.On {
+			start = i
+			break
+		}
+	}
+	if start >= 0 {
+		sequence = append(append(sequence, contour[start:]...), contour[:start]...)
+	} else {
+		sequence = append([]fontPoint{midpoint(contour[len(contour)-1], contour[0])}, contour...)
+	}
+
+	const steps = 6
+	current := transform(sequence[0])
+	result := []point{current}
+	for i := 1; i <= len(sequence); i++ {
+		p := sequence[i%len(sequence)]
+		if p.On {
+			current = transform(p)
+			result = append(result, current)
+			continue
+		}
+		// две контрольные точки подряд подразумевают точку на кривой посередине
+		next := sequence[(i+1)%len(sequence)]
+		end := midpoint(p, next)
+		if next.On {
+			end = next
+			i++
+		}
+		control, to := transform(p), transform(end)
+		for step := 1; step <= steps; step++ {
+			result = append(result, quadAt(current, control, to, float64(step)/steps))
+		}
+		current = to
+	}
+	return result
+}
@@ -0,0 +1,211 @@
+package exports
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Transcript читаемая стенограмма дискуссии: шапка, хронология и итоги
+type Transcript struct {
+	ID           int
+	RoomName     string
+	Mode         string
+	SubType      string
+	Topic        string
+	Subtopic     string
+	Purpose      string
+	Description  string
+	KeyQuestions []string
+	Participants []string
+	Start        time.Time
+	End          time.Time
+	Duration     time.Duration
+
+	Teams        []structures.TeamInfo
+	Entries      []TranscriptEntry
+	Ratings      []RatingSummary
+	AudienceVote *structures.AudienceResult
+}
+
+// TranscriptEntry сообщение участника или системное событие (этап, вопрос повестки, решение)
+type TranscriptEntry struct {
+	Time     time.Time
+	System   bool
+	Username string
+	Content  string
+	Likes    int
+	Dislikes int
+}
+
+// RatingSummary средние оценки, полученные участником
+type RatingSummary struct {
+	Username         string
+	Professionalism  float64
+	ArgumentsQuality float64
+	Politeness       float64
+	Count            int
+}
+
+// LoadTranscript собирает стенограмму; тексты системных событий — на языке locale
+func LoadTranscript(db *sql.DB, discussionID int, locale string) (*Transcript, error) {
+	t := &Transcript{ID: discussionID}
+
+	var keyQuestionsJSON, participantsJSON, teamsJSON, phasesJSON, agendaJSON, audienceJSON []byte
+	var subtype, customTopic, customSubtopic, description, purpose sql.NullString
+	var topicID, subtopicID sql.NullInt64
+	var durationSeconds float64
+
+	err := db.QueryRow(`
+		SELECT room_name, mode, subtype, EXTRACT(EPOCH FROM duration), start_time, end_time,
+			key_questions, participants, topic_id, subtopic_id,
+			custom_topic, custom_subtopic, description, purpose,
+			teams, phases, agenda, audience_vote
+		FROM discussions
+		WHERE id = $1`, discussionID).Scan(
+		&t.RoomName, &t.Mode, &subtype, &durationSeconds, &t.Start, &t.End,
+		&keyQuestionsJSON, &participantsJSON, &topicID, &subtopicID,
+		&customTopic, &customSubtopic, &description, &purpose,
+		&teamsJSON, &phasesJSON, &agendaJSON, &audienceJSON,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDiscussionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t.SubType = subtype.String
+	t.Purpose = purpose.String
+	t.Description = description.String
+	t.Duration = time.Duration(durationSeconds) * time.Second
+	t.Topic, t.Subtopic = customTopic.String, customSubtopic.String
+	if mode, ok := modes.Get(t.Mode, t.SubType); ok {
+		t.Topic, t.Subtopic = mode.TopicNames(int(topicID.Int64), int(subtopicID.Int64), customTopic.String, customSubtopic.String)
+	}
+
+	var phases []structures.PhaseBoundary
+	var agenda []structures.AgendaBoundary
+	for _, field := range []struct {
+		data   []byte
+		target any
+		name   string
+	}{
+		{keyQuestionsJSON, &t.KeyQuestions, "key questions"},
+		{participantsJSON, &t.Participants, "participants"},
+		{teamsJSON, &t.Teams, "teams"},
+		{phasesJSON, &phases, "phases"},
+		{agendaJSON, &agenda, "agenda"},
+		{audienceJSON, &t.AudienceVote, "audience vote"},
+	} {
+		if field.data == nil {
+			continue
+		}
+		if err = json.Unmarshal(field.data, field.target); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", field.name, err)
+		}
+	}
+
+	messages, err := storage.LoadMessages(db, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range messages {
+		t.Entries = append(t.Entries, TranscriptEntry{
+			Time:     msg.Timestamp,
+			System:   msg.Type != "usual",
+			Username: msg.Username,
+			Content:  msg.Content,
+			Likes:    len(msg.LikedBy),
+			Dislikes: len(msg.DislikedBy),
+		})
+	}
+	for _, phase := range phases {
+		t.Entries = append(t.Entries, systemEntry(phase.Start, i18n.T(locale, "transcript_event_phase", i18n.Params{"title": phase.Title})))
+	}
+	for _, item := range agenda {
+		t.Entries = append(t.Entries, systemEntry(item.Start, i18n.T(locale, "transcript_event_agenda", i18n.Params{"question": item.Question})))
+	}
+
+	outcomeEntries, err := loadOutcomeEntries(db, discussionID, locale)
+	if err != nil {
+		return nil, err
+	}
+	t.Entries = append(t.Entries, outcomeEntries...)
+
+	// системные события идут перед сообщениями, отправленными в ту же секунду
+	sort.SliceStable(t.Entries, func(i, j int) bool {
+		if t.Entries[i].Time.Equal(t.Entries[j].Time) {
+			return t.Entries[i].System && !t.Entries[j].System
+		}
+		return t.Entries[i].Time.Before(t.Entries[j].Time)
+	})
+
+	if t.Ratings, err = loadRatingSummary(db, discussionID); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func systemEntry(at time.Time, content string) TranscriptEntry {
+	return TranscriptEntry{Time: at, System: true, Content: content}
+}
+
+func loadOutcomeEntries(db *sql.DB, discussionID int, locale string) ([]TranscriptEntry, error) {
+	rows, err := db.Query(`
+		SELECT o.kind, o.content, COALESCE(u.username, ''), o.created_at
+		FROM discussion_outcomes o
+		LEFT JOIN users u ON u.user_id = o.assignee_user_id
+		WHERE o.discussion_id = $1`, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []TranscriptEntry
+	for rows.Next() {
+		var kind, content, assignee string
+		var createdAt time.Time
+		if err = rows.Scan(&kind, &content, &assignee, &createdAt); err != nil {
+			return nil, err
+		}
+		key := "transcript_event_decision"
+		if kind == structures.OutcomeActionItem {
+			key = "transcript_event_action"
+		}
+		entries = append(entries, systemEntry(createdAt, i18n.T(locale, key, i18n.Params{"content": content, "assignee": assignee})))
+	}
+	return entries, rows.Err()
+}
+
+func loadRatingSummary(db *sql.DB, discussionID int) ([]RatingSummary, error) {
+	rows, err := db.Query(`
+		SELECT u.username, AVG(r.professionalism), AVG(r.arguments_quality), AVG(r.politeness), COUNT(*)
+		FROM ratings r
+		JOIN users u ON u.user_id = r.rated_user_id
+		WHERE r.discussion_id = $1
+		GROUP BY u.username
+		ORDER BY u.username`, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summary []RatingSummary
+	for rows.Next() {
+		var s RatingSummary
+		if err = rows.Scan(&s.Username, &s.Professionalism, &s.ArgumentsQuality, &s.Politeness, &s.Count); err != nil {
+			return nil, err
+		}
+		summary = append(summary, s)
+	}
+	return summary, rows.Err()
+}
@@ -1,17 +1,18 @@
 package handlers
 
 import (
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
 	"awesomeChat/internal/structures"
 	"awesomeChat/package/logger"
-	"database/sql"
-	"encoding/json"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
-func GetDiscussionByID(c *gin.Context, db *sql.DB) {
+func GetDiscussionByID(c *gin.Context, repos *storage.Repos) {
 	idParam := c.Param("id")
 	discussionID, err := strconv.Atoi(idParam)
 	if err != nil {
@@ -19,185 +20,195 @@ func GetDiscussionByID(c *gin.Context, db *sql.DB) {
 		return
 	}
 
-	var response structures.DiscussionResponse
-	var messagesJSON []byte
-	var keyQuestionsJSON []byte
-	var tagsJSON []byte
-	var exportOptionsJSON []byte
-	var participantsJSON []byte
-
-	row := db.QueryRow(`
-		SELECT 
-			id, room_id, mode, subtype, 
-			duration, start_time, end_time,
-			messages, creator_username,
-			key_questions, tags, export_options,
-			participants, 
-			COALESCE(custom_topic, '') as topic,
-			COALESCE(custom_subtopic, '') as subtopic,
-			description, purpose, room_name, public
-		FROM discussions 
-		WHERE id = $1
-	`, discussionID)
-
-	err = row.Scan(
-		&response.ID,
-		&response.RoomID,
-		&response.Mode,
-		&response.SubType,
-		&response.Duration,
-		&response.StartTime,
-		&response.EndTime,
-		&messagesJSON,
-		&response.Creator,
-		&keyQuestionsJSON,
-		&tagsJSON,
-		&exportOptionsJSON,
-		&participantsJSON,
-		&response.Topic,
-		&response.Subtopic,
-		&response.Description,
-		&response.Purpose,
-		&response.RoomName,
-		&response.Public,
-	)
-
+	discussion, err := repos.Discussions.Get(discussionID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, storage.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Discussion not found"})
 			return
 		}
+		logger.Log.Errorln("Discussion query error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
 		return
 	}
 
-	if err = json.Unmarshal(messagesJSON, &response.Messages); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse messages"})
-		return
+	// дискуссии организации не видны за ее пределами
+	if discussion.OrganizationID != 0 {
+		member, err := repos.Users.IsMember(discussion.OrganizationID, requestUser(c))
+		if err != nil || !member {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+			return
+		}
 	}
 
-	if err = json.Unmarshal(keyQuestionsJSON, &response.KeyQuestions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse key questions"})
+	response := discussion.DiscussionResponse
+	if response.Messages, err = repos.Discussions.Messages(discussionID); err != nil {
+		logger.Log.Errorln("Messages query error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load messages"})
 		return
 	}
 
-	if err = json.Unmarshal(tagsJSON, &response.Tags); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse tags"})
-		return
+	if len(response.KeyQuestions) > 0 {
+		response.Agenda = agendaSections(response.KeyQuestions, discussion.AgendaLog, response.Messages)
 	}
 
-	if err = json.Unmarshal(exportOptionsJSON, &response.ExportOptions); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse export options"})
+	c.JSON(http.StatusOK, response)
+}
+
+// GetArchives страница архива; ?sort= — newest, longest, messages, rating или participants
+func GetArchives(c *gin.Context, repos *storage.Repos) {
+	filter, ok := archiveFilter(c, repos.Users, archiveSorts...)
+	if !ok {
 		return
 	}
 
-	if err = json.Unmarshal(participantsJSON, &response.Participants); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse participants"})
+	page, err := repos.Discussions.List(filter)
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		logger.Log.Errorln("Archive query error:", err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	for i := range page.Items {
+		topicNames(&page.Items[i])
+	}
+	if page.Items == nil {
+		page.Items = []structures.ArchiveItem{}
+	}
+
+	c.JSON(http.StatusOK, structures.ArchiveResponse{
+		Data:       page.Items,
+		Total:      page.Total,
+		Page:       pageNumber(filter.PageRequest),
+		Limit:      filter.Limit,
+		Sort:       filter.Sort,
+		NextCursor: encodeCursor(page.Next),
+	})
 }
 
-func GetArchives(c *gin.Context, db *sql.DB) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if page < 1 {
-		page = 1
-	}
-
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	if limit < 1 || limit > 100 {
-		limit = 20
-	}
-
-	offset := (page - 1) * limit
-
-	username := c.Query("username")
-	//username, exists := c.Get("username")
-	//if !exists {
-	//	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-	//	return
-	//}
-
-	var response structures.ArchiveResponse
-	var items []structures.ArchiveItem
-
-	rows, err := db.Query(`
-        SELECT 
-            id, 
-            room_name as name,
-            mode, 
-            subtype, 
-            key_questions, 
-            tags,
-            topic_id, 
-            subtopic_id,
-            custom_topic, 
-            custom_subtopic,
-            public,
-            jsonb_array_length(participants) as participants_count
-        FROM discussions
-        WHERE public = true OR participants @> jsonb_build_array($1::text)
-        ORDER BY end_time DESC
-        LIMIT $2 OFFSET $3`,
-		username,
-		limit,
-		offset,
-	)
+// SearchArchives полнотекстовый поиск по архиву: ?q= в синтаксисе поисковиков и те же фильтры, что у GetArchives;
+// по умолчанию сортирует по релевантности
+func SearchArchives(c *gin.Context, repos *storage.Repos) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
 
+	filter, ok := archiveFilter(c, repos.Users, append([]string{storage.SortRelevance}, archiveSorts...)...)
+	if !ok {
+		return
+	}
+
+	page, err := repos.Discussions.Search(storage.SearchFilter{ArchiveFilter: filter, Query: query})
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
 	if err != nil {
+		logger.Log.Errorln("Archive search error:", err)
 		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "database error"})
 		return
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var item structures.ArchiveItem
-		var keyQuestionsJSON []byte
-		var tagsJSON []byte
-
-		err := rows.Scan(
-			&item.ID,
-			&item.Name,
-			&item.Mode,
-			&item.SubType,
-			&keyQuestionsJSON,
-			&tagsJSON,
-			&item.TopicID,
-			&item.SubtopicID,
-			&item.CustomTopic,
-			&item.CustomSubtopic,
-			&item.Public,
-			&item.ParticipantsCount,
-		)
-
-		if err != nil {
-			logger.Log.Error("Scan error:", err)
-			continue
+
+	for i := range page.Items {
+		topicNames(&page.Items[i].ArchiveItem)
+	}
+	if page.Items == nil {
+		page.Items = []structures.SearchItem{}
+	}
+
+	c.JSON(http.StatusOK, structures.SearchResponse{
+		Data:       page.Items,
+		Total:      page.Total,
+		Page:       pageNumber(filter.PageRequest),
+		Limit:      filter.Limit,
+		Sort:       filter.Sort,
+		NextCursor: encodeCursor(page.Next),
+	})
+}
+
+// archiveFilter разбирает страницу (см. pageRequest) и фильтры архива: organization, mode, subtype,
+// topic, tags, participant, from/to (дата начала) и mine=true. При ошибке сама отвечает клиенту
+func archiveFilter(c *gin.Context, users storage.UserRepo, sorts ...string) (storage.ArchiveFilter, bool) {
+	request, ok := pageRequest(c, sorts...)
+	if !ok {
+		return storage.ArchiveFilter{}, false
+	}
+
+	organizationID, ok := organizationParam(c, users)
+	if !ok {
+		return storage.ArchiveFilter{}, false
+	}
+
+	var topicID int
+	if topic := c.Query("topic"); topic != "" {
+		var err error
+		if topicID, err = strconv.Atoi(topic); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic ID"})
+			return storage.ArchiveFilter{}, false
 		}
+	}
 
-		err = json.Unmarshal(keyQuestionsJSON, &item.KeyQuestions)
-		if err != nil {
-			logger.Log.Error("Unmarshal error:", err)
-			return
+	from, to, ok := dateRangeParams(c)
+	if !ok {
+		return storage.ArchiveFilter{}, false
+	}
+
+	return storage.ArchiveFilter{
+		PageRequest:    request,
+		Username:       requestUser(c),
+		OrganizationID: organizationID,
+		Mode:           c.Query("mode"),
+		SubType:        c.Query("subtype"),
+		TopicID:        topicID,
+		Tags:           listParam(c, "tags"),
+		Participant:    c.Query("participant"),
+		From:           from,
+		To:             to,
+		OnlyMine:       c.Query("mine") == "true",
+	}, true
+}
+
+// topicNames названия темы и подтемы из каталога формата
+func topicNames(item *structures.ArchiveItem) {
+	if mode, ok := modes.Get(item.Mode, item.SubType); ok {
+		item.Topic, item.Subtopic = mode.TopicNames(item.TopicID, item.SubtopicID, item.CustomTopic, item.CustomSubtopic)
+	}
+}
+
+// agendaSections раскладывает стенограмму по ключевым вопросам. Сообщения без вопроса
+// попадают в раздел с индексом -1, если такие есть
+func agendaSections(questions []string, agenda []structures.AgendaBoundary, messages []structures.Message) []structures.AgendaSection {
+	sections := make([]structures.AgendaSection, len(questions))
+	for i, question := range questions {
+		sections[i] = structures.AgendaSection{
+			Index:     i,
+			Question:  question,
+			Intervals: []structures.AgendaBoundary{},
+			Messages:  []structures.Message{},
 		}
-		err = json.Unmarshal(tagsJSON, &item.Tags)
-		if err != nil {
-			logger.Log.Error("Unmarshal error:", err)
-			return
+	}
+	for _, boundary := range agenda {
+		if boundary.Index >= 0 && boundary.Index < len(sections) {
+			sections[boundary.Index].Intervals = append(sections[boundary.Index].Intervals, boundary)
 		}
+	}
 
-		if item.Mode == "personal" && item.SubType == "blitz" {
-			item.Topic = structures.TopicDB[item.TopicID]
-			item.Subtopic = structures.SubtopicDB[item.SubtopicID]
+	outside := structures.AgendaSection{Index: -1, Intervals: []structures.AgendaBoundary{}, Messages: []structures.Message{}}
+	for _, msg := range messages {
+		if msg.AgendaItem != nil && *msg.AgendaItem >= 0 && *msg.AgendaItem < len(sections) {
+			sections[*msg.AgendaItem].Messages = append(sections[*msg.AgendaItem].Messages, msg)
+			continue
 		}
-
-		items = append(items, item)
+		outside.Messages = append(outside.Messages, msg)
+	}
+	if len(outside.Messages) > 0 {
+		sections = append(sections, outside)
 	}
 
-	response.Data = items
-	response.Page = page
-	response.Limit = limit
-
-	c.JSON(http.StatusOK, response)
+	return sections
 }
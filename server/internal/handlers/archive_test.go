@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetArchives(t *testing.T) {
+	store := newTestStore(t)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	public := testDiscussion(base, "bob", "carol")
+	public.Public = true
+	publicID := store.AddDiscussion(public, nil)
+	ownID := store.AddDiscussion(testDiscussion(base.Add(time.Hour), "alice", "bob"), nil)
+	store.AddDiscussion(testDiscussion(base.Add(2*time.Hour), "bob", "carol"), nil)
+	organization := testDiscussion(base.Add(3*time.Hour), "bob", "carol")
+	organization.Public = true
+	organization.OrganizationID = 7
+	organizationDiscussionID := store.AddDiscussion(organization, nil)
+	store.AddMember(7, "alice")
+	repos := store.Repos()
+
+	archive := func(target string) *archivePage {
+		recorder := perform(t, http.MethodGet, target, nil, nil, func(c *gin.Context) {
+			GetArchives(c, repos)
+		})
+		expectStatus(t, recorder, http.StatusOK)
+		var response archivePage
+		decode(t, recorder, &response)
+		return &response
+	}
+
+	t.Run("public and own discussions, newest first", func(t *testing.T) {
+		response := archive("/archives?username=alice")
+		expectIDs(t, response.Data, ownID, publicID)
+		if response.Data[0].Topic != "" || response.Data[0].ParticipantsCount != 2 {
+			t.Fatalf("unexpected item %+v", response.Data[0])
+		}
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		response := archive("/archives?username=alice&page=2&limit=1")
+		expectIDs(t, response.Data, publicID)
+		if response.Page != 2 || response.Limit != 1 || response.Total != 2 {
+			t.Fatalf("page = %d, limit = %d, total = %d", response.Page, response.Limit, response.Total)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		expectIDs(t, archive("/archives?username=alice&mine=true").Data, ownID)
+		expectIDs(t, archive("/archives?username=alice&participant=carol").Data, publicID)
+		expectIDs(t, archive("/archives?username=alice&from=2024-05-01&to=2024-05-01").Data, ownID, publicID)
+		expectIDs(t, archive("/archives?username=alice&mode=professional").Data)
+	})
+
+	t.Run("empty page is an empty list", func(t *testing.T) {
+		recorder := perform(t, http.MethodGet, "/archives?username=alice&mode=professional", nil, nil, func(c *gin.Context) {
+			GetArchives(c, repos)
+		})
+		expectStatus(t, recorder, http.StatusOK)
+		if body := recorder.Body.String(); !strings.Contains(body, `"data":[]`) {
+			t.Fatalf("empty page is not an empty list: %s", body)
+		}
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		recorder := perform(t, http.MethodGet, "/archives?username=alice&from=yesterday", nil, nil, func(c *gin.Context) {
+			GetArchives(c, repos)
+		})
+		expectStatus(t, recorder, http.StatusBadRequest)
+	})
+
+	t.Run("organization archive", func(t *testing.T) {
+		response := archive("/archives?username=alice&organization=7")
+		expectIDs(t, response.Data, organizationDiscussionID)
+	})
+
+	t.Run("not a member of the organization", func(t *testing.T) {
+		recorder := perform(t, http.MethodGet, "/archives?username=bob&organization=7", nil, nil, func(c *gin.Context) {
+			GetArchives(c, repos)
+		})
+		expectStatus(t, recorder, http.StatusForbidden)
+	})
+}
+
+func TestGetArchivesCursor(t *testing.T) {
+	store := newTestStore(t)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	var ids []int
+	for i := 0; i < 5; i++ {
+		ids = append(ids, store.AddDiscussion(testDiscussion(base.Add(time.Duration(i)*time.Hour), "alice", "bob"), nil))
+	}
+	crowded := testDiscussion(base, "alice", "bob", "carol")
+	crowdedID := store.AddDiscussion(crowded, []structures.Message{testMessage("m1", "carol")})
+	// та же дата окончания, что у первой: порядок решает id
+	sameEndID := store.AddDiscussion(testDiscussion(base, "alice", "bob"), nil)
+	repos := store.Repos()
+
+	archive := func(target string) *archivePage {
+		recorder := perform(t, http.MethodGet, target, nil, nil, func(c *gin.Context) {
+			GetArchives(c, repos)
+		})
+		expectStatus(t, recorder, http.StatusOK)
+		var response archivePage
+		decode(t, recorder, &response)
+		return &response
+	}
+
+	t.Run("walks all pages without duplicates", func(t *testing.T) {
+		var got []structures.ArchiveItem
+		target := "/archives?username=alice&limit=3"
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatal("cursor does not advance")
+			}
+			response := archive(target)
+			if pages == 0 && (response.Total != 7 || response.Sort != "newest") {
+				t.Fatalf("total = %d, sort = %q", response.Total, response.Sort)
+			}
+			got = append(got, response.Data...)
+			if response.NextCursor == "" {
+				break
+			}
+			// новая дискуссия не сдвигает следующие страницы
+			store.AddDiscussion(testDiscussion(base.Add(10*time.Hour), "alice", "bob"), nil)
+			target = "/archives?username=alice&limit=3&cursor=" + response.NextCursor
+		}
+		expectIDs(t, got, ids[4], ids[3], ids[2], ids[1], sameEndID, crowdedID, ids[0])
+	})
+
+	t.Run("sort by participants", func(t *testing.T) {
+		response := archive("/archives?username=alice&sort=participants&limit=1")
+		expectIDs(t, response.Data, crowdedID)
+		if response.NextCursor == "" {
+			t.Fatal("next cursor is empty")
+		}
+	})
+
+	t.Run("sort by messages", func(t *testing.T) {
+		expectIDs(t, archive("/archives?username=alice&sort=messages&limit=1").Data, crowdedID)
+	})
+
+	for _, tt := range []struct {
+		name   string
+		target func() string
+	}{
+		{"unknown sort", func() string { return "/archives?username=alice&sort=oldest" }},
+		{"broken cursor", func() string { return "/archives?username=alice&cursor=abc" }},
+		{"forged cursor key", func() string {
+			return "/archives?username=alice&cursor=" + storage.Cursor{Sort: storage.SortNewest, Key: "yesterday", ID: 1}.Encode()
+		}},
+		{"cursor of another sort", func() string {
+			return "/archives?username=alice&sort=longest&cursor=" + archive("/archives?username=alice&limit=1").NextCursor
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := perform(t, http.MethodGet, tt.target(), nil, nil, func(c *gin.Context) {
+				GetArchives(c, repos)
+			})
+			expectStatus(t, recorder, http.StatusBadRequest)
+		})
+	}
+}
+
+// archivePage ответ GetArchives
+type archivePage struct {
+	Data       []structures.ArchiveItem `json:"data"`
+	Total      int                      `json:"total"`
+	Page       int                      `json:"page"`
+	Limit      int                      `json:"limit"`
+	Sort       string                   `json:"sort"`
+	NextCursor string                   `json:"next_cursor"`
+}
+
+func expectIDs(t *testing.T, items []structures.ArchiveItem, ids ...int) {
+	t.Helper()
+	if len(items) != len(ids) {
+		t.Fatalf("got %d items %+v, want ids %v", len(items), items, ids)
+	}
+	for i, id := range ids {
+		if items[i].ID != id {
+			t.Fatalf("item %d id = %d, want %d", i, items[i].ID, id)
+		}
+	}
+}
+
+func TestSearchArchives(t *testing.T) {
+	store := newTestStore(t)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	byName := testDiscussion(base, "alice", "bob")
+	byName.RoomName = "Climate <policy>"
+	byNameID := store.AddDiscussion(byName, nil)
+	byMessage := testDiscussion(base.Add(time.Hour), "alice", "carol")
+	climate := testMessage("m1", "carol")
+	climate.Content = "climate matters"
+	byMessageID := store.AddDiscussion(byMessage, []structures.Message{climate, testMessage("m2", "alice")})
+	store.AddDiscussion(testDiscussion(base.Add(2*time.Hour), "bob", "carol"), []structures.Message{climate})
+	repos := store.Repos()
+
+	search := func(target string) *httptest.ResponseRecorder {
+		return perform(t, http.MethodGet, target, nil, nil, func(c *gin.Context) {
+			SearchArchives(c, repos)
+		})
+	}
+
+	recorder := search("/archive/search?username=alice&q=Climate")
+	expectStatus(t, recorder, http.StatusOK)
+	var response structures.SearchResponse
+	decode(t, recorder, &response)
+	if response.Total != 2 || len(response.Data) != 2 {
+		t.Fatalf("unexpected response %+v", response)
+	}
+	// совпадение в названии (вес A) важнее совпадения в переписке (D), хотя та дискуссия новее
+	if response.Data[0].ID != byNameID || response.Data[1].ID != byMessageID {
+		t.Fatalf("unexpected order %d, %d", response.Data[0].ID, response.Data[1].ID)
+	}
+	if response.Data[0].Rank <= response.Data[1].Rank {
+		t.Fatalf("room name rank %v is not above message rank %v", response.Data[0].Rank, response.Data[1].Rank)
+	}
+	if text := response.Data[0].Snippets[0].Text; text != "<mark>Climate</mark> &lt;policy&gt;" {
+		t.Fatalf("snippet is not escaped: %q", text)
+	}
+	snippet := response.Data[1].Snippets[0]
+	if snippet.Field != "message" || snippet.MessageID != "m1" || snippet.Text != "<mark>climate</mark> matters" {
+		t.Fatalf("unexpected message snippet %+v", snippet)
+	}
+
+	t.Run("every word must match", func(t *testing.T) {
+		recorder := search("/archive/search?username=alice&q=climate+policy")
+		expectStatus(t, recorder, http.StatusOK)
+		var response structures.SearchResponse
+		decode(t, recorder, &response)
+		if response.Total != 1 || response.Data[0].ID != byNameID {
+			t.Fatalf("unexpected response %+v", response)
+		}
+	})
+
+	t.Run("words must match in one place", func(t *testing.T) {
+		// «matters» только в сообщении, «room» только в названии: как и tsquery, слова не складываются
+		// из полей и сообщений, а сообщение m2 со словом «message» не дополняет m1
+		for _, query := range []string{"room+matters", "climate+message"} {
+			recorder := search("/archive/search?username=alice&q=" + query)
+			expectStatus(t, recorder, http.StatusOK)
+			var response structures.SearchResponse
+			decode(t, recorder, &response)
+			if response.Total != 0 {
+				t.Fatalf("q=%s: unexpected response %+v", query, response)
+			}
+		}
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		expectStatus(t, search("/archive/search?username=alice&q=+"), http.StatusBadRequest)
+	})
+}
+
+func TestGetDiscussionByID(t *testing.T) {
+	store := newTestStore(t)
+	discussion := testDiscussion(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), "alice", "bob")
+	discussion.KeyQuestions = []string{"Why?"}
+	discussion.AgendaLog = []structures.AgendaBoundary{{Index: 0, Question: "Why?"}}
+	first := 0
+	withQuestion := testMessage("m1", "alice", "bob")
+	withQuestion.AgendaItem = &first
+	id := store.AddDiscussion(discussion, []structures.Message{withQuestion, testMessage("m2", "bob")})
+
+	hidden := testDiscussion(time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC), "bob", "carol")
+	hidden.OrganizationID = 3
+	hiddenID := store.AddDiscussion(hidden, nil)
+	repos := store.Repos()
+
+	get := func(username, discussionID string) *httptest.ResponseRecorder {
+		return perform(t, http.MethodGet, "/discussion/"+discussionID+"?username="+username, nil,
+			gin.Params{{Key: "id", Value: discussionID}}, func(c *gin.Context) {
+				GetDiscussionByID(c, repos)
+			})
+	}
+
+	t.Run("messages and agenda", func(t *testing.T) {
+		recorder := get("alice", strconv.Itoa(id))
+		expectStatus(t, recorder, http.StatusOK)
+		var body structures.DiscussionResponse
+		decode(t, recorder, &body)
+		if len(body.Messages) != 2 || body.Messages[0].LikeCount != 1 || body.Messages[0].LikedBy[0] != "bob" {
+			t.Fatalf("unexpected messages %+v", body.Messages)
+		}
+		if len(body.Agenda) != 2 || len(body.Agenda[0].Messages) != 1 || body.Agenda[1].Index != -1 {
+			t.Fatalf("unexpected agenda %+v", body.Agenda)
+		}
+	})
+
+	for _, tt := range []struct {
+		name     string
+		username string
+		id       string
+		status   int
+	}{
+		{"invalid id", "alice", "abc", http.StatusBadRequest},
+		{"not found", "alice", "100", http.StatusNotFound},
+		{"organization discussion", "alice", strconv.Itoa(hiddenID), http.StatusForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			expectStatus(t, get(tt.username, tt.id), tt.status)
+		})
+	}
+}
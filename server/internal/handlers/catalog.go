@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"awesomeChat/internal/catalog"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func GetCatalog(c *gin.Context) {
+	includeDeleted := c.Query("deleted") == "true"
+	c.JSON(http.StatusOK, catalog.Topics(includeDeleted))
+}
+
+func GetCatalogSubtopic(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subtopic ID"})
+		return
+	}
+
+	subtopic, ok := catalog.Subtopic(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subtopic not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subtopic)
+}
+
+func CreateTopic(c *gin.Context, db *sql.DB) {
+	var req structures.TopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	id, err := catalog.CreateTopic(db, req.Name)
+	if err != nil {
+		handleCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Topic created", "id": id})
+}
+
+func UpdateTopic(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic ID"})
+		return
+	}
+
+	var req structures.TopicRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err = catalog.UpdateTopic(db, id, req.Name); err != nil {
+		handleCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Topic updated"})
+}
+
+func DeleteTopic(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic ID"})
+		return
+	}
+
+	if err = catalog.DeleteTopic(db, id); err != nil {
+		handleCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Topic deleted"})
+}
+
+func CreateSubtopic(c *gin.Context, db *sql.DB) {
+	var req structures.SubtopicRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	id, err := catalog.CreateSubtopic(db, req.TopicID, req.Name, req.Theses)
+	if err != nil {
+		handleCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subtopic created", "id": id})
+}
+
+func UpdateSubtopic(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subtopic ID"})
+		return
+	}
+
+	var req structures.SubtopicRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err = catalog.UpdateSubtopic(db, id, req.TopicID, req.Name, req.Theses); err != nil {
+		handleCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subtopic updated"})
+}
+
+func DeleteSubtopic(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subtopic ID"})
+		return
+	}
+
+	if err = catalog.DeleteSubtopic(db, id); err != nil {
+		handleCatalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subtopic deleted"})
+}
+
+func handleCatalogError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, catalog.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, catalog.ErrTooFewTheses):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subtopic needs at least two theses"})
+	default:
+		logger.Log.Errorln("Catalog error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+	}
+}
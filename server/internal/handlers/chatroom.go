@@ -1,15 +1,21 @@
 package handlers
 
 import (
+	"awesomeChat/internal/catalog"
+	"awesomeChat/internal/exports"
 	"awesomeChat/internal/informing"
+	"awesomeChat/internal/modes"
 	"awesomeChat/internal/myws"
+	"awesomeChat/internal/organizations"
+	"awesomeChat/internal/storage"
 	"awesomeChat/internal/structures"
 	"awesomeChat/package/logger"
 	"awesomeChat/package/web"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"math/rand"
+	"github.com/google/uuid"
 	"net/http"
 	"strconv"
 	"time"
@@ -17,28 +23,41 @@ import (
 
 const maxRooms = 1000
 
-func ConnectToChatroom(c *gin.Context, db *sql.DB, rooms *map[int]*structures.Room) {
+func ConnectToChatroom(c *gin.Context, db *sql.DB, repos *storage.Repos, rooms *structures.Rooms) {
 	chatNumber, _ := strconv.Atoi(c.Param("num"))
-	username := c.Query("username")
+	username := requestUser(c)
 	password := c.Query("password")
 	logger.Log.Traceln(username + " wants to connect to room " + c.Param("num"))
 
-	room, exists := (*rooms)[chatNumber]
+	room, exists := rooms.Get(chatNumber)
 	if !exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Room does not exists"})
 		return
 	}
 
-	if room.DiscussionActive {
+	spectator := c.Query("spectator") == "true"
+	locale := requestLocale(c, repos.Users)
+
+	if room.DiscussionActive && !spectator {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Room already discussion active"})
 		return
 	}
 
+	if room.OrganizationID != 0 && !organizations.IsMember(db, room.OrganizationID, username) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+		return
+	}
+
 	if !room.Open && room.Password != password {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Wrong password"})
 		return
 	}
 
+	if spectator {
+		watchChatroom(c, room, username, locale)
+		return
+	}
+
 	websocket, err := web.UpgradeConnection(c)
 	if err != nil {
 		logger.Log.Errorln("Error upgrading connection:", err)
@@ -46,12 +65,13 @@ func ConnectToChatroom(c *gin.Context, db *sql.DB, rooms *map[int]*structures.Ro
 		return
 	}
 
-	users := &((*rooms)[chatNumber].Users)
+	users := &room.Users
 
 	if len(*users) < room.MaxUsers {
 		currentUser := structures.ChatUser{
 			Name:       username,
 			Connection: websocket,
+			Locale:     locale,
 		}
 		*users = append(*users, &currentUser)
 		logger.Log.Traceln(currentUser.Name + " added to room №" + strconv.Itoa(chatNumber))
@@ -61,51 +81,60 @@ func ConnectToChatroom(c *gin.Context, db *sql.DB, rooms *map[int]*structures.Ro
 		return
 	}
 
-	logger.Log.Traceln(fmt.Sprintf("Current amount of users in room %d: %d", chatNumber, len((*rooms)[chatNumber].Users)))
+	logger.Log.Traceln(fmt.Sprintf("Current amount of users in room %d: %d", chatNumber, len(room.Users)))
 	informing.SetRoomName(room)
 	informing.InformUserJoined(room, username)
-	go myws.Reader(db, websocket, room, rooms)
+	go myws.Reader(db, repos, websocket, room, rooms)
 }
 
-func getRandomAvailableRoomNumber(rooms *map[int]*structures.Room, maxRooms int) int {
-	for {
-		rand.Seed(time.Now().UnixNano())
-		randomRoom := rand.Intn(maxRooms)
-		if _, ok := (*rooms)[randomRoom]; !ok {
-			return randomRoom
-		}
+// watchChatroom подключает пользователя к комнате зрителем: без лимита мест и без права писать в чат
+func watchChatroom(c *gin.Context, room *structures.Room, username string, locale string) {
+	websocket, err := web.UpgradeConnection(c)
+	if err != nil {
+		logger.Log.Errorln("Error upgrading connection:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade connection"})
+		return
+	}
+
+	spectator := &structures.ChatUser{
+		Name:       username,
+		Connection: websocket,
+		Locale:     locale,
+	}
+
+	room.Mu.Lock()
+	room.Spectators = append(room.Spectators, spectator)
+	poll := room.AudiencePoll
+	room.Mu.Unlock()
+	logger.Log.Traceln(username + " watches room №" + strconv.Itoa(room.ID))
+
+	informing.SetRoomName(room)
+	if poll != "" {
+		informing.SendAudiencePollTo(spectator, poll, catalog.Theses(room.SubtopicID))
 	}
+	go myws.SpectatorReader(websocket, room)
 }
 
-func CreateChatroom(c *gin.Context, rooms *map[int]*structures.Room) {
-	var req struct {
-		Name            string   `json:"name"`
-		Mode            string   `json:"mode"`
-		SubType         string   `json:"subType"`
-		Timer           int      `json:"timer"`
-		MaxParticipants int      `json:"maxUsers"`
-		Description     string   `json:"description"`
-		Password        string   `json:"password"`
-		Purpose         string   `json:"purpose"`
-		KeyQuestions    []string `json:"keyQuestions"`
-		Tags            []string `json:"tags"`
-		Hidden          bool     `json:"hidden"`
-		ExportOptions   []string `json:"exportOptions"`
-		DontJoin        bool     `json:"dontJoin"`
-		Topic           int      `json:"topic"`          // blitz
-		Subtopic        int      `json:"subtopic"`       // blitz
-		CustomTopic     string   `json:"customTopic"`    // free
-		CustomSubtopic  string   `json:"customSubtopic"` // free
-		Open            bool     `json:"open"`
-		CreatorName     string   `json:"creatorName"`
-	}
-
-	if err := c.BindJSON(&req); err != nil {
+func CreateChatroom(c *gin.Context, db *sql.DB, rooms *structures.Rooms) {
+	var req structures.CreateRoomRequest
+
+	body, err := c.GetRawData()
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
 		logger.Log.Errorf("Failed to bind request: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
+	if req.TemplateID != 0 {
+		if err = applyTemplate(db, requestUser(c), body, &req); err != nil {
+			respondTemplateError(c, err)
+			return
+		}
+	}
+
 	logger.Log.Traceln(req)
 
 	if !req.Open && req.Password == "" {
@@ -114,11 +143,21 @@ func CreateChatroom(c *gin.Context, rooms *map[int]*structures.Room) {
 		return
 	}
 
+	if req.OrganizationID != 0 && !organizations.IsMember(db, req.OrganizationID, requestUser(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+		return
+	}
+
 	if req.MaxParticipants <= 1 {
 		req.MaxParticipants = 2
 	}
 
-	chatNumber := getRandomAvailableRoomNumber(rooms, maxRooms)
+	mode, ok := modes.Get(req.Mode, req.SubType)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown mode"})
+		return
+	}
+
 	//username := c.Query("username") // можно передавать имя пользователя как query-параметр
 
 	//websocket, err := web.UpgradeConnection(c)
@@ -133,8 +172,56 @@ func CreateChatroom(c *gin.Context, rooms *map[int]*structures.Room) {
 	//	Connection: websocket,
 	//}
 
-	room := &structures.Room{
-		ID:              chatNumber,
+	room := newRoom(req)
+
+	if err := mode.Configure(room, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validatePhases(room.Phases, mode.Sides(room)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := exports.Validate(room.ExportOptions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := validateAgenda(room.KeyQuestions, room.AgendaTimeBoxes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// при заданных этапах длительность дискуссии — их сумма
+	if len(room.Phases) > 0 {
+		total := 0
+		for _, phase := range room.Phases {
+			total += phase.Duration
+		}
+		room.Duration = time.Duration(total) * time.Second
+	}
+
+	if err := rooms.Add(room, maxRooms); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Too many rooms"})
+		return
+	}
+	logger.Log.Traceln("Created room №" + strconv.Itoa(room.ID))
+	logger.Log.Traceln("room: ", room)
+	//logger.Log.Traceln(currentUser.Name + " added to room")
+
+	//informing.SetRoomName(room)
+	//informing.InformUserJoined(room, username)
+	//go myws.Reader(websocket, room, rooms)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Room created", "roomID": room.ID, "wsUrl": "/ws/chat/" + strconv.Itoa(room.ID)})
+}
+
+// newRoom собирает комнату из параметров создания; поля формата заполняет Mode.Configure,
+// номер — Rooms.Add
+func newRoom(req structures.CreateRoomRequest) *structures.Room {
+	return &structures.Room{
 		Name:            req.Name,
 		Open:            req.Open,
 		Password:        req.Password,
@@ -144,8 +231,11 @@ func CreateChatroom(c *gin.Context, rooms *map[int]*structures.Room) {
 		Description:     req.Description,
 		Purpose:         req.Purpose,
 		KeyQuestions:    req.KeyQuestions,
+		AgendaTimeBoxes: req.AgendaTimeBoxes,
+		AgendaIndex:     -1,
 		Tags:            req.Tags,
 		Hidden:          req.Hidden,
+		OrganizationID:  req.OrganizationID,
 		ExportOptions:   req.ExportOptions,
 		DontJoin:        req.DontJoin,
 		Duration:        time.Duration(req.Timer) * time.Minute,
@@ -154,26 +244,39 @@ func CreateChatroom(c *gin.Context, rooms *map[int]*structures.Room) {
 		UserTheses:      make(map[string]string),
 		CreatorUsername: req.CreatorName,
 		Messages:        make([]structures.Message, 0),
+		Session:         uuid.New().String(),
 		Participants:    make([]string, 0),
+		AudienceBefore:  make(map[string]int),
+		AudienceAfter:   make(map[string]int),
+		Phases:          req.Phases,
 	}
+}
 
-	if req.Mode == "personal" && req.SubType == "blitz" {
-		room.TopicID = req.Topic
-		room.SubtopicID = req.Subtopic
-		room.Duration = 10 * time.Minute
-	} else if req.Mode == "personal" && req.SubType == "free" {
-		room.CustomTopic = req.CustomTopic
-		room.CustomSubtopic = req.CustomSubtopic
+func validateAgenda(questions []string, timeBoxes []int) error {
+	if len(timeBoxes) > len(questions) {
+		return fmt.Errorf("Time boxes are set for %d key questions, but there are only %d", len(timeBoxes), len(questions))
 	}
+	for i, timeBox := range timeBoxes {
+		if timeBox < 0 {
+			return fmt.Errorf("Key question %d has invalid time box", i+1)
+		}
+	}
+	return nil
+}
 
-	(*rooms)[chatNumber] = room
-	logger.Log.Traceln("Created room №" + strconv.Itoa(chatNumber))
-	logger.Log.Traceln("room: ", room)
-	//logger.Log.Traceln(currentUser.Name + " added to room")
-
-	//informing.SetRoomName(room)
-	//informing.InformUserJoined(room, username)
-	//go myws.Reader(websocket, room, rooms)
-
-	c.JSON(http.StatusOK, gin.H{"message": "Room created", "roomID": chatNumber, "wsUrl": "/ws/chat/" + strconv.Itoa(room.ID)})
+func validatePhases(phases []structures.Phase, sides int) error {
+	for i, phase := range phases {
+		if phase.Duration <= 0 {
+			return fmt.Errorf("Phase %d has invalid duration", i+1)
+		}
+		if phase.MessagesPerSpeaker < 0 {
+			return fmt.Errorf("Phase %d has invalid message limit", i+1)
+		}
+		for _, side := range phase.Sides {
+			if side < 0 || side >= sides {
+				return fmt.Errorf("Phase %d has unknown side %d", i+1, side)
+			}
+		}
+	}
+	return nil
 }
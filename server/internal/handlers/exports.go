@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"awesomeChat/internal/exports"
+	"awesomeChat/internal/storage"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// GetDiscussionCSVByID статистика участников; строится заново, сохраненная копия не трогается
+func GetDiscussionCSVByID(c *gin.Context, repos *storage.Repos) {
+	discussionID, ok := discussionVisible(c, repos)
+	if !ok {
+		return
+	}
+
+	data, err := exports.BuildStatsCSV(repos, discussionID)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	exporter, _ := exports.Get("csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", exports.Filename(exporter, discussionID)))
+	c.Data(http.StatusOK, exporter.ContentType, data)
+}
+
+// GetDiscussionGraphByID граф взаимодействий; ?format=png|svg|dot|graphml|gexf|json, по умолчанию png
+func GetDiscussionGraphByID(c *gin.Context, repos *storage.Repos) {
+	discussionID, ok := discussionVisible(c, repos)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "png")
+	data, graphFormat, err := exports.BuildGraph(repos, discussionID, format)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=discussion_%d_graph.%s", discussionID, format))
+	c.Data(http.StatusOK, graphFormat.ContentType, data)
+}
+
+// GetDiscussionMessages построчная выгрузка сообщений; ?format=csv|ndjson, по умолчанию csv.
+// Строки пишутся в ответ по мере чтения из базы
+func GetDiscussionMessages(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	discussionID, ok := discussionVisible(c, repos)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	contentType, ok := exports.MessageFormats[format]
+	if !ok {
+		respondExportError(c, fmt.Errorf("%w: %s", exports.ErrUnknownMessageFormat, format))
+		return
+	}
+
+	stream, err := exports.OpenMessageStream(db, repos, discussionID)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+	defer stream.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=discussion_%d_messages.%s", discussionID, format))
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	// заголовки уже отправлены: ошибку посреди выгрузки остается только записать в лог
+	if err = stream.Write(c.Writer, format); err != nil {
+		logger.Log.Errorf("Messages export for discussion %d interrupted: %v", discussionID, err)
+	}
+}
+
+func GetDiscussionMarkdown(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	serveTranscript(c, repos, "markdown", func(discussionID int, locale string) ([]byte, error) {
+		return exports.BuildMarkdown(db, discussionID, locale)
+	})
+}
+
+func GetDiscussionHTML(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	serveTranscript(c, repos, "html", func(discussionID int, locale string) ([]byte, error) {
+		return exports.BuildHTML(db, discussionID, locale)
+	})
+}
+
+func GetDiscussionPDF(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	serveTranscript(c, repos, "pdf", func(discussionID int, locale string) ([]byte, error) {
+		return exports.BuildPDF(db, repos, discussionID, locale)
+	})
+}
+
+// serveTranscript отдает стенограмму на языке пользователя
+func serveTranscript(c *gin.Context, repos *storage.Repos, kind string, build func(discussionID int, locale string) ([]byte, error)) {
+	discussionID, ok := discussionVisible(c, repos)
+	if !ok {
+		return
+	}
+
+	data, err := build(discussionID, requestLocale(c, repos.Users))
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	exporter, _ := exports.Get(kind)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", exports.Filename(exporter, discussionID)))
+	c.Data(http.StatusOK, exporter.ContentType, data)
+}
+
+// GetArchiveGraph сеть взаимодействий, объединенная по дискуссиям архива.
+// Фильтры: ?from=&to= (даты YYYY-MM-DD включительно), ?tag=, ?organization=; формат — ?format=, по умолчанию json
+func GetArchiveGraph(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	organizationID, ok := organizationParam(c, repos.Users)
+	if !ok {
+		return
+	}
+
+	filter := exports.NetworkFilter{
+		Username:       requestUser(c),
+		OrganizationID: organizationID,
+		Tag:            c.Query("tag"),
+	}
+	if filter.From, filter.To, ok = dateRangeParams(c); !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	data, graphFormat, err := exports.BuildNetwork(db, repos, filter, format)
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=network_graph.%s", format))
+	c.Data(http.StatusOK, graphFormat.ContentType, data)
+}
+
+// dateRangeParams разбирает ?from=&to= (даты YYYY-MM-DD, конец включительно) в полуинтервал [from, to);
+// отсутствующая граница — нулевое время. При ошибке сама отвечает клиенту
+func dateRangeParams(c *gin.Context) (from, to time.Time, ok bool) {
+	for _, bound := range []struct {
+		param  string
+		target *time.Time
+		shift  time.Duration
+	}{
+		{"from", &from, 0},
+		{"to", &to, 24 * time.Hour}, // дата окончания входит в период
+	} {
+		value := c.Query(bound.param)
+		if value == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + bound.param + " date, expected YYYY-MM-DD"})
+			return from, to, false
+		}
+		*bound.target = date.Add(bound.shift)
+	}
+	return from, to, true
+}
+
+// GetDiscussionExports выгрузки, автоматически созданные по Room.ExportOptions
+func GetDiscussionExports(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	discussionID, ok := discussionVisible(c, repos)
+	if !ok {
+		return
+	}
+
+	artifacts, err := exports.Artifacts(db, discussionID)
+	if err != nil {
+		logger.Log.Errorln("Exports query error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exports": artifacts, "available": exports.Kinds()})
+}
+
+// GetDiscussionExport отдает сохраненную выгрузку; если ее еще нет — строит и сохраняет
+func GetDiscussionExport(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	discussionID, ok := discussionVisible(c, repos)
+	if !ok {
+		return
+	}
+
+	kind := c.Param("kind")
+	artifact, data, err := exports.Artifact(db, discussionID, kind)
+	if errors.Is(err, exports.ErrArtifactNotFound) {
+		if _, err = exports.Generate(db, repos, discussionID, kind); err == nil {
+			artifact, data, err = exports.Artifact(db, discussionID, kind)
+		}
+	}
+	if err != nil {
+		respondExportError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", artifact.Filename))
+	c.Data(http.StatusOK, artifact.ContentType, data)
+}
+
+func respondExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, exports.ErrDiscussionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Discussion not found"})
+	case errors.Is(err, exports.ErrUnknownKind):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown export kind"})
+	case errors.Is(err, exports.ErrUnknownUser), errors.Is(err, exports.ErrUnknownGraphFormat),
+		errors.Is(err, exports.ErrUnknownMessageFormat):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logger.Log.Errorln("Export error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate export"})
+	}
+}
+
+// discussionVisible разбирает :id и проверяет, что дискуссия существует и видна пользователю:
+// дискуссии организации доступны только ее участникам. При отказе сама отвечает клиенту
+func discussionVisible(c *gin.Context, repos *storage.Repos) (int, bool) {
+	discussionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discussion ID"})
+		return 0, false
+	}
+
+	discussion, err := repos.Discussions.Get(discussionID)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Discussion not found"})
+		return 0, false
+	}
+	if err != nil {
+		logger.Log.Errorln("Discussion query error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return 0, false
+	}
+	if discussion.OrganizationID != 0 {
+		member, err := repos.Users.IsMember(discussion.OrganizationID, requestUser(c))
+		if err != nil || !member {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+			return 0, false
+		}
+	}
+
+	return discussionID, true
+}
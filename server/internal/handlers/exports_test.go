@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"bytes"
+	"encoding/csv"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newExportStore дискуссия alice, bob и carol с реакциями и оценками и дискуссия организации 7
+func newExportStore(t *testing.T) (repos *storage.Repos, discussionID, privateID int) {
+	t.Helper()
+	store := newTestStore(t)
+	end := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	disliked := testMessage("b1", "bob", "carol")
+	disliked.DislikedBy = []string{"alice"}
+	discussionID = store.AddDiscussion(testDiscussion(end, "alice", "bob", "carol"), []structures.Message{
+		testMessage("a1", "alice", "bob", "carol"),
+		testMessage("a2", "alice"),
+		disliked,
+		{ID: "s1", Type: "system", Username: "alice"},
+	})
+
+	private := testDiscussion(end, "alice", "bob")
+	private.OrganizationID = 7
+	privateID = store.AddDiscussion(private, nil)
+	store.AddMember(7, "alice")
+
+	repos = store.Repos()
+	err := repos.Ratings.Save([]storage.Rating{
+		{DiscussionID: discussionID, RaterID: 2, RatedID: 1, Professionalism: 5, ArgumentsQuality: 4, Politeness: 3},
+		{DiscussionID: discussionID, RaterID: 3, RatedID: 1, Professionalism: 3, ArgumentsQuality: 2, Politeness: 1},
+		{DiscussionID: discussionID, RaterID: 1, RatedID: 2, Professionalism: 2, ArgumentsQuality: 2, Politeness: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repos, discussionID, privateID
+}
+
+func discussionParams(id int) gin.Params {
+	return gin.Params{{Key: "id", Value: strconv.Itoa(id)}}
+}
+
+func TestGetDiscussionCSV(t *testing.T) {
+	repos, discussionID, privateID := newExportStore(t)
+	csvExport := func(username string, id int) *httptest.ResponseRecorder {
+		return perform(t, http.MethodGet, "/discussion/csv?username="+username, nil, discussionParams(id), func(c *gin.Context) {
+			GetDiscussionCSVByID(c, repos)
+		})
+	}
+
+	recorder := csvExport("alice", discussionID)
+	expectStatus(t, recorder, http.StatusOK)
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/csv" {
+		t.Fatalf("content type = %q", contentType)
+	}
+	if disposition := recorder.Header().Get("Content-Disposition"); !strings.Contains(disposition, "discussion_"+strconv.Itoa(discussionID)+"_csv.csv") {
+		t.Fatalf("content disposition = %q", disposition)
+	}
+
+	records, err := csv.NewReader(recorder.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0][1] != "Username" {
+		t.Fatalf("unexpected records %q", records)
+	}
+	// UserID, Username, MessagesSent, LikesReceived, DislikesReceived, LikesGiven, DislikesGiven,
+	// средние полученные оценки, их число, средние выставленные, их число
+	for i, want := range [][]string{
+		{"1", "alice", "2", "2", "0", "0", "1", "4.00", "3.00", "2.00", "2", "2.00", "2.00", "2.00", "1"},
+		{"2", "bob", "1", "1", "1", "1", "0", "2.00", "2.00", "2.00", "1", "5.00", "4.00", "3.00", "1"},
+		{"3", "carol", "0", "0", "0", "2", "0", "0.00", "0.00", "0.00", "0", "3.00", "2.00", "1.00", "1"},
+	} {
+		if got := strings.Join(records[i+1], ","); got != strings.Join(want, ",") {
+			t.Errorf("row %d = %s, want %s", i+1, got, strings.Join(want, ","))
+		}
+	}
+
+	t.Run("unknown discussion", func(t *testing.T) {
+		expectStatus(t, csvExport("alice", 999), http.StatusNotFound)
+	})
+
+	t.Run("organization discussion", func(t *testing.T) {
+		expectStatus(t, csvExport("bob", privateID), http.StatusForbidden)
+		expectStatus(t, csvExport("alice", privateID), http.StatusOK)
+	})
+}
+
+func TestGetDiscussionGraph(t *testing.T) {
+	repos, discussionID, privateID := newExportStore(t)
+	graph := func(username string, id int, format string) *httptest.ResponseRecorder {
+		target := "/discussion/graph?username=" + username
+		if format != "" {
+			target += "&format=" + format
+		}
+		return perform(t, http.MethodGet, target, nil, discussionParams(id), func(c *gin.Context) {
+			GetDiscussionGraphByID(c, repos)
+		})
+	}
+
+	t.Run("png by default", func(t *testing.T) {
+		recorder := graph("alice", discussionID, "")
+		expectStatus(t, recorder, http.StatusOK)
+		if contentType := recorder.Header().Get("Content-Type"); contentType != "image/png" {
+			t.Fatalf("content type = %q", contentType)
+		}
+		if _, err := png.Decode(bytes.NewReader(recorder.Body.Bytes())); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("dot", func(t *testing.T) {
+		recorder := graph("alice", discussionID, "dot")
+		expectStatus(t, recorder, http.StatusOK)
+		dot := recorder.Body.String()
+		for _, edge := range []string{
+			`"bob" -> "alice" [label="Likes: 1"`,
+			`"carol" -> "alice" [label="Likes: 1"`,
+			`"carol" -> "bob" [label="Likes: 1"`,
+			`"alice" -> "bob" [label="Dislikes: 1"`,
+			`"bob" -> "alice" [label="Rating: 4.0 (1)"`,
+			`"carol" -> "alice" [label="Rating: 2.0 (1)"`,
+			`"alice" -> "bob" [label="Rating: 2.0 (1)"`,
+		} {
+			if !strings.Contains(dot, edge) {
+				t.Errorf("dot has no edge %s:\n%s", edge, dot)
+			}
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		expectStatus(t, graph("alice", discussionID, "bmp"), http.StatusBadRequest)
+	})
+
+	t.Run("organization discussion", func(t *testing.T) {
+		expectStatus(t, graph("carol", privateID, "dot"), http.StatusForbidden)
+	})
+}
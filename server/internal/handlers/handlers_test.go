@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	_ "awesomeChat/internal/modes/blitz"
+	_ "awesomeChat/internal/modes/free"
+	_ "awesomeChat/internal/modes/professional"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/storage/memory"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/tkn"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testPassword = "secret"
+
+// хеш testPassword считается один раз: bcrypt медленный
+var (
+	testHashOnce sync.Once
+	testHash     string
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestStore хранилище в памяти с пользователями alice, bob и carol
+func newTestStore(t *testing.T) *memory.Store {
+	t.Helper()
+	testHashOnce.Do(func() {
+		var err error
+		if testHash, err = tkn.HashPassword(testPassword); err != nil {
+			t.Fatal(err)
+		}
+	})
+	store := memory.New()
+	for _, username := range []string{"alice", "bob", "carol"} {
+		store.AddUser(storage.User{Username: username, Email: username + "@example.com", PasswordHash: testHash})
+	}
+	return store
+}
+
+// testDiscussion дискуссия personal/free между participants, закончившаяся end
+func testDiscussion(end time.Time, participants ...string) storage.Discussion {
+	return storage.Discussion{
+		DiscussionResponse: structures.DiscussionResponse{
+			RoomName:      "room",
+			Mode:          "personal",
+			SubType:       "free",
+			Duration:      "01:00:00",
+			StartTime:     end.Add(-time.Hour).Format(time.RFC3339Nano),
+			EndTime:       end.Format(time.RFC3339Nano),
+			Creator:       participants[0],
+			KeyQuestions:  []string{},
+			Tags:          []string{},
+			ExportOptions: []string{},
+			Participants:  participants,
+		},
+	}
+}
+
+func testMessage(id, username string, likedBy ...string) structures.Message {
+	return structures.Message{
+		ID:         id,
+		Type:       "usual",
+		Username:   username,
+		Content:    "message " + id,
+		Timestamp:  time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		LikedBy:    likedBy,
+		DislikedBy: []string{},
+	}
+}
+
+// perform вызывает handler с запросом method target; body кодируется в JSON. ?username= из target
+// кладется в контекст так же, как AuthMiddleware кладет владельца токена
+func perform(t *testing.T, method, target string, body any, params gin.Params, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(method, target, reader)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	if username := c.Query("username"); username != "" {
+		c.Set("username", username)
+	}
+	handler(c)
+	return recorder
+}
+
+func decode(t *testing.T, recorder *httptest.ResponseRecorder, target any) {
+	t.Helper()
+	if err := json.Unmarshal(recorder.Body.Bytes(), target); err != nil {
+		t.Fatalf("decode response %q: %v", recorder.Body.String(), err)
+	}
+}
+
+func expectStatus(t *testing.T, recorder *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if recorder.Code != status {
+		t.Fatalf("status = %d, want %d; body %s", recorder.Code, status, recorder.Body.String())
+	}
+}
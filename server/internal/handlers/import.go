@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"awesomeChat/internal/importer"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxImportSize предел загружаемой стенограммы: экспорт большого чата Telegram занимает десятки мегабайт
+const maxImportSize = 64 << 20
+
+// ImportDiscussion загружает стенограмму в архив. multipart-форма: file — файл, format — archive, telegram или log,
+// authors — JSON-объект «имя в источнике → пользователь», room_name, tags через запятую, public
+func ImportDiscussion(c *gin.Context, db *sql.DB) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transcript file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can not read transcript file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Can not read transcript file"})
+		return
+	}
+
+	options := importer.Options{
+		Source:  c.PostForm("format"),
+		Creator: requestUser(c),
+	}
+	if authors := c.PostForm("authors"); authors != "" {
+		if err = json.Unmarshal([]byte(authors), &options.Authors); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid authors mapping, expected JSON object"})
+			return
+		}
+	}
+	if public := c.PostForm("public"); public != "" {
+		if options.Public, err = strconv.ParseBool(public); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid public flag"})
+			return
+		}
+	}
+
+	transcript, err := importer.Parse(options.Source, data)
+	if err != nil {
+		respondImportError(c, err)
+		return
+	}
+	if name := strings.TrimSpace(c.PostForm("room_name")); name != "" {
+		transcript.RoomName = name
+	}
+	for _, tag := range strings.Split(c.PostForm("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			transcript.Tags = append(transcript.Tags, tag)
+		}
+	}
+
+	result, err := importer.Save(db, transcript, options)
+	if err != nil {
+		respondImportError(c, err)
+		return
+	}
+
+	logger.Log.Infof("Discussion %d imported from %s by %s: %d messages, %d placeholder users",
+		result.DiscussionID, options.Source, options.Creator, result.Messages, len(result.Placeholders))
+	c.JSON(http.StatusCreated, result)
+}
+
+func respondImportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, importer.ErrUnknownFormat):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "formats": importer.Formats()})
+	case errors.Is(err, importer.ErrInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		logger.Log.Errorln("Import error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import discussion"})
+	}
+}
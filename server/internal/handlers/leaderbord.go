@@ -1,18 +1,25 @@
 package handlers
 
 import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
 	"awesomeChat/internal/structures"
-	"database/sql"
-	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
 	"sort"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetLeaderboard(c *gin.Context, db *sql.DB) {
+func GetLeaderboard(c *gin.Context, repos *storage.Repos) {
+	locale := requestLocale(c, repos.Users)
+	organizationID, ok := organizationParam(c, repos.Users)
+	if !ok {
+		return
+	}
 	limit := 50
 	if l := c.Query("limit"); l != "" {
 		fmt.Sscanf(l, "%d", &limit)
@@ -21,68 +28,33 @@ func GetLeaderboard(c *gin.Context, db *sql.DB) {
 		}
 	}
 
-	type uinfo struct {
-		ID   int
-		Name string
-	}
-	userRows, err := db.Query(`SELECT user_id, username FROM users`)
+	// лидерборд организации — только ее участники и только ее дискуссии
+	users, err := repos.Users.List(organizationID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
 		return
 	}
-	defer userRows.Close()
-
-	var users []uinfo
-	for userRows.Next() {
-		var u uinfo
-		if err := userRows.Scan(&u.ID, &u.Name); err == nil {
-			users = append(users, u)
-		}
-	}
 
 	entries := make([]structures.LeaderboardEntry, 0, len(users))
+	filter := storage.ActivityFilter{Modes: modes.LeaderboardKeys(), Organization: &organizationID}
 
 	for _, u := range users {
-		participantsFilter, _ := json.Marshal([]string{u.Name})
-
-		var discussions, msgs, likes, hours int
-		err := db.QueryRow(`
-			SELECT
-				COUNT(DISTINCT d.id) AS discussions,
-
-				COALESCE(SUM(u.msgs), 0) AS messages,
-				COALESCE(SUM(u.likes), 0) AS likes,
-				COALESCE(
-					SUM(EXTRACT(EPOCH FROM d.duration)) / 3600, 0
-				)::int AS hours
-			FROM   discussions d
-			LEFT JOIN LATERAL (
-				SELECT
-					COUNT(*)                                    AS msgs,
-					SUM((e->>'likeCount')::int)                 AS likes
-				FROM   jsonb_array_elements(d.messages) AS e
-				WHERE  e->>'username' = $2
-			) u ON TRUE
-			WHERE  d.mode        != 'professional'
-			  AND  d.participants @> $1::jsonb`,
-			string(participantsFilter), u.Name).Scan(
-			&discussions, &msgs, &likes, &hours)
+		activity, err := repos.Discussions.Activity(u.Username, filter)
 		if err != nil {
 			continue
 		}
+		var msgs, likes int
+		var duration time.Duration
+		for _, item := range activity {
+			msgs += item.Messages
+			likes += item.Likes
+			duration += item.Duration
+		}
+		hours := int(math.Round(duration.Hours()))
 
-		var prof, arg, pol sql.NullFloat64
-		_ = db.QueryRow(`
-			SELECT
-				AVG(professionalism),
-				AVG(arguments_quality),
-				AVG(politeness)
-			FROM ratings r
-			JOIN discussions d ON d.id = r.discussion_id
-			WHERE d.mode != 'professional'
-			  AND r.rated_user_id = $1`, u.ID).
-			Scan(&prof, &arg, &pol)
-		avgRating := (prof.Float64 + arg.Float64 + pol.Float64) / 3
+		averages, _ := repos.Ratings.Averages(u.ID, filter)
+		prof, arg, pol := averages.Professionalism, averages.ArgumentsQuality, averages.Politeness
+		avgRating := (prof + arg + pol) / 3
 
 		base := math.Log1p(float64(msgs))         // 0.4
 		rating := avgRating * 2                   // вес 0.3*2 = 0.6
@@ -94,20 +66,21 @@ func GetLeaderboard(c *gin.Context, db *sql.DB) {
 		level, progress, _ := calculateLevel(structures.Statistics{
 			TotalMessages:    msgs,
 			TotalLikes:       likes,
-			Professionalism:  prof.Float64,
-			ArgumentsQuality: arg.Float64,
-			Politeness:       pol.Float64,
+			Professionalism:  prof,
+			ArgumentsQuality: arg,
+			Politeness:       pol,
 			TotalHours:       hours,
 		})
 
 		entries = append(entries, structures.LeaderboardEntry{
-			Username:      u.Name,
+			Username:      u.Username,
 			Score:         score,
 			TotalMessages: msgs,
 			AvgRating:     math.Round(avgRating*10) / 10,
 			TotalLikes:    likes,
 			TotalHours:    hours,
-			Level:         level,
+			Level:         i18n.T(locale, level, nil),
+			LevelKey:      level,
 			LevelProgress: progress,
 		})
 	}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/storage"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// requestUser пользователь запроса — владелец токена, которого AuthMiddleware положил в контекст
+func requestUser(c *gin.Context) string {
+	return c.GetString("username")
+}
+
+// requestLocale язык ответа: ?lang, затем сохраненный в профиле, затем Accept-Language
+func requestLocale(c *gin.Context, users storage.UserRepo) string {
+	preferred := c.Query("lang")
+	if !i18n.Supported(preferred) {
+		user, _ := users.ByUsername(requestUser(c))
+		preferred = user.Locale
+	}
+	return i18n.Negotiate(preferred, c.GetHeader("Accept-Language"))
+}
+
+func GetLocales(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"locales": i18n.Locales(), "default": i18n.Default})
+}
+
+// SetLocale сохраняет язык пользователя; пустая строка сбрасывает выбор к Accept-Language
+func SetLocale(c *gin.Context, db *sql.DB) {
+	var req struct {
+		Locale string `json:"locale"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if req.Locale != "" && !i18n.Supported(req.Locale) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported locale"})
+		return
+	}
+
+	res, err := db.Exec(`UPDATE users SET locale = NULLIF($1, '') WHERE username = $2`, req.Locale, requestUser(c))
+	if err != nil {
+		logger.Log.Errorln("Update locale error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Locale saved", "locale": i18n.Negotiate(req.Locale, c.GetHeader("Accept-Language"))})
+}
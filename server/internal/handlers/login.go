@@ -1,19 +1,18 @@
 package handlers
 
 import (
+	"awesomeChat/internal/storage"
 	"awesomeChat/internal/structures"
 	"awesomeChat/package/logger"
 	"awesomeChat/package/tkn"
-	"database/sql"
 	"errors"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"regexp"
 	"strings"
 )
 
-func Register(c *gin.Context, db *sql.DB) {
+func Register(c *gin.Context, users storage.UserRepo) {
 	var user structures.RegisterRequest
 
 	if err := c.ShouldBindJSON(&user); err != nil {
@@ -32,12 +31,12 @@ func Register(c *gin.Context, db *sql.DB) {
 		return
 	}
 
-	if taken, err := isCredentialTaken(db, "username", user.Username); err != nil || taken {
+	if taken, err := users.UsernameTaken(user.Username); err != nil || taken {
 		handleCredentialCheck(c, err, taken, "Username is already taken")
 		return
 	}
 
-	if taken, err := isCredentialTaken(db, "email", user.Email); err != nil || taken {
+	if taken, err := users.EmailTaken(user.Email); err != nil || taken {
 		handleCredentialCheck(c, err, taken, "Email is already taken")
 		return
 	}
@@ -49,12 +48,11 @@ func Register(c *gin.Context, db *sql.DB) {
 		return
 	}
 
-	if _, err := db.Exec(
-		"INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3)",
-		user.Username,
-		user.Email,
-		hashedPassword,
-	); err != nil {
+	if _, err := users.Create(storage.User{
+		Username:     user.Username,
+		Email:        user.Email,
+		PasswordHash: hashedPassword,
+	}); err != nil {
 		logger.Log.Errorln("Database insert error:", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Registration failed"})
 		return
@@ -63,15 +61,6 @@ func Register(c *gin.Context, db *sql.DB) {
 	c.JSON(http.StatusOK, gin.H{"message": "Registration successful"})
 }
 
-func isCredentialTaken(db *sql.DB, field string, value string) (bool, error) {
-	var count int
-	err := db.QueryRow(
-		fmt.Sprintf("SELECT COUNT(*) FROM users WHERE %s = $1", field),
-		value,
-	).Scan(&count)
-	return count > 0, err
-}
-
 func handleCredentialCheck(c *gin.Context, err error, taken bool, message string) {
 	if err != nil {
 		logger.Log.Errorln("Database query error:", err)
@@ -88,7 +77,7 @@ func isValidEmail(email string) bool {
 	return regexp.MustCompile(emailRegex).MatchString(strings.ToLower(email))
 }
 
-func Login(c *gin.Context, db *sql.DB) {
+func Login(c *gin.Context, users storage.UserRepo) {
 	var credentials structures.LoginRequest
 
 	if err := c.ShouldBindJSON(&credentials); err != nil {
@@ -97,19 +86,9 @@ func Login(c *gin.Context, db *sql.DB) {
 		return
 	}
 
-	var user struct {
-		ID           int
-		Username     string
-		PasswordHash string
-	}
-
-	err := db.QueryRow(
-		"SELECT user_id, username, password_hash FROM users WHERE username = $1 OR email = $1",
-		credentials.Username,
-	).Scan(&user.ID, &user.Username, &user.PasswordHash)
-
+	user, err := users.ByLogin(credentials.Username)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, storage.ErrNotFound) {
 			logger.Log.Traceln("sql Invalid credentials")
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		} else {
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/tkn"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name    string
+		request structures.RegisterRequest
+		status  int
+		error   string
+	}{
+		{"new user", structures.RegisterRequest{Username: "dave", Email: "dave@example.com", Password: "pw"}, http.StatusOK, ""},
+		{"taken username", structures.RegisterRequest{Username: "alice", Email: "new@example.com", Password: "pw"}, http.StatusBadRequest, "Username is already taken"},
+		{"taken email", structures.RegisterRequest{Username: "erin", Email: "bob@example.com", Password: "pw"}, http.StatusBadRequest, "Email is already taken"},
+		{"invalid email", structures.RegisterRequest{Username: "erin", Email: "erin", Password: "pw"}, http.StatusBadRequest, "Invalid email format"},
+		{"long username", structures.RegisterRequest{Username: "abcdefghijklmnopqrstu", Email: "long@example.com", Password: "pw"}, http.StatusBadRequest, "Username must be less than 20 symbols"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestStore(t).Repos()
+			recorder := perform(t, http.MethodPost, "/register", tt.request, nil, func(c *gin.Context) {
+				Register(c, repos.Users)
+			})
+			expectStatus(t, recorder, tt.status)
+
+			if tt.error != "" {
+				var body struct{ Error string }
+				decode(t, recorder, &body)
+				if body.Error != tt.error {
+					t.Fatalf("error = %q, want %q", body.Error, tt.error)
+				}
+				return
+			}
+
+			user, err := repos.Users.ByUsername(tt.request.Username)
+			if err != nil {
+				t.Fatalf("user was not created: %v", err)
+			}
+			if !tkn.CheckPasswordHash(tt.request.Password, user.PasswordHash) {
+				t.Fatal("stored password hash does not match")
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		login    string
+		password string
+		status   int
+	}{
+		{"by username", "bob", testPassword, http.StatusOK},
+		{"by email", "bob@example.com", testPassword, http.StatusOK},
+		{"wrong password", "bob", "wrong", http.StatusUnauthorized},
+		{"unknown user", "mallory", testPassword, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := newTestStore(t).Repos()
+			request := structures.LoginRequest{Username: tt.login, Password: tt.password}
+			recorder := perform(t, http.MethodPost, "/login", request, nil, func(c *gin.Context) {
+				Login(c, repos.Users)
+			})
+			expectStatus(t, recorder, tt.status)
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				UserID int `json:"user_id"`
+			}
+			decode(t, recorder, &body)
+			if body.UserID != 2 {
+				t.Fatalf("user_id = %d, want 2", body.UserID)
+			}
+			if cookie := recorder.Header().Get("Set-Cookie"); cookie == "" {
+				t.Fatal("auth cookie is not set")
+			}
+		})
+	}
+}
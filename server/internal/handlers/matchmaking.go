@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"awesomeChat/internal/catalog"
+	"awesomeChat/internal/matchmaking"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"awesomeChat/package/tkn"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// навык пользователя без истории оценок — середина шкалы
+const defaultSkill = 3.0
+
+func JoinMatchmaking(c *gin.Context, db *sql.DB, repos *storage.Repos, queue *matchmaking.Queue) {
+	username := requestUser(c)
+
+	var req struct {
+		Topic    int `json:"topic"`    // 0 — любой топик
+		Subtopic int `json:"subtopic"` // 0 — любой сабтопик выбранного топика
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if req.Subtopic != 0 {
+		topicID, ok := catalog.TopicOf(req.Subtopic)
+		if !ok || len(catalog.Theses(req.Subtopic)) < 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown subtopic"})
+			return
+		}
+		req.Topic = topicID
+	} else if req.Topic != 0 {
+		if !catalog.TopicExists(req.Topic) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown topic"})
+			return
+		}
+	}
+
+	skill, err := userSkill(db, username)
+	if err != nil {
+		logger.Log.Errorln("Skill query error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	err = queue.Join(matchmaking.Ticket{
+		Username:   username,
+		TopicID:    req.Topic,
+		SubtopicID: req.Subtopic,
+		Skill:      skill,
+		Locale:     requestLocale(c, repos.Users),
+	})
+	if errors.Is(err, matchmaking.ErrAlreadyQueued) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already in queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Queued",
+		"skill":   skill,
+		"timeout": int(matchmaking.Timeout.Seconds()),
+	})
+}
+
+func CancelMatchmaking(c *gin.Context, queue *matchmaking.Queue) {
+	username := requestUser(c)
+	if !queue.Cancel(username) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not in queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left queue"})
+}
+
+func GetMatchmakingStatus(c *gin.Context, queue *matchmaking.Queue) {
+	ticket, ok := queue.Status(requestUser(c))
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"queued": false})
+		return
+	}
+
+	waited := time.Since(ticket.JoinedAt)
+	c.JSON(http.StatusOK, gin.H{
+		"queued":   true,
+		"ticket":   ticket,
+		"waited":   int(waited.Seconds()),
+		"skillGap": matchmaking.SkillGap(waited),
+	})
+}
+
+// userSkill средняя полученная оценка пользователя по всем критериям
+func userSkill(db *sql.DB, username string) (float64, error) {
+	var skill sql.NullFloat64
+	err := db.QueryRow(`
+		SELECT AVG((r.professionalism + r.arguments_quality + r.politeness) / 3.0)
+		FROM ratings r
+		JOIN users u ON u.user_id = r.rated_user_id
+		WHERE u.username = $1`, username).Scan(&skill)
+	if err != nil {
+		return 0, err
+	}
+	if !skill.Valid {
+		return defaultSkill, nil
+	}
+	return skill.Float64, nil
+}
+
+// PickRandomSubtopic выбирает случайный сабтопик с тезисами; topicID == 0 — из всех топиков
+func PickRandomSubtopic(topicID int) (int, int, bool) {
+	candidates := catalog.PlayableSubtopics(topicID)
+	if len(candidates) == 0 {
+		return 0, 0, false
+	}
+
+	subtopicID := candidates[rand.Intn(len(candidates))]
+	topicID, ok := catalog.TopicOf(subtopicID)
+	return topicID, subtopicID, ok
+}
+
+// CreateMatchedRoom создает скрытую блиц-комнату с паролем для найденной пары
+func CreateMatchedRoom(rooms *structures.Rooms, match matchmaking.Match) (*structures.Room, error) {
+	req := structures.CreateRoomRequest{
+		Name:            fmt.Sprintf("%s vs %s", match.First.Username, match.Second.Username),
+		Mode:            "personal",
+		SubType:         "blitz",
+		MaxParticipants: 2,
+		Password:        tkn.RandString(12),
+		Hidden:          true,
+		Topic:           match.TopicID,
+		Subtopic:        match.SubtopicID,
+		CreatorName:     match.First.Username,
+	}
+
+	mode, ok := modes.Get(req.Mode, req.SubType)
+	if !ok {
+		return nil, errors.New("blitz mode is not registered")
+	}
+
+	room := newRoom(req)
+
+	if err := mode.Configure(room, req); err != nil {
+		return nil, err
+	}
+
+	if err := rooms.Add(room, maxRooms); err != nil {
+		return nil, err
+	}
+	logger.Log.Traceln("Created matched room №" + strconv.Itoa(room.ID))
+
+	return room, nil
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"awesomeChat/internal/organizations"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// приглашение действует неделю, после этого админ организации отправляет новое
+const inviteTTL = 7 * 24 * time.Hour
+
+func CreateOrganization(c *gin.Context, db *sql.DB) {
+	userID, err := userIDByName(db, requestUser(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var req structures.OrganizationRequest
+	if err = c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`INSERT INTO organizations (name, created_by) VALUES ($1, $2) RETURNING id`,
+		strings.TrimSpace(req.Name), userID).Scan(&id)
+	if err == nil {
+		_, err = tx.Exec(`INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)`,
+			id, userID, organizations.RoleAdmin)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		logger.Log.Errorln("Create organization error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Organization created", "id": id})
+}
+
+// GetOrganizations страница организаций, в которых состоит пользователь, по названию
+func GetOrganizations(c *gin.Context, db *sql.DB) {
+	request, ok := pageRequest(c, sortByName)
+	if !ok {
+		return
+	}
+
+	list := listQuery{
+		columns: `o.id, o.name, m.role, o.created_at`,
+		from: `
+		FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		JOIN users u ON u.user_id = m.user_id
+		WHERE u.username = $1`,
+		args: []interface{}{requestUser(c)},
+		id:   `o.id`,
+	}
+	page, err := queryPage(db, list, listSort{expr: `o.name`, typ: `text`, asc: true}, request,
+		func(rows *sql.Rows, key *string) (structures.Organization, error) {
+			var organization structures.Organization
+			var createdAt time.Time
+			err := rows.Scan(&organization.ID, &organization.Name, &organization.Role, &createdAt, key)
+			organization.CreatedAt = createdAt.Format(time.RFC3339)
+			return organization, err
+		},
+		func(organization structures.Organization) int { return organization.ID })
+	respondPage(c, page, request, err)
+}
+
+// GetOrganization организация со списком участников, доступна только ее участникам
+func GetOrganization(c *gin.Context, db *sql.DB) {
+	id, role, ok := organizationAccess(c, db, false)
+	if !ok {
+		return
+	}
+
+	organization := structures.Organization{ID: id, Role: role, Members: []structures.OrganizationMember{}}
+	var createdAt time.Time
+	if err := db.QueryRow(`SELECT name, created_at FROM organizations WHERE id = $1`, id).
+		Scan(&organization.Name, &createdAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	organization.CreatedAt = createdAt.Format(time.RFC3339)
+
+	rows, err := db.Query(`
+		SELECT u.username, m.role, m.joined_at
+		FROM organization_members m
+		JOIN users u ON u.user_id = m.user_id
+		WHERE m.organization_id = $1
+		ORDER BY m.role, u.username`, id)
+	if err != nil {
+		logger.Log.Errorln("Members query error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member structures.OrganizationMember
+		var joinedAt time.Time
+		if err = rows.Scan(&member.Username, &member.Role, &joinedAt); err != nil {
+			logger.Log.Errorln("Scan error:", err)
+			continue
+		}
+		member.JoinedAt = joinedAt.Format(time.RFC3339)
+		organization.Members = append(organization.Members, member)
+	}
+
+	c.JSON(http.StatusOK, organization)
+}
+
+// InviteMember приглашает человека по email; принять приглашение может пользователь с этим email
+func InviteMember(c *gin.Context, db *sql.DB) {
+	id, _, ok := organizationAccess(c, db, true)
+	if !ok {
+		return
+	}
+
+	var req structures.InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if !isValidEmail(req.Email) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
+		return
+	}
+	if req.Role == "" {
+		req.Role = organizations.RoleMember
+	}
+	if !organizations.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	inviterID, _ := userIDByName(db, requestUser(c))
+	token, err := inviteToken()
+	if err != nil {
+		logger.Log.Errorln("Invite token error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+		return
+	}
+
+	var inviteID int
+	err = db.QueryRow(`
+		INSERT INTO organization_invites (organization_id, email, role, token, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		id, req.Email, req.Role, token, inviterID, time.Now().Add(inviteTTL),
+	).Scan(&inviteID)
+	if err != nil {
+		logger.Log.Errorln("Insert invite error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// почтовой рассылки у сервера нет: приглашенный видит приглашение в GET /invites,
+	// а токен можно переслать ему любым удобным способом
+	logger.Log.Infof("Organization %d invited %s as %s", id, req.Email, req.Role)
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation created", "id": inviteID, "token": token})
+}
+
+// GetOrganizationInvites непринятые приглашения организации, для ее админов
+func GetOrganizationInvites(c *gin.Context, db *sql.DB) {
+	id, _, ok := organizationAccess(c, db, true)
+	if !ok {
+		return
+	}
+
+	listInvites(c, db, `i.organization_id = $1`, id)
+}
+
+// GetMyInvites приглашения, отправленные на email пользователя
+func GetMyInvites(c *gin.Context, db *sql.DB) {
+	listInvites(c, db, `LOWER(i.email) = (SELECT LOWER(email) FROM users WHERE username = $1)`, requestUser(c))
+}
+
+// listInvites страница непринятых приглашений, новые первыми
+func listInvites(c *gin.Context, db *sql.DB, where string, arg interface{}) {
+	request, ok := pageRequest(c, storage.SortNewest)
+	if !ok {
+		return
+	}
+
+	list := listQuery{
+		columns: `i.id, o.name, i.email, i.role, i.token, COALESCE(u.username, ''), i.expires_at`,
+		from: `
+		FROM organization_invites i
+		JOIN organizations o ON o.id = i.organization_id
+		LEFT JOIN users u ON u.user_id = i.invited_by
+		WHERE i.accepted_at IS NULL AND i.expires_at > NOW() AND ` + where,
+		args: []interface{}{arg},
+		id:   `i.id`,
+	}
+	page, err := queryPage(db, list, listSort{expr: `i.created_at`, typ: `timestamp`}, request,
+		func(rows *sql.Rows, key *string) (structures.OrganizationInvite, error) {
+			var invite structures.OrganizationInvite
+			var expiresAt time.Time
+			err := rows.Scan(&invite.ID, &invite.Organization, &invite.Email, &invite.Role, &invite.Token, &invite.InvitedBy, &expiresAt, key)
+			invite.ExpiresAt = expiresAt.Format(time.RFC3339)
+			return invite, err
+		},
+		func(invite structures.OrganizationInvite) int { return invite.ID })
+	respondPage(c, page, request, err)
+}
+
+// AcceptInvite добавляет пользователя в организацию, если приглашение выписано на его email
+func AcceptInvite(c *gin.Context, db *sql.DB) {
+	username := requestUser(c)
+
+	var userID int
+	var email string
+	err := db.QueryRow(`SELECT user_id, email FROM users WHERE username = $1`, username).Scan(&userID, &email)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	defer tx.Rollback()
+
+	var organizationID int
+	var role string
+	err = tx.QueryRow(`
+		UPDATE organization_invites SET accepted_at = NOW()
+		WHERE token = $1 AND LOWER(email) = LOWER($2) AND accepted_at IS NULL AND expires_at > NOW()
+		RETURNING organization_id, role`, c.Param("token"), email).Scan(&organizationID, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+		return
+	}
+	if err == nil {
+		_, err = tx.Exec(`
+			INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)
+			ON CONFLICT (organization_id, user_id) DO NOTHING`, organizationID, userID, role)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		logger.Log.Errorln("Accept invite error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Joined organization", "organization_id": organizationID})
+}
+
+func UpdateMemberRole(c *gin.Context, db *sql.DB) {
+	id, _, ok := organizationAccess(c, db, true)
+	if !ok {
+		return
+	}
+
+	var req structures.MemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || !organizations.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	if req.Role != organizations.RoleAdmin && isLastAdmin(db, id, c.Param("member")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Organization must keep at least one admin"})
+		return
+	}
+
+	res, err := db.Exec(`
+		UPDATE organization_members SET role = $1
+		WHERE organization_id = $2 AND user_id = (SELECT user_id FROM users WHERE username = $3)`,
+		req.Role, id, c.Param("member"))
+	respondMemberChange(c, res, err, "Role updated")
+}
+
+// RemoveMember исключает участника; любой участник может так же выйти из организации сам
+func RemoveMember(c *gin.Context, db *sql.DB) {
+	member := c.Param("member")
+	id, _, ok := organizationAccess(c, db, member != requestUser(c))
+	if !ok {
+		return
+	}
+
+	if isLastAdmin(db, id, member) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Organization must keep at least one admin"})
+		return
+	}
+
+	res, err := db.Exec(`
+		DELETE FROM organization_members
+		WHERE organization_id = $1 AND user_id = (SELECT user_id FROM users WHERE username = $2)`,
+		id, member)
+	respondMemberChange(c, res, err, "Member removed")
+}
+
+func respondMemberChange(c *gin.Context, res sql.Result, err error, message string) {
+	if err != nil {
+		logger.Log.Errorln("Member update error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// organizationAccess разбирает :id и проверяет, что пользователь — участник (или админ при adminOnly).
+// При отказе сама отвечает клиенту
+func organizationAccess(c *gin.Context, db *sql.DB, adminOnly bool) (int, string, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return 0, "", false
+	}
+
+	role, err := organizations.Role(db, id, requestUser(c))
+	if errors.Is(err, organizations.ErrNotMember) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+		return 0, "", false
+	}
+	if err != nil {
+		logger.Log.Errorln("Organization role error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return 0, "", false
+	}
+	if adminOnly && role != organizations.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Organization admin rights required"})
+		return 0, "", false
+	}
+
+	return id, role, true
+}
+
+// organizationParam необязательный ?organization=ID для разделов с областью видимости организации.
+// 0 — общий раздел; при отказе отвечает клиенту сама
+func organizationParam(c *gin.Context, users storage.UserRepo) (int, bool) {
+	param := c.Query("organization")
+	if param == "" {
+		return 0, true
+	}
+
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return 0, false
+	}
+	if member, err := users.IsMember(id, requestUser(c)); err != nil || !member {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+		return 0, false
+	}
+	return id, true
+}
+
+func isLastAdmin(db *sql.DB, organizationID int, username string) bool {
+	if !organizations.IsAdmin(db, organizationID, username) {
+		return false
+	}
+	var admins int
+	db.QueryRow(`SELECT COUNT(*) FROM organization_members WHERE organization_id = $1 AND role = $2`,
+		organizationID, organizations.RoleAdmin).Scan(&admins)
+	return admins <= 1
+}
+
+func inviteToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	outcomeColumns = `o.id, o.discussion_id, o.kind, o.content, COALESCE(o.message_id, ''), o.agenda_item,
+		o.author_username, COALESCE(u.username, ''), COALESCE(TO_CHAR(o.due_date, 'YYYY-MM-DD'), ''),
+		o.created_at, o.completed_at, d.room_name`
+	outcomeFrom = `
+	FROM discussion_outcomes o
+	JOIN discussions d ON d.id = o.discussion_id
+	LEFT JOIN users u ON u.user_id = o.assignee_user_id`
+	outcomeSelect = `SELECT ` + outcomeColumns + outcomeFrom
+)
+
+// sortByDue поручения по сроку, ближайшие первыми; без срока — в конце
+const sortByDue = "due"
+
+// GetDiscussionOutcomes решения и поручения дискуссии
+func GetDiscussionOutcomes(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	discussionID, ok := discussionVisible(c, repos)
+	if !ok {
+		return
+	}
+
+	items, err := queryOutcomes(db, outcomeSelect+` WHERE o.discussion_id = $1 ORDER BY o.created_at`, discussionID)
+	if err != nil {
+		logger.Log.Errorln("Outcomes query error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	response := structures.OutcomesResponse{
+		DiscussionID: discussionID,
+		Decisions:    []structures.Outcome{},
+		ActionItems:  []structures.Outcome{},
+	}
+	for _, item := range items {
+		if item.Kind == structures.OutcomeActionItem {
+			response.ActionItems = append(response.ActionItems, item.Outcome)
+		} else {
+			response.Decisions = append(response.Decisions, item.Outcome)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetMyActionItems страница поручений пользователя по сроку; по умолчанию открытые, ?status=all — все
+func GetMyActionItems(c *gin.Context, db *sql.DB) {
+	request, ok := pageRequest(c, sortByDue)
+	if !ok {
+		return
+	}
+
+	where := ` WHERE o.kind = 'action_item' AND u.username = $1`
+	if c.Query("status") != "all" {
+		where += ` AND o.completed_at IS NULL`
+	}
+
+	list := listQuery{columns: outcomeColumns, from: outcomeFrom + where, args: []interface{}{requestUser(c)}, id: `o.id`}
+	page, err := queryPage(db, list, listSort{expr: `COALESCE(o.due_date, 'infinity'::date)`, typ: `date`, asc: true}, request,
+		func(rows *sql.Rows, key *string) (structures.ActionItem, error) { return scanOutcome(rows, key) },
+		func(item structures.ActionItem) int { return item.ID })
+	respondPage(c, page, request, err)
+}
+
+// CompleteActionItem закрывает поручение; сделать это может исполнитель или автор
+func CompleteActionItem(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action item ID"})
+		return
+	}
+
+	username := requestUser(c)
+	res, err := db.Exec(`
+		UPDATE discussion_outcomes SET completed_at = NOW()
+		WHERE id = $1 AND kind = 'action_item' AND completed_at IS NULL
+		  AND (author_username = $2 OR assignee_user_id = (SELECT user_id FROM users WHERE username = $2))`,
+		id, username)
+	if err != nil {
+		logger.Log.Errorln("Complete action item error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Open action item not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Action item completed"})
+}
+
+func queryOutcomes(db *sql.DB, query string, args ...interface{}) ([]structures.ActionItem, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]structures.ActionItem, 0)
+	for rows.Next() {
+		item, err := scanOutcome(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// scanOutcome читает колонки outcomeColumns и следом extra
+func scanOutcome(rows *sql.Rows, extra ...interface{}) (structures.ActionItem, error) {
+	var item structures.ActionItem
+	var agendaItem sql.NullInt64
+	var completedAt sql.NullTime
+	var createdAt time.Time
+	err := rows.Scan(append([]interface{}{
+		&item.ID,
+		&item.DiscussionID,
+		&item.Kind,
+		&item.Content,
+		&item.MessageID,
+		&agendaItem,
+		&item.Author,
+		&item.Assignee,
+		&item.DueDate,
+		&createdAt,
+		&completedAt,
+		&item.RoomName,
+	}, extra...)...)
+	if err != nil {
+		return item, err
+	}
+	if agendaItem.Valid {
+		index := int(agendaItem.Int64)
+		item.AgendaItem = &index
+	}
+	if completedAt.Valid {
+		item.CompletedAt = &completedAt.Time
+	}
+	item.CreatedAt = createdAt
+	return item, nil
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// archiveSorts сортировки архива; первая — по умолчанию
+var archiveSorts = []string{
+	storage.SortNewest,
+	storage.SortLongest,
+	storage.SortMessages,
+	storage.SortRating,
+	storage.SortParticipants,
+}
+
+// sortByName сортировка коротких личных списков по названию, по возрастанию
+const sortByName = "name"
+
+// pageRequest разбирает общие для списков параметры: ?sort= из sorts (первая — по умолчанию),
+// ?limit= и ?cursor= из next_cursor предыдущего ответа либо, по-старому, ?page=.
+// При ошибке сама отвечает клиенту
+func pageRequest(c *gin.Context, sorts ...string) (storage.PageRequest, bool) {
+	request := storage.PageRequest{Sort: c.DefaultQuery("sort", sorts[0])}
+	if !containsString(sorts, request.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown sort", "sorts": sorts})
+		return request, false
+	}
+
+	request.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if request.Limit < 1 || request.Limit > 100 {
+		request.Limit = 20
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := storage.DecodeCursor(value)
+		// курсор другой сортировки указывал бы на случайное место списка
+		if err != nil || cursor.Sort != request.Sort {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return request, false
+		}
+		request.After = &cursor
+		return request, true
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	request.Offset = (page - 1) * request.Limit
+	return request, true
+}
+
+// pageNumber номер страницы для ответа; при листании курсором — 0
+func pageNumber(request storage.PageRequest) int {
+	if request.After != nil {
+		return 0
+	}
+	return request.Offset/request.Limit + 1
+}
+
+func encodeCursor(cursor *storage.Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	return cursor.Encode()
+}
+
+// listSort сортировка списка, который обработчик читает прямо из SQL: выражение ключа, его тип и
+// направление. Как и в архиве, при равенстве ключа порядок решает id, а в курсор ключ попадает текстом
+type listSort struct {
+	expr string
+	typ  string
+	asc  bool
+}
+
+// listQuery список из SQL: колонки элемента, FROM с WHERE и его параметры, выражение id элемента
+type listQuery struct {
+	columns string
+	from    string
+	args    []interface{}
+	id      string
+}
+
+// pageClause условие после курсора для WHERE и хвост запроса: порядок и LIMIT/OFFSET на одну строку
+// больше лимита. Параметры дописываются в args
+func pageClause(sort listSort, id string, request storage.PageRequest, args *[]interface{}) (after, tail string) {
+	direction, compare := "DESC", "<"
+	if sort.asc {
+		direction, compare = "ASC", ">"
+	}
+
+	offset := request.Offset
+	if request.After != nil {
+		*args = append(*args, request.After.Key, request.After.ID)
+		after = fmt.Sprintf(` AND (%s, %s) %s ($%d::%s, $%d)`, sort.expr, id, compare, len(*args)-1, sort.typ, len(*args))
+		offset = 0
+	}
+	*args = append(*args, request.Limit+1, offset)
+	tail = fmt.Sprintf(` ORDER BY %s %s, %s %s LIMIT $%d OFFSET $%d`, sort.expr, direction, id, direction, len(*args)-1, len(*args))
+	return after, tail
+}
+
+// queryPage страница списка list в порядке sort. scan читает колонки элемента и последней — ключ
+// сортировки в key; itemID — id элемента для курсора
+func queryPage[T any](db *sql.DB, list listQuery, sort listSort, request storage.PageRequest,
+	scan func(rows *sql.Rows, key *string) (T, error), itemID func(T) int) (storage.Page[T], error) {
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) `+list.from, list.args...).Scan(&total); err != nil {
+		return storage.Page[T]{}, err
+	}
+
+	args := append([]interface{}{}, list.args...)
+	after, tail := pageClause(sort, list.id, request, &args)
+	rows, err := db.Query(`SELECT `+list.columns+`, (`+sort.expr+`)::text `+list.from+after+tail, args...)
+	if err != nil {
+		return storage.Page[T]{}, storage.CursorError(err, request)
+	}
+	defer rows.Close()
+
+	var items []T
+	var keys []string
+	for rows.Next() {
+		var key string
+		item, err := scan(rows, &key)
+		if err != nil {
+			return storage.Page[T]{}, err
+		}
+		items = append(items, item)
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return storage.Page[T]{}, err
+	}
+	return storage.NewPage(items, keys, total, request, itemID), nil
+}
+
+// respondPage отвечает страницей списка в общем для списков виде или ошибкой ее чтения
+func respondPage[T any](c *gin.Context, page storage.Page[T], request storage.PageRequest, err error) {
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+	if err != nil {
+		logger.Log.Errorln("List query error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	c.JSON(http.StatusOK, structures.PageResponse[T]{
+		Data:       page.Items,
+		Total:      page.Total,
+		Page:       pageNumber(request),
+		Limit:      request.Limit,
+		Sort:       request.Sort,
+		NextCursor: encodeCursor(page.Next),
+	})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"awesomeChat/internal/storage"
+	"fmt"
+	"testing"
+)
+
+func TestPageClause(t *testing.T) {
+	name := listSort{expr: `t.name`, typ: `text`, asc: true}
+	newest := listSort{expr: `i.created_at`, typ: `timestamp`}
+
+	for _, test := range []struct {
+		label       string
+		sort        listSort
+		request     storage.PageRequest
+		after, tail string
+		args        string
+	}{
+		{
+			label:   "first page by name",
+			sort:    name,
+			request: storage.PageRequest{Sort: sortByName, Limit: 20},
+			tail:    ` ORDER BY t.name ASC, t.id ASC LIMIT $2 OFFSET $3`,
+			args:    `[7 21 0]`,
+		},
+		{
+			label:   "page number",
+			sort:    newest,
+			request: storage.PageRequest{Sort: storage.SortNewest, Limit: 10, Offset: 20},
+			tail:    ` ORDER BY i.created_at DESC, t.id DESC LIMIT $2 OFFSET $3`,
+			args:    `[7 11 20]`,
+		},
+		{
+			label: "cursor ignores offset",
+			sort:  name,
+			request: storage.PageRequest{Sort: sortByName, Limit: 20, Offset: 40,
+				After: &storage.Cursor{Sort: sortByName, Key: "Debates", ID: 5}},
+			after: ` AND (t.name, t.id) > ($2::text, $3)`,
+			tail:  ` ORDER BY t.name ASC, t.id ASC LIMIT $4 OFFSET $5`,
+			args:  `[7 Debates 5 21 0]`,
+		},
+		{
+			label: "descending cursor",
+			sort:  newest,
+			request: storage.PageRequest{Sort: storage.SortNewest, Limit: 20,
+				After: &storage.Cursor{Sort: storage.SortNewest, Key: "2024-05-01 10:00:00", ID: 5}},
+			after: ` AND (i.created_at, t.id) < ($2::timestamp, $3)`,
+			tail:  ` ORDER BY i.created_at DESC, t.id DESC LIMIT $4 OFFSET $5`,
+			args:  `[7 2024-05-01 10:00:00 5 21 0]`,
+		},
+	} {
+		t.Run(test.label, func(t *testing.T) {
+			args := []interface{}{7}
+			after, tail := pageClause(test.sort, `t.id`, test.request, &args)
+			if after != test.after {
+				t.Errorf("after = %q, want %q", after, test.after)
+			}
+			if tail != test.tail {
+				t.Errorf("tail = %q, want %q", tail, test.tail)
+			}
+			if got := fmt.Sprint(args); got != test.args {
+				t.Errorf("args = %s, want %s", got, test.args)
+			}
+		})
+	}
+}
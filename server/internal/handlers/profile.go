@@ -1,17 +1,19 @@
 package handlers
 
 import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
 	"awesomeChat/internal/structures"
-	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// calculateLevel возвращает ключи каталога i18n для текущего и следующего уровня
 func calculateLevel(stats structures.Statistics) (string, float64, string) {
 	base := math.Log1p(float64(stats.TotalMessages))
 	rating := (stats.Professionalism + stats.ArgumentsQuality + stats.Politeness) / 3
@@ -24,15 +26,15 @@ func calculateLevel(stats structures.Statistics) (string, float64, string) {
 		Name  string
 		Score float64
 	}{
-		{"🌱 Новичок", 2},
-		{"💬 Активный спикер", 4},
-		{"🎯 Качественный контрибьютор", 6},
-		{"🌟 Топ-участник", 8},
-		{"🏆 Мастер дискуссий", 10},
+		{"level_newbie", 2},
+		{"level_active_speaker", 4},
+		{"level_quality_contributor", 6},
+		{"level_top_participant", 8},
+		{"level_master", 10},
 	}
 
 	currentLevel := levels[0].Name
-	nextLevel := "Максимум"
+	nextLevel := "level_max"
 	progress := 0.0
 
 	for i, level := range levels {
@@ -52,17 +54,16 @@ func calculateLevel(stats structures.Statistics) (string, float64, string) {
 	return currentLevel, progress, nextLevel
 }
 
-func GetProfile(c *gin.Context, db *sql.DB) {
-	username := c.Query("username")
+func GetProfile(c *gin.Context, repos *storage.Repos) {
+	username := requestUser(c)
 	if username == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "требуется имя пользователя"})
 		return
 	}
 
-	var userID int
-	err := db.QueryRow("SELECT user_id FROM users WHERE username = $1", username).Scan(&userID)
+	user, err := repos.Users.ByUsername(username)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, storage.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "пользователь не найден"})
 			return
 		}
@@ -70,111 +71,57 @@ func GetProfile(c *gin.Context, db *sql.DB) {
 		return
 	}
 
-	participantsFilter, err := json.Marshal([]string{username})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "внутренняя ошибка"})
-		return
-	}
+	filter := storage.ActivityFilter{Modes: modes.LeaderboardKeys()}
 
-	rows, err := db.Query(`
-		SELECT id, messages, duration, participants 
-		FROM discussions 
-		WHERE mode != 'professional' 
-		AND participants @> $1::jsonb`,
-		string(participantsFilter))
+	activity, err := repos.Discussions.Activity(username, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка получения дискуссий"})
 		return
 	}
-	defer rows.Close()
 
-	totalDiscussions := 0
+	totalDiscussions := len(activity)
 	totalMessages := 0
 	totalLikes := 0
 	totalDuration := 0
 	uniquePartners := make(map[string]struct{})
 
-	for rows.Next() {
-		totalDiscussions++
-		var (
-			discussionID int
-			messagesJSON []byte
-			durationStr  string
-			participants []byte
-		)
-
-		if err := rows.Scan(&discussionID, &messagesJSON, &durationStr, &participants); err != nil {
-			continue
-		}
+	for _, item := range activity {
+		totalMessages += item.Messages
+		totalLikes += item.Likes
+		totalDuration += int(item.Duration.Minutes())
 
-		duration, _ := time.ParseDuration(durationStr)
-		totalDuration += int(duration.Minutes())
-
-		var parts []string
-		if err := json.Unmarshal(participants, &parts); err == nil {
-			for _, p := range parts {
-				if p != username {
-					uniquePartners[p] = struct{}{}
-				}
-			}
-		}
-
-		var messages []struct {
-			Username  string `json:"username"`
-			LikeCount int    `json:"likeCount"`
-		}
-		if err := json.Unmarshal(messagesJSON, &messages); err != nil {
-			continue
-		}
-
-		for _, msg := range messages {
-			if msg.Username == username {
-				totalMessages++
-				totalLikes += msg.LikeCount
+		for _, p := range item.Participants {
+			if p != username {
+				uniquePartners[p] = struct{}{}
 			}
 		}
 	}
 
-	var (
-		professionalism  float64
-		argumentsQuality float64
-		politeness       float64
-	)
-
-	err = db.QueryRow(`
-		SELECT 
-			COALESCE(AVG(professionalism), 0),
-			COALESCE(AVG(arguments_quality), 0),
-			COALESCE(AVG(politeness), 0)
-		FROM ratings r
-		JOIN discussions d ON r.discussion_id = d.id
-		WHERE d.mode != 'professional'
-		AND r.rated_user_id = $1`, userID).Scan(
-		&professionalism,
-		&argumentsQuality,
-		&politeness,
-	)
+	averages, err := repos.Ratings.Averages(user.ID, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "ошибка получения рейтингов"})
 		return
 	}
+	professionalism := averages.Professionalism
+	argumentsQuality := averages.ArgumentsQuality
+	politeness := averages.Politeness
 
 	achievements := []string{}
 	if professionalism >= 4.5 {
-		achievements = append(achievements, "🏅 Профи общения (проф. ≥4.5)")
+		achievements = append(achievements, "achievement_communication_pro")
 	}
 	if argumentsQuality >= 4.7 {
-		achievements = append(achievements, "💎 Мастер аргументов (качество ≥4.7)")
+		achievements = append(achievements, "achievement_argument_master")
 	}
 	if politeness >= 4.8 {
-		achievements = append(achievements, "🤝 Дипломат (вежливость ≥4.8)")
+		achievements = append(achievements, "achievement_diplomat")
 	}
 	totalHours := totalDuration / 60
 	if totalHours > 120 {
-		achievements = append(achievements, "⏳ Опытный дебатер (120+ часов)")
+		achievements = append(achievements, "achievement_experienced_debater")
 	}
 	if len(uniquePartners) >= 15 {
-		achievements = append(achievements, "👥 Душа компании (15+ собеседников)")
+		achievements = append(achievements, "achievement_life_of_party")
 	}
 
 	stats := structures.Statistics{
@@ -196,22 +143,32 @@ func GetProfile(c *gin.Context, db *sql.DB) {
 	engagementWeight := 0.2
 	experienceWeight := 0.1
 
+	locale := requestLocale(c, repos.Users)
+	percent := func(weight float64) string { return fmt.Sprintf("%.1f", weight*100) }
 	rankingFactors := []string{
-		fmt.Sprintf("Сообщения: %d (%.1f%%)", stats.TotalMessages, baseWeight*100),
-		fmt.Sprintf("Средний рейтинг: %.1f/5 (%.1f%%)", rating, ratingWeight*100),
-		fmt.Sprintf("Лайки: %d (%.1f%%)", stats.TotalLikes, engagementWeight*100),
-		fmt.Sprintf("Опыт: %dч (%.1f%%)", stats.TotalHours, experienceWeight*100),
+		i18n.T(locale, "ranking_messages", i18n.Params{"count": stats.TotalMessages, "weight": percent(baseWeight)}),
+		i18n.T(locale, "ranking_rating", i18n.Params{"rating": fmt.Sprintf("%.1f", rating), "weight": percent(ratingWeight)}),
+		i18n.T(locale, "ranking_likes", i18n.Params{"count": stats.TotalLikes, "weight": percent(engagementWeight)}),
+		i18n.T(locale, "ranking_experience", i18n.Params{"hours": stats.TotalHours, "weight": percent(experienceWeight)}),
+	}
+
+	achievementNames := make([]string, len(achievements))
+	for i, key := range achievements {
+		achievementNames[i] = i18n.T(locale, key, nil)
 	}
 
 	response := structures.ProfileResponse{
 		Username:   username,
 		Statistics: stats,
 		Gamification: structures.Gamification{
-			Level:          level,
-			LevelProgress:  math.Round(progress*100) / 100,
-			NextLevel:      nextLevel,
-			RankingFactors: rankingFactors,
-			Achievements:   achievements,
+			Level:           i18n.T(locale, level, nil),
+			LevelKey:        level,
+			LevelProgress:   math.Round(progress*100) / 100,
+			NextLevel:       i18n.T(locale, nextLevel, nil),
+			NextLevelKey:    nextLevel,
+			RankingFactors:  rankingFactors,
+			Achievements:    achievementNames,
+			AchievementKeys: achievements,
 		},
 	}
 
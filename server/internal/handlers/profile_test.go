@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newActivityStore alice и bob обсуждали дважды, alice и carol — один раз; профессиональный формат
+// и импортированная стенограмма с заглушкой ghost в статистику не входят
+func newActivityStore(t *testing.T) *storage.Repos {
+	t.Helper()
+	store := newTestStore(t)
+	store.AddUser(storage.User{Username: "ghost", Placeholder: true})
+	end := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	first := store.AddDiscussion(testDiscussion(end, "alice", "bob"), []structures.Message{
+		testMessage("a1", "alice", "bob"),
+		testMessage("a2", "alice"),
+		testMessage("b1", "bob", "alice"),
+		{ID: "s1", Type: "system", Username: "alice"},
+	})
+	store.AddDiscussion(testDiscussion(end.Add(time.Hour), "alice", "carol"), []structures.Message{
+		testMessage("a3", "alice", "carol"),
+	})
+	professional := testDiscussion(end.Add(2*time.Hour), "alice", "bob")
+	professional.Mode, professional.SubType = "professional", ""
+	excluded := store.AddDiscussion(professional, []structures.Message{
+		testMessage("a4", "alice", "bob"),
+	})
+
+	transcript := testDiscussion(end.Add(3*time.Hour), "alice", "ghost")
+	transcript.Imported = true
+	imported := store.AddDiscussion(transcript, []structures.Message{
+		testMessage("a5", "alice", "ghost"),
+		testMessage("g1", "ghost", "alice"),
+	})
+
+	repos := store.Repos()
+	err := repos.Ratings.Save([]storage.Rating{
+		{DiscussionID: first, RaterID: 2, RatedID: 1, Professionalism: 5, ArgumentsQuality: 4, Politeness: 3},
+		{DiscussionID: first, RaterID: 1, RatedID: 2, Professionalism: 2, ArgumentsQuality: 2, Politeness: 2},
+		{DiscussionID: excluded, RaterID: 2, RatedID: 1, Professionalism: 1, ArgumentsQuality: 1, Politeness: 1},
+		{DiscussionID: imported, RaterID: 4, RatedID: 1, Professionalism: 1, ArgumentsQuality: 1, Politeness: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repos
+}
+
+func TestGetProfile(t *testing.T) {
+	repos := newActivityStore(t)
+
+	recorder := perform(t, http.MethodGet, "/profile?username=alice", nil, nil, func(c *gin.Context) {
+		GetProfile(c, repos)
+	})
+	expectStatus(t, recorder, http.StatusOK)
+
+	var profile structures.ProfileResponse
+	decode(t, recorder, &profile)
+	stats := profile.Statistics
+	if stats.TotalDiscussions != 2 || stats.TotalMessages != 3 || stats.TotalLikes != 2 || stats.UniquePartners != 2 {
+		t.Fatalf("unexpected statistics %+v", stats)
+	}
+	if stats.Professionalism != 5 || stats.ArgumentsQuality != 4 || stats.Politeness != 3 {
+		t.Fatalf("unexpected ratings %+v", stats)
+	}
+	if profile.Gamification.LevelKey == "" || len(profile.Gamification.RankingFactors) != 4 {
+		t.Fatalf("unexpected gamification %+v", profile.Gamification)
+	}
+
+	t.Run("unknown user", func(t *testing.T) {
+		recorder := perform(t, http.MethodGet, "/profile?username=mallory", nil, nil, func(c *gin.Context) {
+			GetProfile(c, repos)
+		})
+		expectStatus(t, recorder, http.StatusNotFound)
+	})
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	repos := newActivityStore(t)
+
+	recorder := perform(t, http.MethodGet, "/leaderboard?username=alice&limit=2", nil, nil, func(c *gin.Context) {
+		GetLeaderboard(c, repos)
+	})
+	expectStatus(t, recorder, http.StatusOK)
+
+	var entries []structures.LeaderboardEntry
+	decode(t, recorder, &entries)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	alice := entries[0]
+	if alice.Username != "alice" || alice.Rank != 1 || alice.TotalMessages != 3 || alice.TotalLikes != 2 ||
+		alice.TotalHours != 2 || alice.AvgRating != 4 {
+		t.Fatalf("unexpected leader %+v", alice)
+	}
+	if entries[1].Username != "bob" || entries[1].Rank != 2 {
+		t.Fatalf("unexpected second place %+v", entries[1])
+	}
+
+	t.Run("no placeholders", func(t *testing.T) {
+		recorder := perform(t, http.MethodGet, "/leaderboard?username=alice", nil, nil, func(c *gin.Context) {
+			GetLeaderboard(c, repos)
+		})
+		expectStatus(t, recorder, http.StatusOK)
+
+		var entries []structures.LeaderboardEntry
+		decode(t, recorder, &entries)
+		if len(entries) != 3 {
+			t.Fatalf("got %d entries, want alice, bob and carol", len(entries))
+		}
+		for _, entry := range entries {
+			if entry.Username == "ghost" {
+				t.Fatalf("placeholder is on the leaderboard: %+v", entry)
+			}
+		}
+	})
+}
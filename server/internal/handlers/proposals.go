@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"awesomeChat/internal/catalog"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	proposalPending  = "pending"
+	proposalApproved = "approved"
+	proposalRejected = "rejected"
+
+	// с таким рейтингом одобренную тему можно продвинуть в каталог блица; продвигает модератор
+	promotionScore = 10
+)
+
+const proposalColumns = `p.id, u.username, p.topic_id, p.name, p.theses, p.status,
+	COALESCE(p.reject_reason, ''), COALESCE(p.subtopic_id, 0),
+	(SELECT COUNT(*) FROM topic_proposal_votes v WHERE v.proposal_id = p.id AND v.vote = 1),
+	(SELECT COUNT(*) FROM topic_proposal_votes v WHERE v.proposal_id = p.id AND v.vote = -1),
+	COALESCE(mv.vote, 0),
+	p.created_at`
+
+// proposalFrom голос пользователя ($1 — user_id) присоединяется здесь, а не в колонках, чтобы
+// подсчет страницы использовал тот же параметр
+const proposalFrom = `
+	FROM topic_proposals p
+	JOIN users u ON u.user_id = p.author_user_id
+	LEFT JOIN topic_proposal_votes mv ON mv.proposal_id = p.id AND mv.user_id = $1`
+
+// ?sort=top — по рейтингу
+const proposalSortTop = "top"
+
+var proposalSorts = map[string]listSort{
+	storage.SortNewest: {expr: `p.created_at`, typ: `timestamp`},
+	proposalSortTop:    {expr: `(SELECT COALESCE(SUM(v.vote), 0) FROM topic_proposal_votes v WHERE v.proposal_id = p.id)`, typ: `bigint`},
+}
+
+func ProposeSubtopic(c *gin.Context, db *sql.DB) {
+	userID, err := userIDByName(db, requestUser(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var req structures.ProposalRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subtopic name is required"})
+		return
+	}
+	theses := make([]string, 0, len(req.Theses))
+	for _, thesis := range req.Theses {
+		if thesis = strings.TrimSpace(thesis); thesis != "" {
+			theses = append(theses, thesis)
+		}
+	}
+	if len(theses) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least two opposing theses are required"})
+		return
+	}
+	if !catalog.TopicExists(req.TopicID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown topic"})
+		return
+	}
+
+	thesesJSON, _ := json.Marshal(theses)
+
+	var id int
+	err = db.QueryRow(`
+		INSERT INTO topic_proposals (author_user_id, topic_id, name, theses)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`,
+		userID, req.TopicID, req.Name, thesesJSON,
+	).Scan(&id)
+	if err != nil {
+		logger.Log.Errorln("Insert proposal error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Proposal submitted for moderation", "id": id})
+}
+
+// GetProposals страница предложений, ?sort= — newest или top. Обычным пользователям доступны
+// одобренные (для голосования) и уже попавшие в каталог, а также свои предложения через ?mine=true
+func GetProposals(c *gin.Context, db *sql.DB) {
+	username := requestUser(c)
+	userID, _ := userIDByName(db, username)
+
+	where := ` WHERE p.status = ANY(ARRAY['approved', 'promoted'])`
+	args := []interface{}{userID}
+	if c.Query("mine") == "true" {
+		where = ` WHERE p.author_user_id = $2`
+		args = append(args, userID)
+	}
+
+	listProposals(c, db, where, args)
+}
+
+// GetModerationQueue страница очереди модерации для админов, по умолчанию — ожидающие предложения
+func GetModerationQueue(c *gin.Context, db *sql.DB) {
+	userID, _ := userIDByName(db, requestUser(c))
+	status := c.DefaultQuery("status", proposalPending)
+
+	listProposals(c, db, ` WHERE p.status = $2`, []interface{}{userID, status})
+}
+
+func listProposals(c *gin.Context, db *sql.DB, where string, args []interface{}) {
+	request, ok := pageRequest(c, storage.SortNewest, proposalSortTop)
+	if !ok {
+		return
+	}
+
+	page, err := queryPage(db, listQuery{columns: proposalColumns, from: proposalFrom + where, args: args, id: `p.id`},
+		proposalSorts[request.Sort], request,
+		func(rows *sql.Rows, key *string) (structures.Proposal, error) { return scanProposal(rows, key) },
+		func(proposal structures.Proposal) int { return proposal.ID })
+	respondPage(c, page, request, err)
+}
+
+// scanProposal читает колонки proposalColumns и следом extra
+func scanProposal(row interface{ Scan(...any) error }, extra ...interface{}) (structures.Proposal, error) {
+	var proposal structures.Proposal
+	var thesesJSON []byte
+	var createdAt time.Time
+
+	err := row.Scan(append([]interface{}{
+		&proposal.ID,
+		&proposal.Author,
+		&proposal.TopicID,
+		&proposal.Name,
+		&thesesJSON,
+		&proposal.Status,
+		&proposal.RejectReason,
+		&proposal.SubtopicID,
+		&proposal.Upvotes,
+		&proposal.Downvotes,
+		&proposal.MyVote,
+		&createdAt,
+	}, extra...)...)
+	if err != nil {
+		return proposal, err
+	}
+	if err = json.Unmarshal(thesesJSON, &proposal.Theses); err != nil {
+		return proposal, err
+	}
+
+	proposal.Topic = catalog.TopicName(proposal.TopicID)
+	proposal.Score = proposal.Upvotes - proposal.Downvotes
+	proposal.Promotable = proposal.Status == proposalApproved && proposal.Score >= promotionScore
+	proposal.CreatedAt = createdAt.Format(time.RFC3339)
+	return proposal, nil
+}
+
+func ApproveProposal(c *gin.Context, db *sql.DB) {
+	moderateProposal(c, db, proposalApproved, "")
+}
+
+func RejectProposal(c *gin.Context, db *sql.DB) {
+	var req structures.RejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reject reason is required"})
+		return
+	}
+
+	moderateProposal(c, db, proposalRejected, req.Reason)
+}
+
+func moderateProposal(c *gin.Context, db *sql.DB, status string, reason string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	moderatorID, _ := userIDByName(db, requestUser(c))
+
+	res, err := db.Exec(`
+		UPDATE topic_proposals
+		SET status = $1, reject_reason = NULLIF($2, ''), moderator_user_id = $3, moderated_at = NOW()
+		WHERE id = $4 AND status = 'pending'`,
+		status, reason, moderatorID, id)
+	if err != nil {
+		logger.Log.Errorln("Moderate proposal error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pending proposal not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Proposal " + status})
+}
+
+func VoteProposal(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	userID, err := userIDByName(db, requestUser(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var req structures.ProposalVoteRequest
+	if err = c.ShouldBindJSON(&req); err != nil || req.Vote < -1 || req.Vote > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid vote"})
+		return
+	}
+
+	var status string
+	var authorID int
+	err = db.QueryRow(`SELECT status, author_user_id FROM topic_proposals WHERE id = $1`, id).Scan(&status, &authorID)
+	if err != nil || status != proposalApproved {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proposal is not open for voting"})
+		return
+	}
+	if authorID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot vote for own proposal"})
+		return
+	}
+
+	if req.Vote == 0 {
+		_, err = db.Exec(`DELETE FROM topic_proposal_votes WHERE proposal_id = $1 AND user_id = $2`, id, userID)
+	} else {
+		_, err = db.Exec(`
+			INSERT INTO topic_proposal_votes (proposal_id, user_id, vote) VALUES ($1, $2, $3)
+			ON CONFLICT (proposal_id, user_id) DO UPDATE SET vote = EXCLUDED.vote, created_at = NOW()`,
+			id, userID, req.Vote)
+	}
+	if err != nil {
+		logger.Log.Errorln("Vote proposal error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	var score int
+	if err = db.QueryRow(`SELECT COALESCE(SUM(vote), 0) FROM topic_proposal_votes WHERE proposal_id = $1`, id).Scan(&score); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	// голоса сами в каталог не продвигают: их легко накрутить, поэтому решение за модератором
+	c.JSON(http.StatusOK, gin.H{"message": "Vote saved", "score": score, "promotable": score >= promotionScore})
+}
+
+// PromoteProposal переносит в каталог одобренное предложение, набравшее promotionScore
+func PromoteProposal(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proposal ID"})
+		return
+	}
+
+	subtopicID, err := promoteProposal(db, id)
+	if err != nil {
+		if errors.Is(err, catalog.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No approved proposal with enough votes"})
+			return
+		}
+		logger.Log.Errorln("Promote proposal error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Proposal promoted", "subtopic_id": subtopicID})
+}
+
+// promoteProposal создает сабтопик из одобренного предложения с рейтингом не ниже promotionScore;
+// после этого блиц может его выбирать
+func promoteProposal(db *sql.DB, id int) (int, error) {
+	var topicID int
+	var name string
+	var thesesJSON []byte
+	err := db.QueryRow(`
+		UPDATE topic_proposals p SET status = 'promoted'
+		WHERE p.id = $1 AND p.status = 'approved'
+			AND (SELECT COALESCE(SUM(v.vote), 0) FROM topic_proposal_votes v WHERE v.proposal_id = p.id) >= $2
+		RETURNING p.topic_id, p.name, p.theses`, id, promotionScore).Scan(&topicID, &name, &thesesJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, catalog.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var theses []string
+	if err = json.Unmarshal(thesesJSON, &theses); err != nil {
+		return 0, err
+	}
+
+	subtopicID, err := catalog.CreateSubtopic(db, topicID, name, theses)
+	if err != nil {
+		// возвращаем предложение в одобренные, чтобы его можно было продвинуть повторно
+		db.Exec(`UPDATE topic_proposals SET status = 'approved' WHERE id = $1`, id)
+		return 0, err
+	}
+
+	_, err = db.Exec(`UPDATE topic_proposals SET subtopic_id = $1 WHERE id = $2`, subtopicID, id)
+	return subtopicID, err
+}
+
+func userIDByName(db *sql.DB, username string) (int, error) {
+	var userID int
+	err := db.QueryRow("SELECT user_id FROM users WHERE username = $1", username).Scan(&userID)
+	return userID, err
+}
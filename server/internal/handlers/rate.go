@@ -1,20 +1,19 @@
 package handlers
 
 import (
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
 	"awesomeChat/internal/structures"
 	"awesomeChat/package/logger"
-	"database/sql"
 	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 	"net/http"
 )
 
-func RateOpponent(c *gin.Context, db *sql.DB) {
-	username := c.Query("username")
+func RateOpponent(c *gin.Context, repos *storage.Repos) {
+	username := requestUser(c)
 
-	var raterUserID int
-	err := db.QueryRow("SELECT user_id FROM users WHERE username = $1", username).Scan(&raterUserID)
+	rater, err := repos.Users.ByUsername(username)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
@@ -28,39 +27,35 @@ func RateOpponent(c *gin.Context, db *sql.DB) {
 
 	logger.Log.Traceln("Req DiscussionID:", req.DiscussionID)
 
-	var exists bool
-	err = db.QueryRow(
-		"SELECT EXISTS(SELECT 1 FROM discussions WHERE id = $1 AND participants @> jsonb_build_array($2::text))",
-		req.DiscussionID,
-		username,
-	).Scan(&exists)
-	if err != nil || !exists {
+	discussion, err := repos.Discussions.Get(req.DiscussionID)
+	if err != nil || !containsString(discussion.Participants, username) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Discussion not found or user is not a participant"})
 		return
 	}
 
+	// в командном блице к оценке сохраняются команды оценивающего и оцениваемого
+	userTeams := make(map[string]int)
+	for _, team := range discussion.Teams {
+		for _, member := range team.Members {
+			userTeams[member] = team.Team
+		}
+	}
+
+	criteriaList := modes.DefaultCriteria
+	if m, ok := modes.Get(discussion.Mode, discussion.SubType); ok {
+		criteriaList = m.RatingCriteria()
+	}
+
 	ratedUsernames := make([]string, 0, len(req.Ratings))
 	for usrnm := range req.Ratings {
 		ratedUsernames = append(ratedUsernames, usrnm)
 	}
 
-	rows, err := db.Query("SELECT user_id, username FROM users WHERE username = ANY($1)", pq.Array(ratedUsernames))
+	userIDMap, err := repos.Users.IDs(ratedUsernames)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
 		return
 	}
-	defer rows.Close()
-
-	userIDMap := make(map[string]int)
-	for rows.Next() {
-		var userID int
-		var usrnm string
-		if err = rows.Scan(&userID, &usrnm); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			return
-		}
-		userIDMap[usrnm] = userID
-	}
 
 	for usrnm := range req.Ratings {
 		if _, ok := userIDMap[usrnm]; !ok {
@@ -69,24 +64,17 @@ func RateOpponent(c *gin.Context, db *sql.DB) {
 		}
 	}
 
-	tx, err := db.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-		return
-	}
-	defer tx.Rollback()
-
+	ratings := make([]storage.Rating, 0, len(req.Ratings))
 	for ratedUsername, criteria := range req.Ratings {
 		ratedUserID := userIDMap[ratedUsername]
-		if ratedUserID == raterUserID {
+		if ratedUserID == rater.ID {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot rate yourself"})
 			return
 		}
 
-		requiredCriteria := map[string]bool{
-			"professionalism":   true,
-			"arguments_quality": true,
-			"politeness":        true,
+		requiredCriteria := make(map[string]bool)
+		for _, crit := range criteriaList {
+			requiredCriteria[crit] = true
 		}
 		for crit := range criteria {
 			delete(requiredCriteria, crit)
@@ -96,25 +84,40 @@ func RateOpponent(c *gin.Context, db *sql.DB) {
 			return
 		}
 
-		_, err = tx.Exec(`
-			INSERT INTO ratings (
-				discussion_id, rater_user_id, rated_user_id, 
-				professionalism, arguments_quality, politeness
-			) VALUES ($1, $2, $3, $4, $5, $6)
-			ON CONFLICT (discussion_id, rater_user_id, rated_user_id) DO NOTHING`,
-			req.DiscussionID, raterUserID, ratedUserID,
-			criteria["professionalism"], criteria["arguments_quality"], criteria["politeness"],
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rating"})
-			return
-		}
+		ratings = append(ratings, storage.Rating{
+			DiscussionID:     req.DiscussionID,
+			RaterID:          rater.ID,
+			RatedID:          ratedUserID,
+			Professionalism:  criteria["professionalism"],
+			ArgumentsQuality: criteria["arguments_quality"],
+			Politeness:       criteria["politeness"],
+			RaterTeam:        teamOf(userTeams, username),
+			RatedTeam:        teamOf(userTeams, ratedUsername),
+		})
 	}
 
-	if err = tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+	if err = repos.Ratings.Save(ratings); err != nil {
+		logger.Log.Errorln("Save ratings error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rating"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Ratings submitted successfully"})
 }
+
+func teamOf(userTeams map[string]int, username string) *int {
+	team, ok := userTeams[username]
+	if !ok {
+		return nil
+	}
+	return &team
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
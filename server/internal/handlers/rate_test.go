@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"awesomeChat/internal/structures"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateOpponent(t *testing.T) {
+	criteria := map[string]int{"professionalism": 5, "arguments_quality": 4, "politeness": 3}
+
+	tests := []struct {
+		name     string
+		username string
+		ratings  map[string]map[string]int
+		status   int
+		saved    int
+	}{
+		{"rates opponent", "alice", map[string]map[string]int{"bob": criteria}, http.StatusOK, 1},
+		{"cannot rate yourself", "alice", map[string]map[string]int{"alice": criteria}, http.StatusBadRequest, 0},
+		{"missing criteria", "alice", map[string]map[string]int{"bob": {"politeness": 5}}, http.StatusBadRequest, 0},
+		{"unknown user", "alice", map[string]map[string]int{"mallory": criteria}, http.StatusNotFound, 0},
+		{"not a participant", "carol", map[string]map[string]int{"bob": criteria}, http.StatusForbidden, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			discussion := testDiscussion(time.Now(), "alice", "bob")
+			discussion.SubType = "blitz"
+			discussion.Teams = []structures.TeamInfo{{Team: 0, Members: []string{"alice"}}, {Team: 1, Members: []string{"bob"}}}
+			id := store.AddDiscussion(discussion, nil)
+			repos := store.Repos()
+
+			request := structures.RatingRequest{DiscussionID: id, Ratings: tt.ratings}
+			recorder := perform(t, http.MethodPost, "/rate/final?username="+tt.username, request, nil, func(c *gin.Context) {
+				RateOpponent(c, repos)
+			})
+			expectStatus(t, recorder, tt.status)
+
+			saved, err := repos.Ratings.ForDiscussion(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(saved) != tt.saved {
+				t.Fatalf("saved %d ratings, want %d", len(saved), tt.saved)
+			}
+			if tt.saved == 0 {
+				return
+			}
+			rating := saved[0]
+			if rating.RaterID != 1 || rating.RatedID != 2 || rating.Professionalism != 5 || rating.Politeness != 3 {
+				t.Fatalf("unexpected rating %+v", rating)
+			}
+			if rating.RaterTeam == nil || *rating.RaterTeam != 0 || rating.RatedTeam == nil || *rating.RatedTeam != 1 {
+				t.Fatalf("teams are not saved: %+v", rating)
+			}
+		})
+	}
+}
+
+func TestRateOpponentTwice(t *testing.T) {
+	store := newTestStore(t)
+	id := store.AddDiscussion(testDiscussion(time.Now(), "alice", "bob"), nil)
+	repos := store.Repos()
+
+	for _, professionalism := range []int{5, 1} {
+		request := structures.RatingRequest{DiscussionID: id, Ratings: map[string]map[string]int{
+			"bob": {"professionalism": professionalism, "arguments_quality": 4, "politeness": 4},
+		}}
+		recorder := perform(t, http.MethodPost, "/rate/final?username=alice", request, nil, func(c *gin.Context) {
+			RateOpponent(c, repos)
+		})
+		expectStatus(t, recorder, http.StatusOK)
+	}
+
+	saved, _ := repos.Ratings.ForDiscussion(id)
+	if len(saved) != 1 || saved[0].Professionalism != 5 || saved[0].RaterTeam != nil {
+		t.Fatalf("repeated rating must be ignored, got %+v", saved)
+	}
+}
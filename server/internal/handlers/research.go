@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"awesomeChat/internal/exports"
+	"awesomeChat/internal/storage"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetResearchDataset набор данных по публичным дискуссиям для исследователей.
+// Фильтры: ?from=&to= (YYYY-MM-DD), ?mode=, ?topic_id= и ?tag= (повторяются или через запятую),
+// ?min_participants=. ?format=zip|ndjson, по умолчанию zip.
+// Обезличивание включено по умолчанию и отключается ?pseudonymize=false и ?strip_pii=false
+func GetResearchDataset(c *gin.Context, db *sql.DB, repos *storage.Repos) {
+	var options exports.DatasetOptions
+	var ok bool
+	if options.Filter.From, options.Filter.To, ok = dateRangeParams(c); !ok {
+		return
+	}
+	options.Filter.Mode = c.Query("mode")
+	options.Filter.Tags = listParam(c, "tag")
+	for _, value := range listParam(c, "topic_id") {
+		topicID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid topic_id"})
+			return
+		}
+		options.Filter.TopicIDs = append(options.Filter.TopicIDs, topicID)
+	}
+	if value := c.Query("min_participants"); value != "" {
+		minParticipants, err := strconv.Atoi(value)
+		if err != nil || minParticipants < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_participants"})
+			return
+		}
+		options.Filter.MinParticipants = minParticipants
+	}
+	for _, flag := range []struct {
+		param  string
+		target *bool
+	}{
+		{"pseudonymize", &options.Pseudonymize},
+		{"strip_pii", &options.StripPII},
+	} {
+		value, err := strconv.ParseBool(c.DefaultQuery(flag.param, "true"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + flag.param + ", expected true or false"})
+			return
+		}
+		*flag.target = value
+	}
+
+	format := c.DefaultQuery("format", "zip")
+	contentType, ok := exports.DatasetFormats[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown dataset format"})
+		return
+	}
+
+	dataset, err := exports.NewDataset(db, repos, options)
+	if err != nil {
+		logger.Log.Errorln("Dataset query error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=dataset_%s.%s", time.Now().Format("20060102_150405"), format))
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+	// заголовки уже отправлены: ошибку посреди выгрузки остается только записать в лог
+	if err = dataset.Write(c.Writer, format); err != nil {
+		logger.Log.Errorln("Dataset export interrupted:", err)
+	}
+}
+
+// listParam значения параметра, переданного несколько раз или через запятую
+func listParam(c *gin.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryArray(name) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
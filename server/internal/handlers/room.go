@@ -1,19 +1,27 @@
 package handlers
 
 import (
+	"awesomeChat/internal/organizations"
 	"awesomeChat/internal/structures"
+	"database/sql"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
 	"time"
 )
 
-func GetRoomDetails(c *gin.Context, rooms *map[int]*structures.Room) {
+func GetRoomDetails(c *gin.Context, db *sql.DB, rooms *structures.Rooms) {
 	chatNumber, _ := strconv.Atoi(c.Param("id"))
 
-	room, ok := (*rooms)[chatNumber]
+	room, ok := rooms.Get(chatNumber)
 	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discussion ID"})
+		return
+	}
+
+	if room.OrganizationID != 0 && !organizations.IsMember(db, room.OrganizationID, requestUser(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+		return
 	}
 
 	roomToSend := structures.RoomForList{
@@ -21,6 +29,7 @@ func GetRoomDetails(c *gin.Context, rooms *map[int]*structures.Room) {
 		Name:             room.Name,
 		Open:             room.Open,
 		Users:            len(room.Users),
+		Spectators:       len(room.Spectators),
 		MaxUsers:         room.MaxUsers,
 		Mode:             room.Mode,
 		SubType:          room.SubType,
@@ -37,6 +46,10 @@ func GetRoomDetails(c *gin.Context, rooms *map[int]*structures.Room) {
 		DiscussionActive: room.DiscussionActive,
 		Duration:         int(room.Duration.Minutes()),
 		StartTime:        room.StartTime.Format(time.RFC3339),
+		Phases:           room.Phases,
+		OrganizationID:   room.OrganizationID,
+		AgendaIndex:      room.AgendaIndex,
+		AgendaTimeBoxes:  room.AgendaTimeBoxes,
 	}
 
 	c.JSON(http.StatusOK, roomToSend)
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"awesomeChat/internal/organizations"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrTemplateNotFound шаблона нет или он недоступен пользователю
+var ErrTemplateNotFound = errors.New("template not found")
+
+const (
+	templateColumns = `t.id, u.username, t.name, COALESCE(t.organization_id, 0), t.settings, t.created_at, t.updated_at`
+	templateFrom    = `
+	FROM room_templates t
+	JOIN users u ON u.user_id = t.owner_user_id`
+	templateSelect = `SELECT ` + templateColumns + templateFrom
+)
+
+// templateAccess шаблон доступен владельцу и участникам организации, с которой он расшарен ($1 — user_id)
+const templateAccess = `(t.owner_user_id = $1 OR t.organization_id IN (
+	SELECT organization_id FROM organization_members WHERE user_id = $1))`
+
+// templateEditable менять шаблон могут владелец и админы его организации ($N — user_id)
+const templateEditable = `(owner_user_id = $%[1]d OR organization_id IN (
+	SELECT organization_id FROM organization_members WHERE user_id = $%[1]d AND role = 'admin'))`
+
+// GetTemplates страница доступных пользователю шаблонов по названию
+func GetTemplates(c *gin.Context, db *sql.DB) {
+	request, ok := pageRequest(c, sortByName)
+	if !ok {
+		return
+	}
+
+	userID, err := userIDByName(db, requestUser(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	list := listQuery{columns: templateColumns, from: templateFrom + ` WHERE ` + templateAccess, args: []interface{}{userID}, id: `t.id`}
+	page, err := queryPage(db, list, listSort{expr: `t.name`, typ: `text`, asc: true}, request,
+		func(rows *sql.Rows, key *string) (structures.RoomTemplate, error) { return scanTemplate(rows, key) },
+		func(template structures.RoomTemplate) int { return template.ID })
+	respondPage(c, page, request, err)
+}
+
+func GetTemplate(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	template, err := loadTemplate(db, id, requestUser(c))
+	if err != nil {
+		respondTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func CreateTemplate(c *gin.Context, db *sql.DB) {
+	userID, err := userIDByName(db, requestUser(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var req structures.TemplateRequest
+	if err = c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if req.OrganizationID != 0 && !organizations.IsMember(db, req.OrganizationID, requestUser(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+		return
+	}
+
+	settings := templateSettings(req.Settings)
+
+	var id int
+	err = db.QueryRow(`
+		INSERT INTO room_templates (owner_user_id, name, organization_id, settings)
+		VALUES ($1, $2, NULLIF($3, 0), $4)
+		RETURNING id`,
+		userID, strings.TrimSpace(req.Name), req.OrganizationID, settings,
+	).Scan(&id)
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Template with this name already exists"})
+		return
+	}
+	if err != nil {
+		logger.Log.Errorln("Insert template error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template created", "id": id})
+}
+
+func UpdateTemplate(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	userID, err := userIDByName(db, requestUser(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var req structures.TemplateRequest
+	if err = c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if req.OrganizationID != 0 && !organizations.IsMember(db, req.OrganizationID, requestUser(c)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not a member of the organization"})
+		return
+	}
+
+	res, err := db.Exec(`
+		UPDATE room_templates SET name = $1, organization_id = NULLIF($2, 0), settings = $3, updated_at = NOW()
+		WHERE id = $4 AND `+fmt.Sprintf(templateEditable, 5),
+		strings.TrimSpace(req.Name), req.OrganizationID, templateSettings(req.Settings), id, userID)
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Template with this name already exists"})
+		return
+	}
+	if err != nil {
+		logger.Log.Errorln("Update template error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template updated"})
+}
+
+func DeleteTemplate(c *gin.Context, db *sql.DB) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	userID, err := userIDByName(db, requestUser(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	res, err := db.Exec(`DELETE FROM room_templates WHERE id = $1 AND `+fmt.Sprintf(templateEditable, 2), id, userID)
+	if err != nil {
+		logger.Log.Errorln("Delete template error:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template deleted"})
+}
+
+// loadTemplate шаблон, доступный пользователю username
+func loadTemplate(db *sql.DB, id int, username string) (structures.RoomTemplate, error) {
+	userID, err := userIDByName(db, username)
+	if err != nil {
+		return structures.RoomTemplate{}, ErrTemplateNotFound
+	}
+
+	template, err := scanTemplate(db.QueryRow(templateSelect+` WHERE t.id = $2 AND `+templateAccess, userID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return template, ErrTemplateNotFound
+	}
+	return template, err
+}
+
+// applyTemplate собирает запрос на создание комнаты: настройки шаблона, поверх них — поля,
+// явно переданные в теле запроса body
+func applyTemplate(db *sql.DB, username string, body []byte, req *structures.CreateRoomRequest) error {
+	template, err := loadTemplate(db, req.TemplateID, username)
+	if err != nil {
+		return err
+	}
+
+	merged := template.Settings
+	if err = json.Unmarshal(body, &merged); err != nil {
+		return err
+	}
+	*req = merged
+	return nil
+}
+
+// scanTemplate читает колонки templateColumns и следом extra
+func scanTemplate(row interface{ Scan(...any) error }, extra ...interface{}) (structures.RoomTemplate, error) {
+	var template structures.RoomTemplate
+	var settingsJSON []byte
+	var createdAt, updatedAt time.Time
+
+	err := row.Scan(append([]interface{}{&template.ID, &template.Owner, &template.Name, &template.OrganizationID,
+		&settingsJSON, &createdAt, &updatedAt}, extra...)...)
+	if err != nil {
+		return template, err
+	}
+	if err = json.Unmarshal(settingsJSON, &template.Settings); err != nil {
+		return template, err
+	}
+
+	template.CreatedAt = createdAt.Format(time.RFC3339)
+	template.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return template, nil
+}
+
+// templateSettings убирает из настроек поля, которые не должны переживать одну комнату
+func templateSettings(settings structures.CreateRoomRequest) []byte {
+	settings.Password = ""
+	settings.CreatorName = ""
+	settings.DontJoin = false
+	settings.TemplateID = 0
+
+	data, _ := json.Marshal(settings)
+	return data
+}
+
+func respondTemplateError(c *gin.Context, err error) {
+	if errors.Is(err, ErrTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	logger.Log.Errorln("Template query error:", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"awesomeChat/package/logger"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	Russian = "ru"
+	English = "en"
+
+	// Default язык, на котором сервер говорил до появления каталогов
+	Default = Russian
+)
+
+// Params параметры подстановки: в шаблоне "{username} присоединился" ключ — username
+type Params map[string]any
+
+// Text локализуемый текст: ключ каталога и параметры. Клиент может сам перевести его по ключу
+type Text struct {
+	Key    string `json:"key"`
+	Params Params `json:"params,omitempty"`
+}
+
+func New(key string, params Params) Text {
+	return Text{Key: key, Params: params}
+}
+
+func (t Text) Render(locale string) string {
+	return T(locale, t.Key, t.Params)
+}
+
+//go:embed locales/*.json
+var files embed.FS
+
+var bundles = loadBundles()
+
+func loadBundles() map[string]map[string]string {
+	result := make(map[string]map[string]string)
+
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		logger.Log.Fatalln("Can not read locales: " + err.Error())
+	}
+	for _, entry := range entries {
+		data, err := files.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			logger.Log.Fatalln("Can not read locale " + entry.Name() + ": " + err.Error())
+		}
+		bundle := make(map[string]string)
+		if err = json.Unmarshal(data, &bundle); err != nil {
+			logger.Log.Fatalln("Can not parse locale " + entry.Name() + ": " + err.Error())
+		}
+		result[strings.TrimSuffix(entry.Name(), ".json")] = bundle
+	}
+
+	return result
+}
+
+// T переводит ключ на язык locale; если перевода нет — берет язык по умолчанию, затем сам ключ
+func T(locale, key string, params Params) string {
+	template, ok := bundles[locale][key]
+	if !ok {
+		template, ok = bundles[Default][key]
+	}
+	if !ok {
+		logger.Log.Warnf("Missing translation for %q", key)
+		template = key
+	}
+
+	if len(params) == 0 {
+		return template
+	}
+
+	replacements := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+func Supported(locale string) bool {
+	_, ok := bundles[locale]
+	return ok
+}
+
+// Locales список доступных языков
+func Locales() []string {
+	locales := make([]string, 0, len(bundles))
+	for locale := range bundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Negotiate выбирает язык: явное предпочтение пользователя, затем заголовок Accept-Language, затем Default
+func Negotiate(preferred string, acceptLanguage string) string {
+	if Supported(preferred) {
+		return preferred
+	}
+
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		// "en-US" подходит под "en"
+		base, _, _ := strings.Cut(tag, "-")
+		candidates = append(candidates, candidate{locale: base, q: q})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	for _, c := range candidates {
+		if c.q > 0 && Supported(c.locale) {
+			return c.locale
+		}
+	}
+
+	return Default
+}
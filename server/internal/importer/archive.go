@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"awesomeChat/internal/structures"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ParseArchive разбирает дискуссию в формате ответа GET /discussion/:id
+func ParseArchive(data []byte) (*Transcript, error) {
+	var discussion structures.DiscussionResponse
+	if err := json.Unmarshal(data, &discussion); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	t := &Transcript{
+		RoomName:       discussion.RoomName,
+		Mode:           discussion.Mode,
+		SubType:        discussion.SubType,
+		CustomTopic:    discussion.Topic,
+		CustomSubtopic: discussion.Subtopic,
+		Description:    discussion.Description,
+		Purpose:        discussion.Purpose,
+		KeyQuestions:   discussion.KeyQuestions,
+		Tags:           discussion.Tags,
+		Teams:          discussion.Teams,
+		Participants:   discussion.Participants,
+		Start:          parseArchiveTime(discussion.StartTime),
+		End:            parseArchiveTime(discussion.EndTime),
+	}
+	for _, msg := range discussion.Messages {
+		t.Messages = append(t.Messages, Message{
+			ID:         msg.ID,
+			Type:       msg.Type,
+			Time:       msg.Timestamp,
+			Author:     msg.Username,
+			Content:    msg.Content,
+			LikedBy:    msg.LikedBy,
+			DislikedBy: msg.DislikedBy,
+			ReplyTo:    msg.ReplyTo,
+		})
+	}
+	return t, nil
+}
+
+// parseArchiveTime время начала и конца; нечитаемое значение заменится временем сообщений
+func parseArchiveTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,313 @@
+package importer
+
+import (
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown transcript format")
+	ErrInvalid       = errors.New("invalid transcript")
+)
+
+// Transcript дискуссия, разобранная из внешнего файла, до сохранения в архив.
+// Авторы и оценившие — имена из источника; в пользователей они превращаются при сохранении
+type Transcript struct {
+	RoomName       string
+	Mode           string
+	SubType        string
+	CustomTopic    string
+	CustomSubtopic string
+	Description    string
+	Purpose        string
+	KeyQuestions   []string
+	Tags           []string
+	Teams          []structures.TeamInfo
+	Participants   []string // участники без сообщений; авторы, оценившие и члены команд добавляются сами
+	Start          time.Time
+	End            time.Time
+	Messages       []Message
+}
+
+// Message сообщение источника; ID и ReplyTo — идентификаторы источника, при сохранении заменяются новыми
+type Message struct {
+	ID         string
+	Type       string
+	Time       time.Time
+	Author     string
+	Content    string
+	LikedBy    []string
+	DislikedBy []string
+	ReplyTo    string
+}
+
+// parsers разборщики по значению параметра format
+var parsers = map[string]func([]byte) (*Transcript, error){
+	"archive":  ParseArchive,
+	"telegram": ParseTelegram,
+	"log":      ParseLog,
+}
+
+func Formats() []string {
+	formats := make([]string, 0, len(parsers))
+	for format := range parsers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+func Parse(format string, data []byte) (*Transcript, error) {
+	parse, ok := parsers[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	t, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Messages) == 0 {
+		return nil, fmt.Errorf("%w: no messages", ErrInvalid)
+	}
+	t.fillDefaults()
+	return t, nil
+}
+
+// fillDefaults сортирует сообщения и дополняет то, чего нет в источнике: формат, название, время
+func (t *Transcript) fillDefaults() {
+	sort.SliceStable(t.Messages, func(i, j int) bool { return t.Messages[i].Time.Before(t.Messages[j].Time) })
+	for i := range t.Messages {
+		if t.Messages[i].Type == "" {
+			t.Messages[i].Type = "usual"
+		}
+	}
+	if t.Mode == "" {
+		t.Mode, t.SubType = "personal", "free"
+	}
+	if t.Start.IsZero() {
+		t.Start = t.Messages[0].Time
+	}
+	if t.End.IsZero() || t.End.Before(t.Start) {
+		t.End = t.Messages[len(t.Messages)-1].Time
+	}
+	if t.RoomName == "" {
+		t.RoomName = "Imported " + t.Start.Format("2006-01-02")
+	}
+}
+
+// Options параметры сохранения импортированной дискуссии
+type Options struct {
+	Source  string            // формат источника, сохраняется в import_source
+	Creator string            // кто импортировал
+	Public  bool              // видна ли дискуссия в общем архиве
+	Authors map[string]string // имя в источнике -> имя пользователя; остальные ищутся по совпадению имени
+}
+
+// Result итог импорта
+type Result struct {
+	DiscussionID int      `json:"id"`
+	Messages     int      `json:"messages"`
+	Participants []string `json:"participants"`
+	Placeholders []string `json:"placeholders"` // созданные заглушки пользователей
+}
+
+// Save сохраняет дискуссию с пометкой imported. Авторы сопоставляются с пользователями по Options.Authors
+// или по имени; для неизвестных создаются заглушки, под которыми нельзя войти
+func Save(db *sql.DB, t *Transcript, options Options) (*Result, error) {
+	if _, ok := modes.Get(t.Mode, t.SubType); !ok {
+		return nil, fmt.Errorf("%w: unknown mode %s/%s", ErrInvalid, t.Mode, t.SubType)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	users := &userResolver{tx: tx, mapping: options.Authors, resolved: make(map[string]string)}
+	result := &Result{Participants: []string{}, Placeholders: []string{}}
+
+	// новые id сообщений, чтобы ответы ссылались на сообщения этой дискуссии
+	ids := make(map[string]string, len(t.Messages))
+	for _, msg := range t.Messages {
+		if msg.ID != "" {
+			ids[msg.ID] = uuid.New().String()
+		}
+	}
+
+	participants := make(map[string]bool)
+	join := func(author string) (string, error) {
+		username, err := users.resolve(author)
+		if err == nil && !participants[username] {
+			participants[username] = true
+			result.Participants = append(result.Participants, username)
+		}
+		return username, err
+	}
+
+	messages := make([]structures.Message, 0, len(t.Messages))
+	for _, msg := range t.Messages {
+		converted := structures.Message{
+			ID:         ids[msg.ID],
+			Type:       msg.Type,
+			Content:    msg.Content,
+			Timestamp:  msg.Time,
+			ReplyTo:    ids[msg.ReplyTo],
+			LikedBy:    []string{},
+			DislikedBy: []string{},
+		}
+		if converted.ID == "" {
+			converted.ID = uuid.New().String()
+		}
+		converted.Username = msg.Author
+		if msg.Type == "usual" {
+			if converted.Username, err = join(msg.Author); err != nil {
+				return nil, err
+			}
+		}
+		for _, list := range []struct {
+			from []string
+			to   *[]string
+		}{
+			{msg.LikedBy, &converted.LikedBy},
+			{msg.DislikedBy, &converted.DislikedBy},
+		} {
+			for _, voter := range list.from {
+				username, err := join(voter)
+				if err != nil {
+					return nil, err
+				}
+				*list.to = append(*list.to, username)
+			}
+		}
+		converted.LikeCount, converted.DislikeCount = len(converted.LikedBy), len(converted.DislikedBy)
+		messages = append(messages, converted)
+	}
+
+	var teamsJSON []byte
+	if len(t.Teams) > 0 {
+		teams := make([]structures.TeamInfo, len(t.Teams))
+		for i, team := range t.Teams {
+			teams[i] = structures.TeamInfo{Team: team.Team, Thesis: team.Thesis, Members: []string{}}
+			for _, member := range team.Members {
+				username, err := join(member)
+				if err != nil {
+					return nil, err
+				}
+				teams[i].Members = append(teams[i].Members, username)
+			}
+		}
+		teamsJSON, _ = json.Marshal(teams)
+	}
+
+	for _, participant := range t.Participants {
+		if _, err = join(participant); err != nil {
+			return nil, err
+		}
+	}
+
+	keyQuestionsJSON, _ := json.Marshal(nonNil(t.KeyQuestions))
+	tagsJSON, _ := json.Marshal(nonNil(t.Tags))
+	participantsJSON, _ := json.Marshal(result.Participants)
+
+	// пустые строки и нули вместо NULL — так же сохраняются дискуссии из комнат
+	err = tx.QueryRow(`
+		INSERT INTO discussions
+			(room_id, mode, subtype, duration, start_time, end_time,
+			 creator_username, key_questions, tags,
+			 export_options, participants, topic_id, subtopic_id,
+			 custom_topic, custom_subtopic, description, purpose, room_name, public, teams,
+			 imported, import_source)
+		VALUES
+			(0, $1, $2, $3, $4, $5, $6, $7, $8, '[]', $9, 0, 0, $10, $11, $12, $13, $14, $15, $16, true, $17)
+		RETURNING id`,
+		t.Mode,
+		t.SubType,
+		int64(t.End.Sub(t.Start).Seconds()),
+		t.Start,
+		t.End,
+		options.Creator,
+		keyQuestionsJSON,
+		tagsJSON,
+		participantsJSON,
+		t.CustomTopic,
+		t.CustomSubtopic,
+		t.Description,
+		t.Purpose,
+		t.RoomName,
+		options.Public,
+		teamsJSON,
+		options.Source,
+	).Scan(&result.DiscussionID)
+	if err != nil {
+		return nil, err
+	}
+	if err = storage.SaveMessages(tx, result.DiscussionID, messages); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	result.Messages = len(messages)
+	result.Placeholders = append(result.Placeholders, users.created...)
+	return result, nil
+}
+
+// userResolver сопоставляет имена источника с пользователями в рамках одной транзакции импорта
+type userResolver struct {
+	tx       *sql.Tx
+	mapping  map[string]string
+	resolved map[string]string
+	created  []string
+}
+
+func (r *userResolver) resolve(author string) (string, error) {
+	author = strings.TrimSpace(author)
+	if username, ok := r.resolved[author]; ok {
+		return username, nil
+	}
+
+	username := author
+	if mapped, ok := r.mapping[author]; ok && strings.TrimSpace(mapped) != "" {
+		username = strings.TrimSpace(mapped)
+	}
+	if username == "" {
+		username = "unknown"
+	}
+
+	var exists bool
+	err := r.tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		// адрес в зарезервированном домене и пустой хеш пароля: войти под заглушкой невозможно
+		_, err = r.tx.Exec(`INSERT INTO users (username, email, password_hash, placeholder) VALUES ($1, $2, '', true)`,
+			username, "placeholder-"+uuid.New().String()+"@imported.invalid")
+		if err != nil {
+			return "", err
+		}
+		r.created = append(r.created, username)
+	}
+
+	r.resolved[author] = username
+	return username, nil
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
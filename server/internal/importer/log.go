@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logLine строка вида "2024-03-01 18:05:12 alice: текст"; время можно взять в квадратные скобки,
+// секунды и часовой пояс необязательны
+var logLine = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}(?::\d{2})?(?:Z|[+-]\d{2}:?\d{2})?)\]?\s+([^:]+?):\s?(.*)$`)
+
+var logTimeLayouts = []string{
+	"2006-01-02 15:04:05Z07:00", "2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05Z0700", "2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04Z07:00", "2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05", "2006-01-02T15:04:05",
+	"2006-01-02 15:04", "2006-01-02T15:04",
+}
+
+// ParseLog разбирает текстовый лог "время автор: текст". Строки без времени продолжают предыдущее
+// сообщение; время без часового пояса считается UTC
+func ParseLog(data []byte) (*Transcript, error) {
+	t := &Transcript{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if number == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		match := logLine.FindStringSubmatch(line)
+		if match == nil {
+			if last := len(t.Messages) - 1; last >= 0 {
+				t.Messages[last].Content += "\n" + line
+				continue
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%w: line %d: expected \"timestamp username: text\"", ErrInvalid, number)
+		}
+
+		at, err := parseLogTime(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalid, number, err)
+		}
+		t.Messages = append(t.Messages, Message{
+			ID:      fmt.Sprint(number),
+			Time:    at,
+			Author:  strings.TrimSpace(match[2]),
+			Content: match[3],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	for i := range t.Messages {
+		t.Messages[i].Content = strings.TrimRight(t.Messages[i].Content, "\n ")
+	}
+	return t, nil
+}
+
+func parseLogTime(value string) (time.Time, error) {
+	for _, layout := range logTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time %q", value)
+}
@@ -0,0 +1,125 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegramExport result.json из экспорта чата Telegram Desktop
+type telegramExport struct {
+	Name     string            `json:"name"`
+	Messages []telegramMessage `json:"messages"`
+}
+
+type telegramMessage struct {
+	ID           int                `json:"id"`
+	Type         string             `json:"type"` // "message" или "service"
+	Date         string             `json:"date"`
+	DateUnixtime string             `json:"date_unixtime"`
+	From         string             `json:"from"`
+	FromID       string             `json:"from_id"`
+	Text         telegramText       `json:"text"`
+	ReplyTo      int                `json:"reply_to_message_id"`
+	Reactions    []telegramReaction `json:"reactions"`
+}
+
+type telegramReaction struct {
+	Emoji  string `json:"emoji"`
+	Recent []struct {
+		From string `json:"from"`
+	} `json:"recent"`
+}
+
+// telegramText текст сообщения: строка или массив из строк и фрагментов с разметкой
+type telegramText string
+
+func (t *telegramText) UnmarshalJSON(data []byte) error {
+	var plain string
+	if err := json.Unmarshal(data, &plain); err == nil {
+		*t = telegramText(plain)
+		return nil
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return err
+	}
+	var text strings.Builder
+	for _, part := range parts {
+		var entity struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(part, &plain); err == nil {
+			text.WriteString(plain)
+		} else if err = json.Unmarshal(part, &entity); err == nil {
+			text.WriteString(entity.Text)
+		}
+	}
+	*t = telegramText(text.String())
+	return nil
+}
+
+// telegramLikes реакции, которые считаются лайком и дизлайком
+var telegramLikes = map[string]int{"👍": 1, "❤": 1, "❤️": 1, "🔥": 1, "👎": -1}
+
+// ParseTelegram разбирает экспорт чата Telegram Desktop в JSON. Служебные сообщения и сообщения
+// без текста (стикеры, фото без подписи) пропускаются; реакции 👍 ❤ 🔥 становятся лайками, 👎 — дизлайками.
+// Экспорт хранит только последних поставивших реакцию, поэтому лайков может оказаться меньше, чем в чате
+func ParseTelegram(data []byte) (*Transcript, error) {
+	var export telegramExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	t := &Transcript{RoomName: export.Name}
+	for _, msg := range export.Messages {
+		if msg.Type != "message" || strings.TrimSpace(string(msg.Text)) == "" {
+			continue
+		}
+		at, err := telegramTime(msg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: message %d: %v", ErrInvalid, msg.ID, err)
+		}
+
+		author := msg.From
+		if author == "" {
+			author = msg.FromID
+		}
+		imported := Message{
+			ID:      strconv.Itoa(msg.ID),
+			Time:    at,
+			Author:  author,
+			Content: string(msg.Text),
+		}
+		if msg.ReplyTo != 0 {
+			imported.ReplyTo = strconv.Itoa(msg.ReplyTo)
+		}
+		for _, reaction := range msg.Reactions {
+			for _, recent := range reaction.Recent {
+				switch telegramLikes[reaction.Emoji] {
+				case 1:
+					imported.LikedBy = append(imported.LikedBy, recent.From)
+				case -1:
+					imported.DislikedBy = append(imported.DislikedBy, recent.From)
+				}
+			}
+		}
+		t.Messages = append(t.Messages, imported)
+	}
+	return t, nil
+}
+
+// telegramTime время сообщения: date_unixtime точнее, date записан в местном времени экспортировавшего
+func telegramTime(msg telegramMessage) (time.Time, error) {
+	if msg.DateUnixtime != "" {
+		seconds, err := strconv.ParseInt(msg.DateUnixtime, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Parse("2006-01-02T15:04:05", msg.Date)
+}
@@ -0,0 +1,55 @@
+package informing
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/structures"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"time"
+)
+
+// SendAgendaUpdate объявляет переход к ключевому вопросу index (-1 — повестка закрыта)
+func SendAgendaUpdate(room *structures.Room, index int, endsAt *time.Time) {
+	update := structures.AgendaUpdateMessage{
+		Type:   "agenda_update",
+		Index:  index,
+		Total:  len(room.KeyQuestions),
+		EndsAt: endsAt,
+	}
+
+	if index < 0 {
+		sendToAll(room, structures.Message{Type: "system", Key: "agenda_closed"})
+	} else {
+		update.Question = room.KeyQuestions[index]
+		params := i18n.Params{"number": index + 1, "total": len(room.KeyQuestions), "question": update.Question}
+		if index < len(room.AgendaTimeBoxes) && room.AgendaTimeBoxes[index] > 0 {
+			update.TimeBox = room.AgendaTimeBoxes[index]
+			params["duration"] = formatRemaining(time.Duration(update.TimeBox) * time.Second)
+		}
+
+		for _, user := range append(append([]*structures.ChatUser{}, room.Users...), room.Spectators...) {
+			content := i18n.T(user.Locale, "agenda_item_started", params)
+			if update.TimeBox > 0 {
+				content += i18n.T(user.Locale, "agenda_timebox", params)
+			}
+			messageToSend, _ := json.Marshal(structures.Message{Type: "system", Content: content, Params: params})
+			user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+		}
+	}
+
+	messageToSend, _ := json.Marshal(update)
+	for _, user := range room.Users {
+		user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+	for _, spectator := range room.Spectators {
+		spectator.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+}
+
+func SendAgendaTimeBoxOver(room *structures.Room, index int) {
+	sendToAll(room, structures.Message{
+		Type:   "system",
+		Key:    "agenda_timebox_over",
+		Params: i18n.Params{"question": room.KeyQuestions[index]},
+	})
+}
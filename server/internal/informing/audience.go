@@ -0,0 +1,55 @@
+package informing
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+)
+
+// SendAudiencePoll рассылает зрителям открытый опрос с тезисами
+func SendAudiencePoll(room *structures.Room, theses []string) {
+	for _, spectator := range room.Spectators {
+		SendAudiencePollTo(spectator, room.AudiencePoll, theses)
+	}
+}
+
+// SendAudiencePollTo отправляет опрос одному зрителю (например, только что подключившемуся)
+func SendAudiencePollTo(spectator *structures.ChatUser, stage string, theses []string) {
+	msg := structures.AudiencePollMessage{
+		Type:   "audience_poll",
+		Stage:  stage,
+		Theses: theses,
+	}
+	messageToSend, _ := json.Marshal(msg)
+
+	logger.Log.Traceln("Sending message:", string(messageToSend))
+	spectator.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+}
+
+// SendAudienceResult объявляет итог голосования зрителей всем в комнате
+func SendAudienceResult(room *structures.Room, result structures.AudienceResult) {
+	msg := structures.AudienceResultMessage{
+		Type:   "audience_result",
+		Result: result,
+	}
+	messageToSend, _ := json.Marshal(msg)
+
+	for _, user := range room.Users {
+		user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+	for _, spectator := range room.Spectators {
+		spectator.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+
+	announcement := structures.Message{
+		Type: "system",
+		Key:  "audience_draw",
+	}
+	if result.Winner >= 0 {
+		announcement.Key = "audience_winner"
+		announcement.Params = i18n.Params{"thesis": result.WinnerThesis}
+	}
+	sendToAll(room, announcement)
+}
@@ -1,19 +1,23 @@
 package informing
 
 import (
+	"awesomeChat/internal/catalog"
+	"awesomeChat/internal/i18n"
 	"awesomeChat/internal/structures"
 	"awesomeChat/package/logger"
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"strconv"
+	"strings"
 	"time"
 )
 
 func InformUserLeft(room *structures.Room, username string) {
 	msg := structures.Message{
 		Type:     "userLeft",
-		Content:  username + " покинул комнату",
+		Key:      "user_left",
+		Params:   i18n.Params{"username": username},
 		Username: "default",
 	}
 
@@ -23,7 +27,8 @@ func InformUserLeft(room *structures.Room, username string) {
 func InformUserJoined(room *structures.Room, username string) {
 	msg := structures.Message{
 		Type:     "userJoined",
-		Content:  username + " присоединился",
+		Key:      "user_joined",
+		Params:   i18n.Params{"username": username},
 		Username: "default",
 	}
 
@@ -32,8 +37,9 @@ func InformUserJoined(room *structures.Room, username string) {
 
 func SetRoomName(room *structures.Room) {
 	msg := structures.Message{
-		Type:    "setRoomName",
-		Content: "[Комната #" + strconv.Itoa(room.ID) + "]    " + room.Name,
+		Type:   "setRoomName",
+		Key:    "room_name",
+		Params: i18n.Params{"id": room.ID, "name": room.Name},
 	}
 
 	sendToAll(room, msg)
@@ -49,16 +55,21 @@ func SetRoomName(room *structures.Room) {
 //	sendToAll(room, msg)
 //}
 
+// sendToAll рассылает сообщение участникам и зрителям; если у сообщения есть Key,
+// Content переводится на язык каждого получателя
 func sendToAll(room *structures.Room, msg structures.Message) {
-	messageToSend, _ := json.Marshal(msg)
-
 	for _, user := range room.Users {
-		logger.Log.Traceln("Sending message:", string(messageToSend))
-		user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+		sendToOne(user, msg)
+	}
+	for _, spectator := range room.Spectators {
+		sendToOne(spectator, msg)
 	}
 }
 
 func sendToOne(user *structures.ChatUser, msg structures.Message) {
+	if msg.Key != "" {
+		msg.Content = i18n.T(user.Locale, msg.Key, msg.Params)
+	}
 	messageToSend, _ := json.Marshal(msg)
 
 	logger.Log.Traceln("Sending message:", string(messageToSend))
@@ -70,40 +81,32 @@ func SendTimerUpdate(room *structures.Room, remaining time.Duration) {
 		remaining = 0
 	}
 
+	msg := structures.Message{
+		Type:   "timer",
+		Key:    "timer",
+		Params: i18n.Params{"remaining": formatRemaining(remaining), "seconds": int(remaining.Seconds())},
+	}
+	sendToAll(room, msg)
+}
+
+func formatRemaining(remaining time.Duration) string {
 	hours := int(remaining.Hours())
 	minutes := int(remaining.Minutes()) % 60
 	seconds := int(remaining.Seconds()) % 60
 
-	var timeStr string
 	if hours > 0 {
-		timeStr = fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
-	} else {
-		timeStr = fmt.Sprintf("%02d:%02d", minutes, seconds)
+		return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
 	}
-
-	msg := structures.Message{
-		Type:    "timer",
-		Content: fmt.Sprintf("Осталось времени: %s", timeStr),
-	}
-	sendToAll(room, msg)
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
 }
 
-func sendRateYourOpponents(room *structures.Room) {
-	var msg structures.FinalRateMessage
-	if room.Mode == "professional" {
-		msg = structures.FinalRateMessage{
-			DiscussionID: room.DiscussionID,
-			Type:         "discussion_end",
-			Users:        room.Participants,
-			Criteria:     []string{"professionalism", "arguments_quality", "politeness"}, //todo make const
-		}
-	} else {
-		msg = structures.FinalRateMessage{
-			DiscussionID: room.DiscussionID,
-			Type:         "discussion_end",
-			Users:        room.Participants,
-			Criteria:     []string{"professionalism", "politeness", "arguments_quality"}, //todo make const
-		}
+func sendRateYourOpponents(room *structures.Room, criteria []string) {
+	msg := structures.FinalRateMessage{
+		DiscussionID: room.DiscussionID,
+		Type:         "discussion_end",
+		Users:        room.Participants,
+		Criteria:     criteria,
+		Teams:        room.UserTeams,
 	}
 
 	messageToSend, _ := json.Marshal(msg)
@@ -114,100 +117,200 @@ func sendRateYourOpponents(room *structures.Room) {
 	}
 }
 
-func SendDiscussionEnd(room *structures.Room) {
-	sendRateYourOpponents(room)
+func SendDiscussionEnd(room *structures.Room, criteria []string) {
+	sendRateYourOpponents(room, criteria)
 
 	msg := structures.Message{
-		Type:    "discussion_end",
-		Content: "Обсуждение закончено! Оцените ваших собеседников",
+		Type: "discussion_end",
+		Key:  "discussion_end",
 	}
 	sendToAll(room, msg)
 }
 
 func sendTheses(room *structures.Room) {
+	teams := structures.MakeTeamList(room)
+
 	for _, user := range room.Users {
 		msg := structures.Message{
-			Type:    "system",
-			Content: fmt.Sprintf("Ваша точка зрения на это обсуждение: %s", room.UserTheses[user.Name]),
+			Type:   "system",
+			Key:    "your_thesis",
+			Params: i18n.Params{"thesis": room.UserTheses[user.Name]},
 		}
 		sendToOne(user, msg)
+
+		team, ok := room.UserTeams[user.Name]
+		if !ok || team >= len(teams) {
+			continue
+		}
+		teammates := make([]string, 0, len(teams[team].Members))
+		for _, member := range teams[team].Members {
+			if member != user.Name {
+				teammates = append(teammates, member)
+			}
+		}
+		if len(teammates) > 0 {
+			sendToOne(user, structures.Message{
+				Type:   "system",
+				Key:    "your_team",
+				Params: i18n.Params{"team": team + 1, "teammates": strings.Join(teammates, ", ")},
+			})
+		}
+		sendTeamAssignment(user, structures.TeamAssignmentMessage{
+			Type:      "team_assignment",
+			Team:      team,
+			Thesis:    teams[team].Thesis,
+			Teammates: teammates,
+		})
 	}
 }
 
-func sendBlitzDiscussion(room *structures.Room) {
+func sendTeamAssignment(user *structures.ChatUser, msg structures.TeamAssignmentMessage) {
+	messageToSend, _ := json.Marshal(msg)
+
+	logger.Log.Traceln("Sending message:", string(messageToSend))
+	user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+}
+
+func SendBlitzStart(room *structures.Room) {
 	time.Sleep(2 * time.Second)
 	msg := structures.Message{
-		Type:    "system",
-		Content: fmt.Sprintf("Тема: %s", structures.SubtopicDB[room.SubtopicID]),
-	}
-	sendToAll(room, msg)
-	time.Sleep(3 * time.Second)
-	msg = structures.Message{
-		Type:    "system",
-		Content: "Противоположные тезисы:",
+		Type:   "system",
+		Key:    "blitz_topic",
+		Params: i18n.Params{"subtopic": catalog.SubtopicName(room.SubtopicID)},
 	}
 	sendToAll(room, msg)
 	time.Sleep(3 * time.Second)
 	msg = structures.Message{
-		Type:    "system",
-		Content: fmt.Sprintf("1. %s", structures.ThesesDB[room.SubtopicID][0]),
+		Type: "system",
+		Key:  "blitz_theses",
 	}
 	sendToAll(room, msg)
-	time.Sleep(4 * time.Second)
-	msg = structures.Message{
-		Type:    "system",
-		Content: fmt.Sprintf("2. %s", structures.ThesesDB[room.SubtopicID][1]),
+	for i, thesis := range room.AssignedTheses {
+		time.Sleep(3 * time.Second)
+		msg = structures.Message{
+			Type:   "system",
+			Key:    "blitz_thesis_item",
+			Params: i18n.Params{"number": i + 1, "thesis": thesis},
+		}
+		sendToAll(room, msg)
 	}
-	sendToAll(room, msg)
 	time.Sleep(4 * time.Second)
 
 	sendTheses(room)
 	time.Sleep(5 * time.Second)
 
 	msg = structures.Message{
-		Type:    "discussion_start",
-		Content: "🎉 Дискуссия началась!",
+		Type: "discussion_start",
+		Key:  "discussion_start",
 	}
 	sendToAll(room, msg)
 	time.Sleep(2 * time.Second)
 }
 
-func sendFreeDiscussion(room *structures.Room) {
+func SendFreeStart(room *structures.Room) {
 	msg := structures.Message{
-		Type:    "discussion_start",
-		Content: "🎉 Дискуссия началась!",
+		Type: "discussion_start",
+		Key:  "discussion_start",
 	}
 	sendToAll(room, msg)
 	time.Sleep(2 * time.Second)
 }
 
-func sendProfessionalDiscussion(room *structures.Room) {
+func SendProfessionalStart(room *structures.Room) {
 	msg := structures.Message{
-		Type:    "discussion_start",
-		Content: "🎉 Дискуссия началась!",
+		Type: "discussion_start",
+		Key:  "discussion_start",
 	}
 	sendToAll(room, msg)
 	time.Sleep(2 * time.Second)
 }
 
-func SendDiscussionStart(room *structures.Room) {
-	switch room.Mode {
-	case "personal":
-		if room.SubType == "blitz" {
-			sendBlitzDiscussion(room)
-		} else if room.SubType == "free" {
-			sendFreeDiscussion(room)
-		}
-	case "professional":
-		sendProfessionalDiscussion(room)
-	}
-}
-
 func SendUserReady(room *structures.Room, username string) {
 	msg := structures.Message{
 		Type:     "system",
-		Content:  fmt.Sprintf("Пользователь %s готов начать!", username),
+		Key:      "user_ready",
+		Params:   i18n.Params{"username": username},
 		Username: "system",
 	}
 	sendToAll(room, msg)
 }
+
+// SendPhaseChange объявляет начало этапа структурированной дискуссии
+func SendPhaseChange(room *structures.Room, index int, endsAt time.Time) {
+	phase := room.Phases[index]
+
+	params := i18n.Params{
+		"number":   index + 1,
+		"total":    len(room.Phases),
+		"title":    phase.Title,
+		"duration": formatRemaining(time.Duration(phase.Duration) * time.Second),
+	}
+	if len(phase.Sides) > 0 {
+		sides := make([]string, len(phase.Sides))
+		for i, side := range phase.Sides {
+			sides[i] = strconv.Itoa(side + 1)
+		}
+		params["sides"] = strings.Join(sides, ", ")
+	}
+	if phase.MessagesPerSpeaker > 0 {
+		params["limit"] = phase.MessagesPerSpeaker
+	}
+
+	// объявление собирается из нескольких фраз каталога, поэтому переводится здесь, а не в sendToOne
+	for _, user := range append(append([]*structures.ChatUser{}, room.Users...), room.Spectators...) {
+		content := i18n.T(user.Locale, "phase_started", params)
+		if _, ok := params["sides"]; ok {
+			content += i18n.T(user.Locale, "phase_sides", params)
+		}
+		if _, ok := params["limit"]; ok {
+			content += i18n.T(user.Locale, "phase_limit", params)
+		}
+		msg := structures.Message{
+			Type:    "system",
+			Content: content,
+			Params:  params,
+		}
+		messageToSend, _ := json.Marshal(msg)
+		user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+
+	messageToSend, _ := json.Marshal(structures.PhaseChangeMessage{
+		Type:   "phase_change",
+		Index:  index,
+		Total:  len(room.Phases),
+		Phase:  phase,
+		EndsAt: endsAt,
+	})
+	for _, user := range room.Users {
+		user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+	for _, spectator := range room.Spectators {
+		spectator.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+}
+
+// SendPostRejected сообщает отправителю, что его сообщение не прошло правила формата или этапа
+func SendPostRejected(user *structures.ChatUser, tempID string, reason i18n.Text) {
+	sendToOne(user, structures.Message{
+		Type:   "post_rejected",
+		Key:    reason.Key,
+		Params: reason.Params,
+		TempID: tempID,
+	})
+}
+
+// SendExportsReady сообщает оставшимся в комнате, что выгрузки готовы; позже их можно найти
+// в GET /discussion/:id/exports
+func SendExportsReady(room *structures.Room, artifacts []structures.ExportArtifact) {
+	messageToSend, _ := json.Marshal(structures.ExportsReadyMessage{
+		Type:         "exports_ready",
+		DiscussionID: room.DiscussionID,
+		Exports:      artifacts,
+	})
+
+	room.Mu.Lock()
+	defer room.Mu.Unlock()
+	for _, user := range room.Users {
+		user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+}
@@ -0,0 +1,37 @@
+package informing
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/structures"
+	"encoding/json"
+	"github.com/gorilla/websocket"
+)
+
+// SendOutcomeAdded объявляет новое решение или поручение всем в комнате
+func SendOutcomeAdded(room *structures.Room, outcome structures.Outcome) {
+	params := i18n.Params{"author": outcome.Author, "content": outcome.Content, "assignee": outcome.Assignee, "due": outcome.DueDate}
+	key := "outcome_decision_added"
+	if outcome.Kind == structures.OutcomeActionItem {
+		key = "outcome_action_added"
+	}
+
+	messageToSend, _ := json.Marshal(structures.OutcomeAddedMessage{Type: "outcome_added", Outcome: outcome})
+	for _, user := range append(append([]*structures.ChatUser{}, room.Users...), room.Spectators...) {
+		content := i18n.T(user.Locale, key, params)
+		if outcome.DueDate != "" {
+			content += i18n.T(user.Locale, "outcome_due", params)
+		}
+		systemMessage, _ := json.Marshal(structures.Message{Type: "system", Content: content, Params: params})
+		user.Connection.WriteMessage(websocket.TextMessage, systemMessage)
+		user.Connection.WriteMessage(websocket.TextMessage, messageToSend)
+	}
+}
+
+// SendOutcomeRejected сообщает автору, почему команда не принята
+func SendOutcomeRejected(user *structures.ChatUser, reason i18n.Text) {
+	sendToOne(user, structures.Message{
+		Type:   "outcome_rejected",
+		Key:    reason.Key,
+		Params: reason.Params,
+	})
+}
@@ -0,0 +1,226 @@
+package matchmaking
+
+import (
+	"awesomeChat/package/logger"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	TickInterval  = 2 * time.Second  // как часто очередь пытается составить пары
+	Timeout       = 5 * time.Minute  // сколько можно ждать соперника до выхода из очереди
+	baseSkillGap  = 0.5              // допустимая разница навыка сразу после входа в очередь
+	skillGapStep  = 0.25             // на сколько расширяется допуск
+	skillGapEvery = 15 * time.Second // через какое время ожидания допуск расширяется
+	maxSkillGap   = 4.0              // при таком допуске подходит любой соперник (рейтинги от 1 до 5)
+)
+
+var ErrAlreadyQueued = errors.New("user is already in the queue")
+
+// Ticket заявка пользователя в очереди. TopicID и SubtopicID равные 0 означают "любая тема"
+type Ticket struct {
+	Username   string    `json:"username"`
+	TopicID    int       `json:"topic"`
+	SubtopicID int       `json:"subtopic"`
+	Skill      float64   `json:"skill"`
+	Locale     string    `json:"locale"`
+	JoinedAt   time.Time `json:"joinedAt"`
+}
+
+// Match найденная пара и тема, о которой им предстоит спорить
+type Match struct {
+	First      Ticket
+	Second     Ticket
+	TopicID    int
+	SubtopicID int
+}
+
+type Queue struct {
+	mu      sync.Mutex
+	tickets []*Ticket
+
+	// PickSubtopic выбирает топик и сабтопик для пары: topicID == 0 значит любой топик
+	PickSubtopic func(topicID int) (int, int, bool)
+	OnMatch      func(match Match)
+	OnTimeout    func(ticket Ticket)
+}
+
+func NewQueue() *Queue {
+	return &Queue{tickets: make([]*Ticket, 0)}
+}
+
+func (q *Queue) Join(ticket Ticket) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, t := range q.tickets {
+		if t.Username == ticket.Username {
+			return ErrAlreadyQueued
+		}
+	}
+
+	if ticket.JoinedAt.IsZero() {
+		ticket.JoinedAt = time.Now()
+	}
+	q.tickets = append(q.tickets, &ticket)
+	logger.Log.Tracef("%s joined matchmaking (topic %d, subtopic %d, skill %.2f)",
+		ticket.Username, ticket.TopicID, ticket.SubtopicID, ticket.Skill)
+	return nil
+}
+
+// Cancel убирает пользователя из очереди, возвращает false, если его там не было
+func (q *Queue) Cancel(username string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, t := range q.tickets {
+		if t.Username == username {
+			q.tickets = append(q.tickets[:i], q.tickets[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Status возвращает заявку пользователя, если он в очереди
+func (q *Queue) Status(username string) (Ticket, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, t := range q.tickets {
+		if t.Username == username {
+			return *t, true
+		}
+	}
+	return Ticket{}, false
+}
+
+// Run вечно составляет пары с интервалом TickInterval
+func (q *Queue) Run() {
+	ticker := time.NewTicker(TickInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		matches, expired := q.tick(now)
+		for _, ticket := range expired {
+			logger.Log.Tracef("%s left matchmaking by timeout", ticket.Username)
+			if q.OnTimeout != nil {
+				q.OnTimeout(ticket)
+			}
+		}
+		for _, match := range matches {
+			logger.Log.Tracef("Matched %s and %s on subtopic %d", match.First.Username, match.Second.Username, match.SubtopicID)
+			if q.OnMatch != nil {
+				q.OnMatch(match)
+			}
+		}
+	}
+}
+
+// tick убирает просроченные заявки и жадно составляет пары: дольше всех ждущий
+// получает ближайшего по навыку соперника в пределах своего допуска
+func (q *Queue) tick(now time.Time) ([]Match, []Ticket) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var expired []Ticket
+	waiting := make([]*Ticket, 0, len(q.tickets))
+	for _, t := range q.tickets {
+		if now.Sub(t.JoinedAt) >= Timeout {
+			expired = append(expired, *t)
+			continue
+		}
+		waiting = append(waiting, t)
+	}
+
+	sort.SliceStable(waiting, func(i, j int) bool {
+		return waiting[i].JoinedAt.Before(waiting[j].JoinedAt)
+	})
+
+	var matches []Match
+	matched := make(map[*Ticket]bool)
+	for i, first := range waiting {
+		if matched[first] {
+			continue
+		}
+
+		gap := SkillGap(now.Sub(first.JoinedAt))
+		var best *Ticket
+		var bestSubtopic, bestTopic int
+		bestDiff := math.Inf(1)
+
+		for _, second := range waiting[i+1:] {
+			if matched[second] {
+				continue
+			}
+			diff := math.Abs(first.Skill - second.Skill)
+			if diff > gap || diff >= bestDiff {
+				continue
+			}
+			topicID, subtopicID, ok := q.commonSubtopic(first, second)
+			if !ok {
+				continue
+			}
+			best, bestDiff, bestTopic, bestSubtopic = second, diff, topicID, subtopicID
+		}
+
+		if best == nil {
+			continue
+		}
+		matched[first], matched[best] = true, true
+		matches = append(matches, Match{
+			First:      *first,
+			Second:     *best,
+			TopicID:    bestTopic,
+			SubtopicID: bestSubtopic,
+		})
+	}
+
+	q.tickets = q.tickets[:0]
+	for _, t := range waiting {
+		if !matched[t] {
+			q.tickets = append(q.tickets, t)
+		}
+	}
+
+	return matches, expired
+}
+
+// commonSubtopic подбирает сабтопик, устраивающий обе заявки
+func (q *Queue) commonSubtopic(a, b *Ticket) (int, int, bool) {
+	switch {
+	case a.SubtopicID != 0 && b.SubtopicID != 0:
+		if a.SubtopicID != b.SubtopicID {
+			return 0, 0, false
+		}
+		return a.TopicID, a.SubtopicID, true
+	case a.SubtopicID != 0:
+		if b.TopicID != 0 && b.TopicID != a.TopicID {
+			return 0, 0, false
+		}
+		return a.TopicID, a.SubtopicID, true
+	case b.SubtopicID != 0:
+		return q.commonSubtopic(b, a)
+	}
+
+	if a.TopicID != 0 && b.TopicID != 0 && a.TopicID != b.TopicID {
+		return 0, 0, false
+	}
+	topicID := a.TopicID
+	if topicID == 0 {
+		topicID = b.TopicID
+	}
+	if q.PickSubtopic == nil {
+		return 0, 0, false
+	}
+	return q.PickSubtopic(topicID)
+}
+
+// SkillGap допустимая разница навыка после ожидания waited
+func SkillGap(waited time.Duration) float64 {
+	gap := baseSkillGap + skillGapStep*float64(waited/skillGapEvery)
+	return math.Min(gap, maxSkillGap)
+}
@@ -0,0 +1,103 @@
+package blitz
+
+import (
+	"awesomeChat/internal/informing"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"database/sql"
+	"math"
+	"time"
+)
+
+// за сколько до конца дискуссии зрители голосуют повторно
+const audienceAfterPollWindow = time.Minute
+
+// openAudiencePoll открывает опрос зрителей, если у комнаты есть пара тезисов для голосования
+func openAudiencePoll(room *structures.Room, stage string) bool {
+	theses := Theses(room.SubtopicID)
+	if len(theses) < 2 {
+		return false
+	}
+
+	room.AudiencePoll = stage
+	informing.SendAudiencePoll(room, theses)
+	return true
+}
+
+// scheduleAudiencePoll открывает повторное голосование за audienceAfterPollWindow до конца дискуссии:
+// итог подводится в End, поэтому голосовать после discussion_end уже не нужно. Вызывается под room.Mu
+func scheduleAudiencePoll(room *structures.Room) {
+	time.AfterFunc(room.Duration-audienceAfterPollWindow, func() {
+		room.Mu.Lock()
+		defer room.Mu.Unlock()
+		if room.DiscussionActive && room.AudiencePoll == "" {
+			openAudiencePoll(room, structures.AudiencePollAfter)
+		}
+	})
+}
+
+// finishAudienceVote закрывает повторное голосование, подводит итог, сохраняет его в архив и объявляет
+func finishAudienceVote(db *sql.DB, room *structures.Room) {
+	room.Mu.Lock()
+	if room.AudiencePoll != structures.AudiencePollAfter {
+		room.Mu.Unlock()
+		return
+	}
+	room.AudiencePoll = ""
+	result := tallyAudienceVotes(Theses(room.SubtopicID), room.AudienceBefore, room.AudienceAfter)
+	room.Mu.Unlock()
+
+	if room.DiscussionID > 0 {
+		storage.SaveAudienceResult(db, room.DiscussionID, result)
+	}
+	informing.SendAudienceResult(room, result)
+}
+
+// tallyAudienceVotes считает доли каждого тезиса до и после дискуссии.
+// Побеждает тезис с наибольшим приростом доли, при равенстве победителя нет
+func tallyAudienceVotes(theses []string, before, after map[string]int) structures.AudienceResult {
+	result := structures.AudienceResult{
+		Theses:      theses,
+		Before:      make([]int, len(theses)),
+		After:       make([]int, len(theses)),
+		BeforeShare: make([]float64, len(theses)),
+		AfterShare:  make([]float64, len(theses)),
+		ShareChange: make([]float64, len(theses)),
+		Winner:      -1,
+	}
+
+	for _, thesis := range before {
+		result.Before[thesis]++
+	}
+	for _, thesis := range after {
+		result.After[thesis]++
+	}
+
+	bestChange := math.Inf(-1)
+	for i := range theses {
+		if len(before) > 0 {
+			result.BeforeShare[i] = float64(result.Before[i]) / float64(len(before))
+		}
+		if len(after) > 0 {
+			result.AfterShare[i] = float64(result.After[i]) / float64(len(after))
+		}
+		result.ShareChange[i] = result.AfterShare[i] - result.BeforeShare[i]
+
+		switch {
+		case result.ShareChange[i] > bestChange:
+			bestChange = result.ShareChange[i]
+			result.Winner = i
+		case result.ShareChange[i] == bestChange:
+			result.Winner = -1
+		}
+	}
+
+	if len(before) == 0 || len(after) == 0 {
+		result.Winner = -1
+	}
+	if result.Winner >= 0 {
+		result.WinnerThesis = theses[result.Winner]
+	}
+
+	return result
+}
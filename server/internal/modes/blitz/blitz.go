@@ -0,0 +1,99 @@
+package blitz
+
+import (
+	"awesomeChat/internal/catalog"
+	"awesomeChat/internal/informing"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+const Duration = 10 * time.Minute
+
+// Blitz личная дискуссия на заданную тему, где каждой стороне достается свой тезис
+type Blitz struct {
+	modes.Base
+}
+
+func init() {
+	modes.Register(Blitz{})
+}
+
+func (Blitz) Name() string    { return "personal" }
+func (Blitz) SubType() string { return "blitz" }
+
+func (Blitz) Configure(room *structures.Room, req structures.CreateRoomRequest) error {
+	topicID, ok := catalog.TopicOf(req.Subtopic)
+	if !ok || len(catalog.Theses(req.Subtopic)) < 2 {
+		return errors.New("Unknown subtopic")
+	}
+	// Start делит участников по тезисам по кругу: команды равны, только если мест поровну на каждый тезис
+	if sides := len(catalog.Theses(req.Subtopic)); room.MaxUsers < sides || room.MaxUsers%sides != 0 {
+		return fmt.Errorf("Blitz on this subtopic needs a multiple of %d participants to split them evenly between theses", sides)
+	}
+
+	room.TopicID = topicID
+	room.SubtopicID = req.Subtopic
+	room.Duration = Duration
+	room.AudiencePoll = structures.AudiencePollBefore
+	return nil
+}
+
+func (Blitz) Sides(room *structures.Room) int {
+	return len(catalog.Theses(room.SubtopicID))
+}
+
+// Start перемешивает тезисы и по кругу делит участников на команды, по одной на тезис
+func (Blitz) Start(room *structures.Room) error {
+	theses := Theses(room.SubtopicID)
+	if len(theses) < 2 {
+		return fmt.Errorf("no theses found for subtopic %d", room.SubtopicID)
+	}
+
+	// перемешиваем тезисы для случайного распределения
+	rand.Shuffle(len(theses), func(i, j int) {
+		theses[i], theses[j] = theses[j], theses[i]
+	})
+
+	logger.Log.Tracef("Theses: %v", theses)
+
+	room.AssignedTheses = theses
+	room.UserTheses = make(map[string]string)
+	room.UserTeams = make(map[string]int)
+
+	order := rand.Perm(len(room.Users))
+	for i, idx := range order {
+		user := room.Users[idx]
+		team := i % len(theses)
+		room.UserTeams[user.Name] = team
+		room.UserTheses[user.Name] = theses[team]
+	}
+
+	// голосование зрителей до начала закрывается вместе со стартом, повторное откроется перед концом
+	room.AudiencePoll = ""
+	scheduleAudiencePoll(room)
+	return nil
+}
+
+func (Blitz) Announce(room *structures.Room) {
+	informing.SendBlitzStart(room)
+}
+
+// End закрывает повторное голосование зрителей и сразу объявляет итог, пока все еще на экране окончания
+func (Blitz) End(db *sql.DB, room *structures.Room) {
+	finishAudienceVote(db, room)
+}
+
+func (Blitz) TopicNames(topicID, subtopicID int, customTopic, customSubtopic string) (string, string) {
+	return catalog.TopicName(topicID), catalog.SubtopicName(subtopicID)
+}
+
+// Theses тезисы сабтопика; каталог отдает копию, поэтому их можно перемешивать
+func Theses(subtopicID int) []string {
+	return catalog.Theses(subtopicID)
+}
@@ -0,0 +1,29 @@
+package free
+
+import (
+	"awesomeChat/internal/informing"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/structures"
+)
+
+// Free личная дискуссия на произвольную тему, заданную создателем комнаты
+type Free struct {
+	modes.Base
+}
+
+func init() {
+	modes.Register(Free{})
+}
+
+func (Free) Name() string    { return "personal" }
+func (Free) SubType() string { return "free" }
+
+func (Free) Configure(room *structures.Room, req structures.CreateRoomRequest) error {
+	room.CustomTopic = req.CustomTopic
+	room.CustomSubtopic = req.CustomSubtopic
+	return nil
+}
+
+func (Free) Announce(room *structures.Room) {
+	informing.SendFreeStart(room)
+}
@@ -0,0 +1,68 @@
+package modes
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/structures"
+	"database/sql"
+)
+
+// DefaultCriteria критерии итоговой оценки собеседников
+var DefaultCriteria = []string{"professionalism", "arguments_quality", "politeness"}
+
+// Mode формат дискуссии. Реализации живут в собственных пакетах и регистрируются через Register в init()
+type Mode interface {
+	// Name и SubType — значения Room.Mode и Room.SubType; пустой SubType подходит к любому подтипу
+	Name() string
+	SubType() string
+
+	// Configure проверяет параметры создания и заполняет специфичные для формата поля комнаты
+	Configure(room *structures.Room, req structures.CreateRoomRequest) error
+	// Sides количество сторон, между которыми делятся участники; 0 — сторон нет
+	Sides(room *structures.Room) int
+
+	// Start готовит комнату к старту (вызывается под room.Mu), Announce рассылает стартовые сообщения
+	Start(room *structures.Room) error
+	Announce(room *structures.Room)
+
+	// CanPost проверяет правила формата для сообщения пользователя (вызывается под room.Mu)
+	CanPost(room *structures.Room, username string) (bool, i18n.Text)
+
+	// End вызывается после сохранения дискуссии в архив
+	End(db *sql.DB, room *structures.Room)
+
+	RatingCriteria() []string
+	LeaderboardEligible() bool
+
+	// CapturesOutcomes можно ли фиксировать решения и поручения по ходу дискуссии
+	CapturesOutcomes() bool
+
+	// TopicNames возвращает названия темы и подтемы для архива
+	TopicNames(topicID, subtopicID int, customTopic, customSubtopic string) (string, string)
+}
+
+// Base реализация по умолчанию, которую форматы встраивают и переопределяют нужное
+type Base struct{}
+
+func (Base) Configure(room *structures.Room, req structures.CreateRoomRequest) error { return nil }
+
+func (Base) Sides(room *structures.Room) int { return 0 }
+
+func (Base) Start(room *structures.Room) error { return nil }
+
+func (Base) Announce(room *structures.Room) {}
+
+func (Base) CanPost(room *structures.Room, username string) (bool, i18n.Text) {
+	return true, i18n.Text{}
+}
+
+func (Base) End(db *sql.DB, room *structures.Room) {}
+
+func (Base) RatingCriteria() []string { return DefaultCriteria }
+
+func (Base) LeaderboardEligible() bool { return true }
+
+func (Base) CapturesOutcomes() bool { return false }
+
+func (Base) TopicNames(topicID, subtopicID int, customTopic, customSubtopic string) (string, string) {
+	return customTopic, customSubtopic
+}
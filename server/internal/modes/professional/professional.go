@@ -0,0 +1,36 @@
+package professional
+
+import (
+	"awesomeChat/internal/informing"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"database/sql"
+)
+
+// Professional рабочая дискуссия с целью и ключевыми вопросами; в лидерборде не учитывается
+type Professional struct {
+	modes.Base
+}
+
+func init() {
+	modes.Register(Professional{})
+}
+
+func (Professional) Name() string    { return "professional" }
+func (Professional) SubType() string { return "" }
+
+func (Professional) Announce(room *structures.Room) {
+	informing.SendProfessionalStart(room)
+}
+
+func (Professional) LeaderboardEligible() bool { return false }
+
+func (Professional) CapturesOutcomes() bool { return true }
+
+// End сохраняет решения и поручения встречи: у дискуссии уже есть id в архиве
+func (Professional) End(db *sql.DB, room *structures.Room) {
+	if room.DiscussionID > 0 {
+		storage.SaveOutcomes(db, room.DiscussionID, room.Outcomes)
+	}
+}
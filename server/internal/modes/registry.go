@@ -0,0 +1,75 @@
+package modes
+
+import (
+	"awesomeChat/internal/structures"
+	"sort"
+	"sync"
+)
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Mode)
+)
+
+// wildcard подтип, которым регистрируются форматы без подтипов
+const wildcard = "*"
+
+func key(name, subType string) string {
+	if subType == "" {
+		subType = wildcard
+	}
+	return name + "/" + subType
+}
+
+// Register добавляет формат в реестр. Повторная регистрация того же формата — ошибка программиста
+func Register(mode Mode) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	k := key(mode.Name(), mode.SubType())
+	if _, dup := registry[k]; dup {
+		panic("modes: Register called twice for mode " + k)
+	}
+	registry[k] = mode
+}
+
+// Get ищет формат по точному подтипу, затем формат без подтипов
+func Get(name, subType string) (Mode, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if mode, ok := registry[key(name, subType)]; ok {
+		return mode, true
+	}
+	mode, ok := registry[key(name, "")]
+	return mode, ok
+}
+
+// ForRoom формат комнаты; для незарегистрированных значений — свободный чат без особых правил
+func ForRoom(room *structures.Room) Mode {
+	if mode, ok := Get(room.Mode, room.SubType); ok {
+		return mode
+	}
+	return fallback{}
+}
+
+// LeaderboardKeys ключи "mode/subtype" форматов, учитываемых в лидерборде и профиле.
+// В SQL сравниваются с mode || '/' || subtype и mode || '/*'
+func LeaderboardKeys() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	keys := make([]string, 0, len(registry))
+	for k, mode := range registry {
+		if mode.LeaderboardEligible() {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type fallback struct{ Base }
+
+func (fallback) Name() string    { return "" }
+func (fallback) SubType() string { return "" }
@@ -0,0 +1,87 @@
+package myws
+
+import (
+	"awesomeChat/internal/informing"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"github.com/gorilla/websocket"
+	"time"
+)
+
+// handleAgendaCommand переключает ключевой вопрос; управлять повесткой может только создатель комнаты,
+// подключенный участником или зрителем. Имя берется из соединения, а не из команды
+func handleAgendaCommand(room *structures.Room, conn *websocket.Conn, cmd structures.AgendaCommand) {
+	room.Mu.Lock()
+	author := findUser(room, conn)
+	if author == nil {
+		author = findSpectator(room, conn)
+	}
+	if author == nil || author.Name != room.CreatorUsername || !room.DiscussionActive || len(room.KeyQuestions) == 0 {
+		room.Mu.Unlock()
+		if author != nil {
+			logger.Log.Warnf("Agenda command from %s in room %d ignored", author.Name, room.ID)
+		}
+		return
+	}
+
+	index := cmd.Index
+	if cmd.Type == "agenda_next" {
+		index = room.AgendaIndex + 1
+		if index >= len(room.KeyQuestions) {
+			index = -1
+		}
+	}
+	if index < -1 || index >= len(room.KeyQuestions) || index == room.AgendaIndex {
+		room.Mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	closeAgendaItem(room, now)
+	room.AgendaIndex = index
+	room.AgendaRun++
+	run := room.AgendaRun
+
+	var endsAt *time.Time
+	var timeBox time.Duration
+	if index >= 0 {
+		room.AgendaLog = append(room.AgendaLog, structures.AgendaBoundary{
+			Index:    index,
+			Question: room.KeyQuestions[index],
+			Start:    now,
+		})
+		if index < len(room.AgendaTimeBoxes) && room.AgendaTimeBoxes[index] > 0 {
+			timeBox = time.Duration(room.AgendaTimeBoxes[index]) * time.Second
+			end := now.Add(timeBox)
+			endsAt = &end
+		}
+	}
+	room.Mu.Unlock()
+
+	logger.Log.Tracef("Room %d: agenda item %d", room.ID, index)
+	informing.SendAgendaUpdate(room, index, endsAt)
+
+	if timeBox > 0 {
+		go agendaTimeBox(room, index, run, timeBox)
+	}
+}
+
+// agendaTimeBox напоминает, что время на вопрос вышло; к следующему вопросу ведущий переходит сам
+func agendaTimeBox(room *structures.Room, index int, run int, timeBox time.Duration) {
+	time.Sleep(timeBox)
+
+	room.Mu.Lock()
+	current := room.DiscussionActive && room.AgendaRun == run
+	room.Mu.Unlock()
+
+	if current {
+		informing.SendAgendaTimeBoxOver(room, index)
+	}
+}
+
+// closeAgendaItem завершает отрезок активного вопроса. Вызывается под room.Mu
+func closeAgendaItem(room *structures.Room, now time.Time) {
+	if last := len(room.AgendaLog) - 1; last >= 0 && room.AgendaLog[last].End.IsZero() {
+		room.AgendaLog[last].End = now
+	}
+}
@@ -0,0 +1,87 @@
+package myws
+
+import (
+	"awesomeChat/internal/catalog"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+)
+
+// SpectatorReader слушает зрителя: из всех сообщений принимаются только голоса в опросе аудитории
+// и команды повестки от ведущего
+func SpectatorReader(conn *websocket.Conn, room *structures.Room) {
+	defer func() {
+		room.Mu.Lock()
+		for i, spectator := range room.Spectators {
+			if spectator.Connection == conn {
+				room.Spectators = append(room.Spectators[:i], room.Spectators[i+1:]...)
+				break
+			}
+		}
+		room.Mu.Unlock()
+		logger.Log.Traceln(fmt.Sprintf("Current amount of spectators in room %d: %d", room.ID, len(room.Spectators)))
+	}()
+
+	for {
+		_, p, err := conn.ReadMessage()
+		if err != nil {
+			logger.Log.Traceln("ReadMessage error: " + err.Error())
+			return
+		}
+
+		var msg structures.AudienceVoteMessage
+		if err = json.Unmarshal(p, &msg); err != nil {
+			logger.Log.Traceln("Unmarshal message error: " + err.Error())
+			return
+		}
+
+		switch msg.Type {
+		case "audience_vote":
+			handleAudienceVote(room, conn, msg)
+		case "agenda_set", "agenda_next":
+			// ведущий может вести повестку, не участвуя в споре (dontJoin)
+			var cmd structures.AgendaCommand
+			if err = json.Unmarshal(p, &cmd); err == nil {
+				handleAgendaCommand(room, conn, cmd)
+			}
+		}
+	}
+}
+
+// handleAudienceVote засчитывает голос зрителя под именем, с которым он подключился;
+// имя из сообщения не используется, иначе один зритель мог бы голосовать за многих
+func handleAudienceVote(room *structures.Room, conn *websocket.Conn, msg structures.AudienceVoteMessage) {
+	room.Mu.Lock()
+	defer room.Mu.Unlock()
+
+	spectator := findSpectator(room, conn)
+	if spectator == nil || spectator.Name == "" {
+		return
+	}
+
+	if msg.Thesis < 0 || msg.Thesis >= len(catalog.Theses(room.SubtopicID)) {
+		logger.Log.Warnf("Invalid audience vote %d from %s", msg.Thesis, spectator.Name)
+		return
+	}
+
+	switch room.AudiencePoll {
+	case structures.AudiencePollBefore:
+		room.AudienceBefore[spectator.Name] = msg.Thesis
+	case structures.AudiencePollAfter:
+		room.AudienceAfter[spectator.Name] = msg.Thesis
+	default:
+		logger.Log.Warnf("Audience poll in room %d is closed, vote from %s ignored", room.ID, spectator.Name)
+	}
+}
+
+// findSpectator зритель комнаты по его соединению; вызывается под room.Mu
+func findSpectator(room *structures.Room, conn *websocket.Conn) *structures.ChatUser {
+	for _, spectator := range room.Spectators {
+		if spectator.Connection == conn {
+			return spectator
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package myws
+
+import (
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"sync"
+)
+
+// journal очередь записей комнаты в базу: сообщения и голоса ставятся в нее под room.Mu в том же
+// порядке, в каком меняют комнату, а пишет их одна горутина уже без блокировки. Так медленная база
+// не задерживает чат, а голос не опережает сообщение, за которое отдан
+type journal struct {
+	pending []func() error // под journalsMu
+	closed  bool           // под journalsMu; новых записей не будет
+	wake    chan struct{}
+	done    chan struct{}
+}
+
+var (
+	journalsMu sync.Mutex
+	journals   = make(map[*structures.Room]*journal)
+)
+
+// enqueue ставит запись в журнал комнаты; журнал и его горутина создаются при первой записи
+func enqueue(room *structures.Room, write func() error) {
+	journalsMu.Lock()
+	j, ok := journals[room]
+	if !ok {
+		j = &journal{wake: make(chan struct{}, 1), done: make(chan struct{})}
+		journals[room] = j
+		go j.run()
+	}
+	j.pending = append(j.pending, write)
+	journalsMu.Unlock()
+	j.signal()
+}
+
+// flushJournal дожидается всех поставленных записей комнаты. Вызывается без room.Mu перед тем,
+// как история комнаты сохраняется целиком или удаляется; следующие записи начнут новый журнал
+func flushJournal(room *structures.Room) {
+	journalsMu.Lock()
+	j, ok := journals[room]
+	if ok {
+		delete(journals, room)
+		j.closed = true
+	}
+	journalsMu.Unlock()
+	if !ok {
+		return
+	}
+	j.signal()
+	<-j.done
+}
+
+func (j *journal) signal() {
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (j *journal) run() {
+	defer close(j.done)
+	for range j.wake {
+		for {
+			journalsMu.Lock()
+			writes, closed := j.pending, j.closed
+			j.pending = nil
+			journalsMu.Unlock()
+
+			if len(writes) == 0 {
+				if closed {
+					return
+				}
+				break
+			}
+			for _, write := range writes {
+				if err := write(); err != nil {
+					logger.Log.Errorln("Save room history error:", err)
+				}
+			}
+		}
+	}
+}
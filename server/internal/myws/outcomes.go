@@ -0,0 +1,95 @@
+package myws
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/informing"
+	"awesomeChat/internal/modes"
+	"awesomeChat/internal/structures"
+	"github.com/gorilla/websocket"
+	"strings"
+	"time"
+)
+
+// handleOutcomeCommand фиксирует решение или поручение от участника дискуссии
+func handleOutcomeCommand(room *structures.Room, conn *websocket.Conn, cmd structures.OutcomeCommand) {
+	room.Mu.Lock()
+	author := findUser(room, conn)
+	if author == nil {
+		room.Mu.Unlock()
+		return
+	}
+
+	outcome, reason := buildOutcome(room, author.Name, cmd)
+	if reason.Key != "" {
+		room.Mu.Unlock()
+		informing.SendOutcomeRejected(author, reason)
+		return
+	}
+	room.Outcomes = append(room.Outcomes, outcome)
+	room.Mu.Unlock()
+
+	informing.SendOutcomeAdded(room, outcome)
+}
+
+// buildOutcome проверяет команду и собирает из нее решение или поручение. Вызывается под room.Mu
+func buildOutcome(room *structures.Room, author string, cmd structures.OutcomeCommand) (structures.Outcome, i18n.Text) {
+	if !room.DiscussionActive || !modes.ForRoom(room).CapturesOutcomes() {
+		return structures.Outcome{}, i18n.New("outcome_not_supported", nil)
+	}
+
+	outcome := structures.Outcome{
+		Kind:      structures.OutcomeDecision,
+		Content:   strings.TrimSpace(cmd.Content),
+		Author:    author,
+		CreatedAt: time.Now(),
+	}
+	if room.AgendaIndex >= 0 {
+		item := room.AgendaIndex
+		outcome.AgendaItem = &item
+	}
+
+	switch cmd.Type {
+	case "outcome_mark_decision":
+		var marked *structures.Message
+		for i := range room.Messages {
+			if room.Messages[i].ID == cmd.MessageID {
+				marked = &room.Messages[i]
+				break
+			}
+		}
+		if marked == nil {
+			return outcome, i18n.New("outcome_message_not_found", nil)
+		}
+		outcome.MessageID = marked.ID
+		outcome.AgendaItem = marked.AgendaItem
+		if outcome.Content == "" {
+			outcome.Content = marked.Content
+		}
+	case "outcome_action":
+		outcome.Kind = structures.OutcomeActionItem
+		if !isParticipant(room, cmd.Assignee) {
+			return outcome, i18n.New("outcome_bad_assignee", nil)
+		}
+		outcome.Assignee = cmd.Assignee
+		if cmd.DueDate != "" {
+			if _, err := time.Parse("2006-01-02", cmd.DueDate); err != nil {
+				return outcome, i18n.New("outcome_bad_due_date", nil)
+			}
+			outcome.DueDate = cmd.DueDate
+		}
+	}
+
+	if outcome.Content == "" {
+		return outcome, i18n.New("outcome_empty", nil)
+	}
+	return outcome, i18n.Text{}
+}
+
+func isParticipant(room *structures.Room, username string) bool {
+	for _, participant := range room.Participants {
+		if participant == username {
+			return true
+		}
+	}
+	return false
+}
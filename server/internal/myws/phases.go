@@ -0,0 +1,76 @@
+package myws
+
+import (
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/informing"
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"time"
+)
+
+// runPhases по очереди переключает этапы структурированной дискуссии и объявляет каждый переход
+func runPhases(room *structures.Room) {
+	for i, phase := range room.Phases {
+		start := time.Now()
+		duration := time.Duration(phase.Duration) * time.Second
+
+		room.Mu.Lock()
+		room.PhaseIndex = i
+		room.PhaseMessages = make(map[string]int)
+		room.PhaseLog = append(room.PhaseLog, structures.PhaseBoundary{
+			Index: i,
+			Name:  phase.Name,
+			Title: phase.Title,
+			Start: start,
+		})
+		room.Mu.Unlock()
+
+		logger.Log.Tracef("Room %d: phase %d (%s) started", room.ID, i, phase.Name)
+		informing.SendPhaseChange(room, i, start.Add(duration))
+
+		time.Sleep(duration)
+
+		room.Mu.Lock()
+		if len(room.PhaseLog) > i {
+			room.PhaseLog[i].End = time.Now()
+		}
+		room.Mu.Unlock()
+	}
+
+	room.Mu.Lock()
+	room.PhaseIndex = len(room.Phases)
+	room.Mu.Unlock()
+}
+
+// checkPhaseRules проверяет, может ли пользователь писать в текущем этапе, и возвращает причину отказа.
+// Вызывается под room.Mu
+func checkPhaseRules(room *structures.Room, username string) (bool, i18n.Text) {
+	if len(room.Phases) == 0 || !room.DiscussionActive {
+		return true, i18n.Text{}
+	}
+	if room.PhaseIndex >= len(room.Phases) {
+		return false, i18n.New("phase_all_finished", nil)
+	}
+
+	phase := room.Phases[room.PhaseIndex]
+
+	if len(phase.Sides) > 0 {
+		team, ok := room.UserTeams[username]
+		allowed := false
+		for _, side := range phase.Sides {
+			if ok && side == team {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false, i18n.New("phase_not_your_side", i18n.Params{"title": phase.Title})
+		}
+	}
+
+	if phase.MessagesPerSpeaker > 0 && room.PhaseMessages[username] >= phase.MessagesPerSpeaker {
+		return false, i18n.New("phase_limit_reached", i18n.Params{"title": phase.Title, "limit": phase.MessagesPerSpeaker})
+	}
+
+	return true, i18n.Text{}
+}
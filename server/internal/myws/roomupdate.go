@@ -9,7 +9,7 @@ import (
 )
 
 // HandleConnections апгрейдит соединение до вебсокета при заходе пользователя в список чатрумов, чтобы динамически показывать открытые
-func (server *WebSocketServer) HandleConnections(w http.ResponseWriter, r *http.Request, rooms *map[int]*structures.Room) {
+func (server *WebSocketServer) HandleConnections(w http.ResponseWriter, r *http.Request, rooms *structures.Rooms) {
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		http.Error(w, "Failed to upgrade connection", http.StatusInternalServerError)
@@ -22,17 +22,28 @@ func (server *WebSocketServer) HandleConnections(w http.ResponseWriter, r *http.
 		}
 	}(ws)
 
+	// по имени пользователю доставляются личные уведомления, в том числе пароли найденных матчей,
+	// и комнаты его организаций, поэтому имя берется из токена, а не из ?username=.
+	// Анонимный клиент видит только общие комнаты
+	client := &lobbyClient{}
+	if server.Authenticate != nil {
+		client.username = server.Authenticate(r)
+	}
+	if server.Memberships != nil && client.username != "" {
+		client.organizations = server.Memberships(client.username)
+	}
+
 	server.mu.Lock()
-	roomsToSend := structures.MakeRoomList(rooms)
-	server.clients[ws] = struct{}{}
+	roomsToSend := structures.VisibleRooms(*structures.MakeRoomList(rooms), client.organizations)
+	server.clients[ws] = client
 
-	sort.Slice(*roomsToSend, func(i, j int) bool {
-		return (*roomsToSend)[i].ID < (*roomsToSend)[j].ID
+	sort.Slice(roomsToSend, func(i, j int) bool {
+		return roomsToSend[i].ID < roomsToSend[j].ID
 	})
 
 	// отправить текущий список комнат новому клиенту
-	logger.Log.Traceln("To new client: ", *roomsToSend)
-	if err = ws.WriteJSON(*roomsToSend); err != nil {
+	logger.Log.Traceln("To new client: ", roomsToSend)
+	if err = ws.WriteJSON(roomsToSend); err != nil {
 		delete(server.clients, ws)
 		err = ws.Close()
 		if err != nil {
@@ -58,8 +69,8 @@ func (server *WebSocketServer) HandleMessages() {
 		rooms := <-server.Broadcast
 
 		server.mu.Lock()
-		for client := range server.clients {
-			err := client.WriteJSON(rooms)
+		for client, info := range server.clients {
+			err := client.WriteJSON(structures.VisibleRooms(rooms, info.organizations))
 			if err != nil {
 				err = client.Close()
 				if err != nil {
@@ -71,3 +82,26 @@ func (server *WebSocketServer) HandleMessages() {
 		server.mu.Unlock()
 	}
 }
+
+// SendToUser отправляет личное уведомление во все лобби-сокеты пользователя, возвращает false, если их нет
+func (server *WebSocketServer) SendToUser(username string, payload any) bool {
+	if username == "" {
+		return false
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	sent := false
+	for client, info := range server.clients {
+		if info.username != username {
+			continue
+		}
+		if err := client.WriteJSON(payload); err != nil {
+			logger.Log.Errorln(err)
+			continue
+		}
+		sent = true
+	}
+	return sent
+}
@@ -1,7 +1,9 @@
 package myws
 
 import (
+	"awesomeChat/internal/exports"
 	"awesomeChat/internal/informing"
+	"awesomeChat/internal/modes"
 	"awesomeChat/internal/storage"
 	"awesomeChat/internal/structures"
 	"awesomeChat/package/logger"
@@ -10,13 +12,12 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
-	"math/rand"
 	"net/http"
 	"sync"
 	"time"
 )
 
-func Reader(db *sql.DB, conn *websocket.Conn, room *structures.Room, rooms *map[int]*structures.Room) {
+func Reader(db *sql.DB, repos *storage.Repos, conn *websocket.Conn, room *structures.Room, rooms *structures.Rooms) {
 	var leftUser string
 	defer func() {
 		for i, user := range room.Users {
@@ -35,8 +36,15 @@ func Reader(db *sql.DB, conn *websocket.Conn, room *structures.Room, rooms *map[
 			go func() {
 				//todo если никого нет больше часа, то удаляем
 			}()
-			delete(*rooms, room.ID)
+			rooms.Remove(room)
 			logger.Log.Traceln(fmt.Sprintf("Deleting room %d", room.ID))
+			// идущую дискуссию сохранит таймер, остальное в архив уже не попадет
+			if !room.DiscussionActive || room.DiscussionID > 0 {
+				flushJournal(room)
+				if err := storage.DiscardSessionMessages(db, room.Session); err != nil {
+					logger.Log.Errorln("Discard messages error:", err)
+				}
+			}
 		}
 	}()
 
@@ -68,7 +76,6 @@ func Reader(db *sql.DB, conn *websocket.Conn, room *structures.Room, rooms *map[
 				ID:           uuid.New().String(),
 				Type:         "usual",
 				Content:      clientMsg.Content,
-				Username:     clientMsg.Username,
 				Timestamp:    time.Now(),
 				LikeCount:    0,
 				DislikeCount: 0,
@@ -77,16 +84,65 @@ func Reader(db *sql.DB, conn *websocket.Conn, room *structures.Room, rooms *map[
 			}
 
 			room.Mu.Lock()
+			// автор — участник, под чьим именем открыто соединение: имени из сообщения верить нельзя,
+			// иначе правила этапов и формата обходятся подстановкой чужого имени
+			sender := findUser(room, conn)
+			if sender == nil {
+				room.Mu.Unlock()
+				continue
+			}
+			finalMsg.Username = sender.Name
+			if clientMsg.ReplyTo != "" && hasUsualMessage(room, clientMsg.ReplyTo) {
+				finalMsg.ReplyTo = clientMsg.ReplyTo
+			}
+			ok, reason := checkPhaseRules(room, finalMsg.Username)
+			if ok && room.DiscussionActive {
+				ok, reason = modes.ForRoom(room).CanPost(room, finalMsg.Username)
+			}
+			if !ok {
+				room.Mu.Unlock()
+				informing.SendPostRejected(sender, finalMsg.TempID, reason)
+				continue
+			}
+			if room.PhaseMessages != nil {
+				room.PhaseMessages[finalMsg.Username]++
+			}
+			if room.AgendaIndex >= 0 {
+				item := room.AgendaIndex
+				finalMsg.AgendaItem = &item
+			}
 			room.Messages = append(room.Messages, finalMsg)
+			session, seq := room.Session, len(room.Messages)
+			enqueue(room, func() error { return storage.SaveMessage(db, session, seq, finalMsg) })
 			room.Mu.Unlock()
 
 			handleUsualMessage(room, conn, finalMsg)
 		case "ready_check":
-			handleReadyCheck(db, room, conn, msg.Username)
+			handleReadyCheck(db, repos, room, conn)
 		case "rate":
-			handleRating(room, p)
+			handleRating(db, room, conn, p)
+		case "outcome_decision", "outcome_action", "outcome_mark_decision":
+			var cmd structures.OutcomeCommand
+			if err = json.Unmarshal(p, &cmd); err == nil {
+				handleOutcomeCommand(room, conn, cmd)
+			}
+		case "agenda_set", "agenda_next":
+			var cmd structures.AgendaCommand
+			if err = json.Unmarshal(p, &cmd); err == nil {
+				handleAgendaCommand(room, conn, cmd)
+			}
+		}
+	}
+}
+
+// hasUsualMessage есть ли в истории комнаты сообщение участника с таким id; вызывается под room.Mu
+func hasUsualMessage(room *structures.Room, messageID string) bool {
+	for _, msg := range room.Messages {
+		if msg.ID == messageID && msg.Type == "usual" {
+			return true
 		}
 	}
+	return false
 }
 
 func handleUsualMessage(room *structures.Room, conn *websocket.Conn, msg structures.Message) {
@@ -103,25 +159,40 @@ func handleUsualMessage(room *structures.Room, conn *websocket.Conn, msg structu
 		}
 		//}
 	}
+	for _, spectator := range room.Spectators {
+		if err := spectator.Connection.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
+			logger.Log.Errorln(err)
+		}
+	}
 }
 
-func handleReadyCheck(db *sql.DB, room *structures.Room, conn *websocket.Conn, username string) {
-	if _, ready := room.ReadyUsers[username]; room.DiscussionActive || ready {
+// handleReadyCheck отмечает готовность участника, под чьим именем открыто соединение;
+// имени из сообщения не верим, иначе один игрок мог бы объявить готовыми всех
+func handleReadyCheck(db *sql.DB, repos *storage.Repos, room *structures.Room, conn *websocket.Conn) {
+	room.Mu.Lock()
+	user := findUser(room, conn)
+	if user == nil {
+		room.Mu.Unlock()
 		return
 	}
-	room.Mu.Lock()
-	room.ReadyUsers[username] = true
+	if _, ready := room.ReadyUsers[user.Name]; room.DiscussionActive || ready {
+		room.Mu.Unlock()
+		return
+	}
+	room.ReadyUsers[user.Name] = true
+	readyUsers := len(room.ReadyUsers)
 	room.Mu.Unlock()
 
-	informing.SendUserReady(room, username)
+	informing.SendUserReady(room, user.Name)
 
-	logger.Log.Tracef("Ready users: %d", len(room.ReadyUsers))
-	if len(room.ReadyUsers) == room.MaxUsers {
-		startDiscussion(db, room)
+	logger.Log.Tracef("Ready users: %d", readyUsers)
+	if readyUsers == room.MaxUsers {
+		startDiscussion(db, repos, room)
 	}
 }
 
-func handleRating(room *structures.Room, p []byte) {
+// handleRating засчитывает лайк или дизлайк участнику соединения, как и авторство сообщений
+func handleRating(db *sql.DB, room *structures.Room, conn *websocket.Conn, p []byte) {
 	var msg structures.RateMessage
 	err := json.Unmarshal(p, &msg)
 	if err != nil {
@@ -132,6 +203,12 @@ func handleRating(room *structures.Room, p []byte) {
 	room.Mu.Lock()
 	defer room.Mu.Unlock()
 
+	user := findUser(room, conn)
+	if user == nil {
+		return
+	}
+	voter := user.Name
+
 	var targetMsg *structures.Message
 	for i := range room.Messages {
 		if room.Messages[i].ID == msg.TargetMessageID {
@@ -145,12 +222,12 @@ func handleRating(room *structures.Room, p []byte) {
 		return
 	}
 
-	if targetMsg.Username == msg.Username {
-		logger.Log.Warnf("User %s tried to vote own message", msg.Username)
+	if targetMsg.Username == voter {
+		logger.Log.Warnf("User %s tried to vote own message", voter)
 		return
 	}
 
-	previousVote := targetMsg.Votes[msg.Username]
+	previousVote := targetMsg.Votes[voter]
 	newVote := msg.Vote
 
 	switch previousVote {
@@ -166,15 +243,17 @@ func handleRating(room *structures.Room, p []byte) {
 	case -1:
 		targetMsg.DislikeCount++
 	case 0:
-		delete(targetMsg.Votes, msg.Username)
+		delete(targetMsg.Votes, voter)
 	default:
 		logger.Log.Warnf("Invalid vote value: %d", newVote)
 		return
 	}
 
 	if newVote != 0 {
-		targetMsg.Votes[msg.Username] = newVote
+		targetMsg.Votes[voter] = newVote
 	}
+	messageID := targetMsg.ID
+	enqueue(room, func() error { return storage.SaveVote(db, messageID, voter, newVote) })
 
 	update := map[string]interface{}{
 		"type":         "vote_update",
@@ -191,9 +270,14 @@ func handleRating(room *structures.Room, p []byte) {
 			logger.Log.Errorln("Broadcast error:", err)
 		}
 	}
+	for _, spectator := range room.Spectators {
+		if err := spectator.Connection.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
+			logger.Log.Errorln("Broadcast error:", err)
+		}
+	}
 }
 
-func startDiscussion(db *sql.DB, room *structures.Room) {
+func startDiscussion(db *sql.DB, repos *storage.Repos, room *structures.Room) {
 	room.Mu.Lock()
 	defer room.Mu.Unlock()
 
@@ -203,49 +287,27 @@ func startDiscussion(db *sql.DB, room *structures.Room) {
 		room.Participants = append(room.Participants, user.Name)
 	}
 
-	if room.Mode == "personal" && room.SubType == "blitz" {
-		theses := getThesesForSubtopic(room.SubtopicID)
-		if len(theses) < 2 {
-			logger.Log.Errorf("No theses found for subtopic %d", room.SubtopicID)
-			return
-		}
-
-		// получаем тезисы для текущего сабтопика
-
-		// перемешиваем тезисы для случайного распределения
-		rand.Shuffle(len(theses), func(i, j int) {
-			theses[i], theses[j] = theses[j], theses[i]
-		})
-
-		logger.Log.Tracef("Theses: %v", theses)
-
-		room.AssignedTheses = theses
-		room.UserTheses = make(map[string]string)
-
-		for i, user := range room.Users {
-			if i >= room.MaxUsers {
-				break
-			}
-			room.UserTheses[user.Name] = theses[i]
-		}
+	mode := modes.ForRoom(room)
+	if err := mode.Start(room); err != nil {
+		logger.Log.Errorf("Can not start discussion %d: %v", room.ID, err)
+		return
 	}
 
 	// отправка сообщения о старте (+ для блитца темы и тезисов)
-	informing.SendDiscussionStart(room)
+	mode.Announce(room)
 
 	room.DiscussionActive = true
 	room.StartTime = time.Now()
 
 	// запуск таймера
-	go discussionTimer(db, room)
-}
-
-func getThesesForSubtopic(subtopicID int) []string {
-	return structures.ThesesDB[subtopicID]
+	go discussionTimer(db, repos, room)
+	if len(room.Phases) > 0 {
+		go runPhases(room)
+	}
 }
 
 // в логике таймера
-func discussionTimer(db *sql.DB, room *structures.Room) {
+func discussionTimer(db *sql.DB, repos *storage.Repos, room *structures.Room) {
 	var reminderInterval time.Duration
 
 	switch {
@@ -267,9 +329,16 @@ func discussionTimer(db *sql.DB, room *structures.Room) {
 		case <-ticker.C:
 			remaining := room.Duration - time.Since(room.StartTime)
 			if remaining <= 0 {
+				// сообщения, еще не дошедшие до базы, иначе допишутся после сохранения дискуссии
+				flushJournal(room)
 				room.DiscussionID = int(storage.SaveDiscussionHistory(db, room))
+				exports.Schedule(db, repos, room.DiscussionID, room.ExportOptions, func(artifacts []structures.ExportArtifact) {
+					informing.SendExportsReady(room, artifacts)
+				})
 
-				informing.SendDiscussionEnd(room)
+				mode := modes.ForRoom(room)
+				informing.SendDiscussionEnd(room, mode.RatingCriteria())
+				mode.End(db, room)
 				return
 			}
 			informing.SendTimerUpdate(room, remaining)
@@ -284,14 +353,36 @@ var upgrader = websocket.Upgrader{
 }
 
 type WebSocketServer struct {
-	clients   map[*websocket.Conn]any
+	clients   map[*websocket.Conn]*lobbyClient
 	Broadcast chan []structures.RoomForList
 	mu        sync.Mutex
+
+	// Memberships организации пользователя: по ним в лобби показываются комнаты организаций
+	Memberships func(username string) []int
+	// Authenticate имя пользователя по токену запроса или "" для анонимного подключения
+	Authenticate func(r *http.Request) string
+}
+
+// lobbyClient подключение к лобби. Организации запоминаются при подключении,
+// новое членство станет видно после переподключения
+type lobbyClient struct {
+	username      string
+	organizations []int
 }
 
 func NewWebSocketServer() *WebSocketServer {
 	return &WebSocketServer{
-		clients:   make(map[*websocket.Conn]any),
+		clients:   make(map[*websocket.Conn]*lobbyClient),
 		Broadcast: make(chan []structures.RoomForList),
 	}
 }
+
+// findUser участник комнаты по его соединению
+func findUser(room *structures.Room, conn *websocket.Conn) *structures.ChatUser {
+	for _, user := range room.Users {
+		if user.Connection == conn {
+			return user
+		}
+	}
+	return nil
+}
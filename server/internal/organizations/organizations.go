@@ -0,0 +1,64 @@
+package organizations
+
+import (
+	"database/sql"
+	"errors"
+)
+
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+var ErrNotMember = errors.New("user is not a member of the organization")
+
+// MemberOf id организаций, в которых состоит пользователь
+func MemberOf(db *sql.DB, username string) ([]int, error) {
+	rows, err := db.Query(`
+		SELECT m.organization_id
+		FROM organization_members m
+		JOIN users u ON u.user_id = m.user_id
+		WHERE u.username = $1`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Role роль пользователя в организации; ErrNotMember, если он в ней не состоит
+func Role(db *sql.DB, organizationID int, username string) (string, error) {
+	var role string
+	err := db.QueryRow(`
+		SELECT m.role
+		FROM organization_members m
+		JOIN users u ON u.user_id = m.user_id
+		WHERE m.organization_id = $1 AND u.username = $2`, organizationID, username).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotMember
+	}
+	return role, err
+}
+
+func IsMember(db *sql.DB, organizationID int, username string) bool {
+	_, err := Role(db, organizationID, username)
+	return err == nil
+}
+
+func IsAdmin(db *sql.DB, organizationID int, username string) bool {
+	role, err := Role(db, organizationID, username)
+	return err == nil && role == RoleAdmin
+}
+
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleMember
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+	"encoding/json"
+)
+
+// SaveAudienceResult дописывает итог голосования зрителей к уже сохраненной дискуссии
+func SaveAudienceResult(db *sql.DB, discussionID int, result structures.AudienceResult) {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		logger.Log.Errorln("Marshal error:", err)
+		return
+	}
+
+	_, err = db.Exec(`UPDATE discussions SET audience_vote = $1 WHERE id = $2`, resultJSON, discussionID)
+	if err != nil {
+		logger.Log.Errorln("Save audience vote error:", err)
+		return
+	}
+
+	logger.Log.Traceln("Audience vote saved for discussion", discussionID)
+}
@@ -5,13 +5,34 @@ import (
 	"awesomeChat/package/logger"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// SaveDiscussionHistory сохраняет дискуссию комнаты и возвращает ее id или -1 при ошибке
 func SaveDiscussionHistory(db *sql.DB, room *structures.Room) int64 {
 	room.Mu.Lock()
 	defer room.Mu.Unlock()
 
+	FinishRoom(room)
+	discussionID, err := NewDiscussionRepo(db).Save(room)
+	if err != nil {
+		logger.Log.Errorln("Save to DB error:", err)
+		return -1
+	}
+
+	logger.Log.Traceln("Discussion saved for room", room.ID, "with discussionID", discussionID)
+	room.Messages = nil
+
+	return int64(discussionID)
+}
+
+// FinishRoom готовит комнату к сохранению: голоса раскладываются по LikedBy и DislikedBy,
+// незакрытые этап и ключевой вопрос завершаются сейчас. Вызывающий держит room.Mu
+func FinishRoom(room *structures.Room) {
 	for i := range room.Messages {
 		msg := &room.Messages[i]
 		msg.LikedBy = []string{}
@@ -25,26 +46,209 @@ func SaveDiscussionHistory(db *sql.DB, room *structures.Room) int64 {
 		}
 	}
 
-	messagesJSON, err := json.Marshal(room.Messages)
+	for i := range room.PhaseLog {
+		if room.PhaseLog[i].End.IsZero() {
+			room.PhaseLog[i].End = time.Now()
+		}
+	}
+	if last := len(room.AgendaLog) - 1; last >= 0 && room.AgendaLog[last].End.IsZero() {
+		room.AgendaLog[last].End = time.Now()
+	}
+}
+
+type pgDiscussions struct {
+	db *sql.DB
+}
+
+func NewDiscussionRepo(db *sql.DB) DiscussionRepo {
+	return &pgDiscussions{db: db}
+}
+
+func (r *pgDiscussions) Get(id int) (*Discussion, error) {
+	var discussion Discussion
+	var keyQuestionsJSON []byte
+	var tagsJSON []byte
+	var exportOptionsJSON []byte
+	var participantsJSON []byte
+	var audienceVoteJSON []byte
+	var teamsJSON []byte
+	var phasesJSON []byte
+	var organizationID sql.NullInt64
+	var agendaJSON []byte
+
+	err := r.db.QueryRow(`
+		SELECT
+			id, room_id, mode, subtype,
+			duration, start_time, end_time,
+			creator_username,
+			key_questions, tags, export_options,
+			participants,
+			COALESCE(custom_topic, '') as topic,
+			COALESCE(custom_subtopic, '') as subtopic,
+			COALESCE(topic_id, 0), COALESCE(subtopic_id, 0),
+			description, purpose, room_name, public,
+			audience_vote, teams, phases, organization_id, agenda,
+			imported, COALESCE(import_source, '')
+		FROM discussions
+		WHERE id = $1
+	`, id).Scan(
+		&discussion.ID,
+		&discussion.RoomID,
+		&discussion.Mode,
+		&discussion.SubType,
+		&discussion.Duration,
+		&discussion.StartTime,
+		&discussion.EndTime,
+		&discussion.Creator,
+		&keyQuestionsJSON,
+		&tagsJSON,
+		&exportOptionsJSON,
+		&participantsJSON,
+		&discussion.Topic,
+		&discussion.Subtopic,
+		&discussion.TopicID,
+		&discussion.SubtopicID,
+		&discussion.Description,
+		&discussion.Purpose,
+		&discussion.RoomName,
+		&discussion.Public,
+		&audienceVoteJSON,
+		&teamsJSON,
+		&phasesJSON,
+		&organizationID,
+		&agendaJSON,
+		&discussion.Imported,
+		&discussion.ImportSource,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
-		logger.Log.Errorln("Marshal error:", err)
-		return -1
+		return nil, err
+	}
+	discussion.OrganizationID = int(organizationID.Int64)
+
+	for _, field := range []struct {
+		name   string
+		data   []byte
+		target any
+	}{
+		{"key questions", keyQuestionsJSON, &discussion.KeyQuestions},
+		{"tags", tagsJSON, &discussion.Tags},
+		{"export options", exportOptionsJSON, &discussion.ExportOptions},
+		{"participants", participantsJSON, &discussion.Participants},
+		{"teams", teamsJSON, &discussion.Teams},
+		{"phases", phasesJSON, &discussion.Phases},
+		{"agenda", agendaJSON, &discussion.AgendaLog},
+		{"audience vote", audienceVoteJSON, &discussion.AudienceVote},
+	} {
+		if field.data == nil {
+			continue
+		}
+		if err = json.Unmarshal(field.data, field.target); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", field.name, err)
+		}
+	}
+
+	return &discussion, nil
+}
+
+func (r *pgDiscussions) Messages(id int) ([]structures.Message, error) {
+	return LoadMessages(r.db, id)
+}
+
+func (r *pgDiscussions) List(filter ArchiveFilter) (Page[structures.ArchiveItem], error) {
+	if filter.Sort == "" {
+		filter.Sort = SortNewest
+	}
+	key, ok := archiveSortKeys[filter.Sort]
+	if !ok {
+		return Page[structures.ArchiveItem]{}, fmt.Errorf("unknown sort %q", filter.Sort)
+	}
+
+	var args []interface{}
+	where := archiveWhere(filter, &args)
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM discussions d `+where, args...).Scan(&total); err != nil {
+		return Page[structures.ArchiveItem]{}, err
 	}
 
+	after, order := keyset(key, filter.PageRequest, &args)
+	limit := limitOffset(filter.PageRequest, &args)
+	rows, err := r.db.Query(`
+        SELECT `+archiveColumns+`, (`+key.expr+`)::text
+        FROM discussions d
+        `+where+after+order+limit,
+		args...,
+	)
+	if err != nil {
+		return Page[structures.ArchiveItem]{}, CursorError(err, filter.PageRequest)
+	}
+	defer rows.Close()
+
+	var items []structures.ArchiveItem
+	var keys []string
+	for rows.Next() {
+		var item structures.ArchiveItem
+		var keyQuestionsJSON []byte
+		var tagsJSON []byte
+		var sortKey string
+
+		if err = rows.Scan(append(archiveTargets(&item, &keyQuestionsJSON, &tagsJSON), &sortKey)...); err != nil {
+			return Page[structures.ArchiveItem]{}, err
+		}
+		if err = parseArchiveItem(&item, keyQuestionsJSON, tagsJSON); err != nil {
+			return Page[structures.ArchiveItem]{}, err
+		}
+
+		items = append(items, item)
+		keys = append(keys, sortKey)
+	}
+	if err = rows.Err(); err != nil {
+		return Page[structures.ArchiveItem]{}, err
+	}
+	return NewPage(items, keys, total, filter.PageRequest, func(item structures.ArchiveItem) int { return item.ID }), nil
+}
+
+func (r *pgDiscussions) Save(room *structures.Room) (int, error) {
 	keyQuestionsJSON, _ := json.Marshal(room.KeyQuestions)
 	tagsJSON, _ := json.Marshal(room.Tags)
 	exportOptionsJSON, _ := json.Marshal(room.ExportOptions)
 	participantsJSON, _ := json.Marshal(room.Participants)
 
-	var discussionID int64
-	err = db.QueryRow(`
-        INSERT INTO discussions 
+	var phasesJSON []byte
+	if len(room.PhaseLog) > 0 {
+		phasesJSON, _ = json.Marshal(room.PhaseLog)
+	}
+
+	var agendaJSON []byte
+	if len(room.AgendaLog) > 0 {
+		agendaJSON, _ = json.Marshal(room.AgendaLog)
+	}
+
+	var teamsJSON []byte
+	if teams := structures.MakeTeamList(room); teams != nil {
+		teamsJSON, _ = json.Marshal(teams)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var discussionID int
+	err = tx.QueryRow(`
+        INSERT INTO discussions
             (room_id, mode, subtype, duration, start_time, end_time,
-             messages, creator_username, key_questions, tags,
+             creator_username, key_questions, tags,
              export_options, participants, topic_id, subtopic_id,
-             custom_topic, custom_subtopic, description, purpose, room_name, public) 
-        VALUES 
-            ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+             custom_topic, custom_subtopic, description, purpose, room_name, public, teams, phases,
+             organization_id, agenda)
+        VALUES
+            ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+             NULLIF($22, 0), $23)
         RETURNING id`,
 		room.ID,
 		room.Mode,
@@ -52,7 +256,6 @@ func SaveDiscussionHistory(db *sql.DB, room *structures.Room) int64 {
 		int64(room.Duration.Seconds()),
 		room.StartTime,
 		time.Now(),
-		messagesJSON,
 		room.CreatorUsername,
 		keyQuestionsJSON,
 		tagsJSON,
@@ -66,15 +269,68 @@ func SaveDiscussionHistory(db *sql.DB, room *structures.Room) int64 {
 		room.Purpose,
 		room.Name,
 		room.Password == "" || room.Hidden,
+		teamsJSON,
+		phasesJSON,
+		room.OrganizationID,
+		agendaJSON,
 	).Scan(&discussionID)
+	if err != nil {
+		return 0, err
+	}
 
+	if err = SaveMessages(tx, discussionID, room.Messages); err != nil {
+		return 0, err
+	}
+	return discussionID, tx.Commit()
+}
+
+func (r *pgDiscussions) Activity(username string, filter ActivityFilter) ([]Activity, error) {
+	participantsFilter, err := json.Marshal([]string{username})
 	if err != nil {
-		logger.Log.Errorln("Save to DB error:", err)
-		return -1
+		return nil, err
 	}
 
-	logger.Log.Traceln("Discussion saved for room", room.ID, "with discussionID", discussionID)
-	room.Messages = nil
+	rows, err := r.db.Query(`
+		SELECT d.id, EXTRACT(EPOCH FROM d.duration)::bigint, d.participants, u.msgs, u.likes
+		FROM discussions d
+		CROSS JOIN LATERAL (
+			SELECT
+				COUNT(*) AS msgs,
+				COALESCE(SUM((SELECT COUNT(*) FROM message_votes v WHERE v.message_id = m.id AND v.vote = 1)), 0) AS likes
+			FROM discussion_messages m
+			WHERE m.discussion_id = d.id AND m.username = $3 AND m.type = 'usual'
+		) u
+		WHERE `+modeFilter("d", 2)+`
+		  AND ($4::int IS NULL OR COALESCE(d.organization_id, 0) = $4)
+		  AND d.participants @> $1::jsonb
+		  AND NOT d.imported
+		ORDER BY d.id`,
+		string(participantsFilter), pq.Array(filter.Modes), username, filter.Organization)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []Activity
+	for rows.Next() {
+		var item Activity
+		var seconds int64
+		var participantsJSON []byte
+		if err = rows.Scan(&item.DiscussionID, &seconds, &participantsJSON, &item.Messages, &item.Likes); err != nil {
+			return nil, err
+		}
+		item.Duration = time.Duration(seconds) * time.Second
+		if err = json.Unmarshal(participantsJSON, &item.Participants); err != nil {
+			return nil, fmt.Errorf("parse participants: %w", err)
+		}
+		activity = append(activity, item)
+	}
+	return activity, rows.Err()
+}
 
-	return discussionID
+// modeFilter условие на дискуссии форматов, которые учитываются в лидерборде и профиле;
+// param — номер параметра с modes.LeaderboardKeys()
+func modeFilter(alias string, param int) string {
+	return fmt.Sprintf("(%[1]s.mode || '/' || COALESCE(%[1]s.subtype, '') = ANY($%[2]d) OR %[1]s.mode || '/*' = ANY($%[2]d))",
+		alias, param)
 }
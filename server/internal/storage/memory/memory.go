@@ -0,0 +1,634 @@
+// Package memory хранилища storage в памяти процесса: для тестов обработчиков и запуска без Postgres
+package memory
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/structures"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Store общее состояние трех хранилищ; методы Add* заполняют его в обход проверок
+type Store struct {
+	mu          sync.RWMutex
+	users       []storage.User
+	members     map[int]map[string]bool // организация → имена участников
+	discussions []discussion
+	messages    map[int][]structures.Message
+	ratings     []storage.Rating
+}
+
+type discussion struct {
+	storage.Discussion
+	start time.Time
+	end   time.Time
+}
+
+func New() *Store {
+	return &Store{
+		members:  make(map[int]map[string]bool),
+		messages: make(map[int][]structures.Message),
+	}
+}
+
+// Repos хранилища поверх s
+func (s *Store) Repos() *storage.Repos {
+	return &storage.Repos{
+		Users:       users{s},
+		Discussions: discussions{s},
+		Ratings:     ratings{s},
+	}
+}
+
+// AddUser добавляет пользователя и возвращает его id
+func (s *Store) AddUser(user storage.User) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addUser(user)
+}
+
+func (s *Store) addUser(user storage.User) int {
+	user.ID = len(s.users) + 1
+	s.users = append(s.users, user)
+	return user.ID
+}
+
+func (s *Store) AddMember(organizationID int, username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.members[organizationID] == nil {
+		s.members[organizationID] = make(map[string]bool)
+	}
+	s.members[organizationID][username] = true
+}
+
+// AddDiscussion добавляет сохраненную дискуссию с историей и возвращает ее id; StartTime и EndTime
+// в формате RFC 3339 задают фильтр по датам и порядок в архиве
+func (s *Store) AddDiscussion(d storage.Discussion, messages []structures.Message) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addDiscussion(d, messages)
+}
+
+func (s *Store) addDiscussion(d storage.Discussion, messages []structures.Message) int {
+	d.ID = len(s.discussions) + 1
+	start, _ := time.Parse(time.RFC3339Nano, d.StartTime)
+	end, _ := time.Parse(time.RFC3339Nano, d.EndTime)
+	s.discussions = append(s.discussions, discussion{Discussion: d, start: start, end: end})
+
+	stored := make([]structures.Message, len(messages))
+	for i, msg := range messages {
+		msg.LikedBy = append([]string{}, msg.LikedBy...)
+		msg.DislikedBy = append([]string{}, msg.DislikedBy...)
+		msg.LikeCount, msg.DislikeCount = len(msg.LikedBy), len(msg.DislikedBy)
+		msg.Votes = nil
+		stored[i] = msg
+	}
+	s.messages[d.ID] = stored
+	return d.ID
+}
+
+func (s *Store) discussion(id int) (*discussion, bool) {
+	if id < 1 || id > len(s.discussions) {
+		return nil, false
+	}
+	return &s.discussions[id-1], true
+}
+
+type users struct{ s *Store }
+
+func (r users) Create(user storage.User) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.users {
+		if existing.Username == user.Username || existing.Email == user.Email {
+			return 0, fmt.Errorf("user %s already exists", user.Username)
+		}
+	}
+	return r.s.addUser(user), nil
+}
+
+func (r users) ByUsername(username string) (storage.User, error) {
+	return r.find(func(user storage.User) bool { return user.Username == username })
+}
+
+func (r users) ByLogin(login string) (storage.User, error) {
+	return r.find(func(user storage.User) bool { return user.Username == login || user.Email == login })
+}
+
+func (r users) find(match func(storage.User) bool) (storage.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	for _, user := range r.s.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return storage.User{}, storage.ErrNotFound
+}
+
+func (r users) UsernameTaken(username string) (bool, error) {
+	_, err := r.ByUsername(username)
+	return err == nil, nil
+}
+
+func (r users) EmailTaken(email string) (bool, error) {
+	_, err := r.find(func(user storage.User) bool { return user.Email == email })
+	return err == nil, nil
+}
+
+func (r users) IDs(usernames []string) (map[string]int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	wanted := toSet(usernames)
+	ids := make(map[string]int, len(usernames))
+	for _, user := range r.s.users {
+		if wanted[user.Username] {
+			ids[user.Username] = user.ID
+		}
+	}
+	return ids, nil
+}
+
+func (r users) List(organizationID int) ([]storage.User, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var list []storage.User
+	for _, user := range r.s.users {
+		if !user.Placeholder && (organizationID == 0 || r.s.members[organizationID][user.Username]) {
+			list = append(list, user)
+		}
+	}
+	return list, nil
+}
+
+func (r users) IsMember(organizationID int, username string) (bool, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	return r.s.members[organizationID][username], nil
+}
+
+type discussions struct{ s *Store }
+
+func (r discussions) Get(id int) (*storage.Discussion, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	d, ok := r.s.discussion(id)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	copied := d.Discussion
+	return &copied, nil
+}
+
+func (r discussions) Messages(id int) ([]structures.Message, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	messages := make([]structures.Message, len(r.s.messages[id]))
+	copy(messages, r.s.messages[id])
+	return messages, nil
+}
+
+func (r discussions) List(filter storage.ArchiveFilter) (storage.Page[structures.ArchiveItem], error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	if filter.Sort == "" {
+		filter.Sort = storage.SortNewest
+	}
+	var entries []entry
+	for _, d := range r.s.visible(filter) {
+		key, err := r.s.sortKey(d, filter.Sort, 0)
+		if err != nil {
+			return storage.Page[structures.ArchiveItem]{}, err
+		}
+		entries = append(entries, entry{key, d.ID})
+	}
+
+	var items []structures.ArchiveItem
+	keys, err := page(entries, filter.PageRequest, func(id int) {
+		d, _ := r.s.discussion(id)
+		items = append(items, archiveItem(*d))
+	})
+	if err != nil {
+		return storage.Page[structures.ArchiveItem]{}, err
+	}
+	return storage.NewPage(items, keys, len(entries), filter.PageRequest, func(item structures.ArchiveItem) int { return item.ID }), nil
+}
+
+// Веса полей как у ts_rank по умолчанию: название и своя тема — A, ключевые вопросы — B,
+// описание и цель — C; у сообщений веса нет, это D
+const (
+	weightA = 1.0
+	weightB = 0.4
+	weightC = 0.2
+	weightD = 0.1
+)
+
+// Search поиск как в Postgres без стемминга: слова запроса ищутся подстрокой без учета регистра.
+// Дискуссия подходит, если все слова есть в ее полях или все в одном сообщении — так же, как
+// tsquery сопоставляется с одним tsvector. Релевантность — вхождения с весами полей и лучшее сообщение
+func (r discussions) Search(filter storage.SearchFilter) (storage.Page[structures.SearchItem], error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	words := strings.Fields(strings.ToLower(filter.Query))
+	if filter.Sort == "" {
+		filter.Sort = storage.SortRelevance
+	}
+	var entries []entry
+	found := make(map[int]structures.SearchItem)
+	for _, d := range r.s.visible(filter.ArchiveFilter) {
+		item := structures.SearchItem{ArchiveItem: archiveItem(d), Snippets: []structures.SearchSnippet{}}
+		matched := make(map[string]bool)
+		var fieldsRank float64
+		for _, field := range []struct {
+			name, text string
+			weight     float64
+		}{
+			{"room_name", d.RoomName, weightA},
+			{"custom_topic", d.Topic, weightA},
+			{"key_questions", strings.Join(d.KeyQuestions, " / "), weightB},
+			{"description", d.Description, weightC},
+			{"purpose", d.Purpose, weightC},
+		} {
+			if hits := occurrences(field.text, words, matched); hits > 0 {
+				fieldsRank += field.weight * float64(hits)
+				item.Snippets = append(item.Snippets, structures.SearchSnippet{Field: field.name, Text: mark(field.text, words)})
+			}
+		}
+		fieldsMatch := len(words) > 0 && len(matched) == len(words)
+		if fieldsMatch {
+			item.Rank += fieldsRank
+		}
+
+		var best *structures.Message
+		var bestHits, messages int
+		for i, msg := range r.s.messages[d.ID] {
+			if msg.Type != "usual" {
+				continue
+			}
+			matched := make(map[string]bool)
+			hits := occurrences(msg.Content, words, matched)
+			if len(words) == 0 || len(matched) < len(words) {
+				continue
+			}
+			messages++
+			if hits > bestHits {
+				best, bestHits = &r.s.messages[d.ID][i], hits
+			}
+		}
+		if !fieldsMatch && best == nil {
+			continue
+		}
+		if best != nil {
+			item.Rank += weightD * float64(bestHits)
+			item.Snippets = append(item.Snippets, structures.SearchSnippet{
+				Field:     "message",
+				Text:      mark(best.Content, words),
+				MessageID: best.ID,
+				Matches:   messages,
+			})
+		}
+		key, err := r.s.sortKey(d, filter.Sort, item.Rank)
+		if err != nil {
+			return storage.Page[structures.SearchItem]{}, err
+		}
+		entries = append(entries, entry{key, d.ID})
+		found[d.ID] = item
+	}
+
+	var items []structures.SearchItem
+	keys, err := page(entries, filter.PageRequest, func(id int) { items = append(items, found[id]) })
+	if err != nil {
+		return storage.Page[structures.SearchItem]{}, err
+	}
+	return storage.NewPage(items, keys, len(entries), filter.PageRequest, func(item structures.SearchItem) int { return item.ID }), nil
+}
+
+// entry дискуссия в списке с ключом сортировки
+type entry struct {
+	key float64
+	id  int
+}
+
+// page сортирует entries как Postgres, передает в add дискуссии страницы (на одну больше лимита)
+// и возвращает их ключи для курсора
+func page(entries []entry, request storage.PageRequest, add func(id int)) ([]string, error) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].key != entries[j].key {
+			return entries[i].key > entries[j].key
+		}
+		return entries[i].id > entries[j].id
+	})
+
+	start := request.Offset
+	if request.After != nil {
+		after, err := strconv.ParseFloat(request.After.Key, 64)
+		if err != nil {
+			return nil, storage.ErrInvalidCursor
+		}
+		start = sort.Search(len(entries), func(i int) bool {
+			return entries[i].key < after || entries[i].key == after && entries[i].id < request.After.ID
+		})
+	}
+
+	var keys []string
+	for i := start; i < len(entries) && i <= start+request.Limit; i++ {
+		add(entries[i].id)
+		keys = append(keys, strconv.FormatFloat(entries[i].key, 'g', -1, 64))
+	}
+	return keys, nil
+}
+
+// sortKey ключ сортировки дискуссии; rank — релевантность, если это поиск
+func (s *Store) sortKey(d discussion, sortBy string, rank float64) (float64, error) {
+	switch sortBy {
+	case storage.SortNewest:
+		return float64(d.end.UnixNano()) / 1e9, nil
+	case storage.SortLongest:
+		return parseInterval(d.Duration).Seconds(), nil
+	case storage.SortMessages:
+		count := 0
+		for _, msg := range s.messages[d.ID] {
+			if msg.Type == "usual" {
+				count++
+			}
+		}
+		return float64(count), nil
+	case storage.SortRating:
+		var sum, count int
+		for _, rating := range s.ratings {
+			if rating.DiscussionID == d.ID {
+				sum += rating.Professionalism + rating.ArgumentsQuality + rating.Politeness
+				count++
+			}
+		}
+		if count == 0 {
+			return 0, nil
+		}
+		return float64(sum) / float64(count) / 3, nil
+	case storage.SortParticipants:
+		return float64(len(d.Participants)), nil
+	case storage.SortRelevance:
+		return rank, nil
+	}
+	return 0, fmt.Errorf("unknown sort %q", sortBy)
+}
+
+// visible дискуссии, подходящие под filter, в порядке добавления; вызывающий держит s.mu
+func (s *Store) visible(filter storage.ArchiveFilter) []discussion {
+	var visible []discussion
+	for _, d := range s.discussions {
+		if filter.OrganizationID != 0 {
+			if d.OrganizationID != filter.OrganizationID {
+				continue
+			}
+		} else if !(d.Public && d.OrganizationID == 0) && !contains(d.Participants, filter.Username) {
+			continue
+		}
+		if filter.OnlyMine && !contains(d.Participants, filter.Username) ||
+			filter.Participant != "" && !contains(d.Participants, filter.Participant) ||
+			filter.Mode != "" && d.Mode != filter.Mode ||
+			filter.SubType != "" && d.SubType != filter.SubType ||
+			filter.TopicID != 0 && d.TopicID != filter.TopicID ||
+			!filter.From.IsZero() && d.start.Before(filter.From) ||
+			!filter.To.IsZero() && !d.start.Before(filter.To) {
+			continue
+		}
+		tags := toSet(d.Tags)
+		hasTags := true
+		for _, tag := range filter.Tags {
+			hasTags = hasTags && tags[tag]
+		}
+		if hasTags {
+			visible = append(visible, d)
+		}
+	}
+	return visible
+}
+
+func archiveItem(d discussion) structures.ArchiveItem {
+	return structures.ArchiveItem{
+		ID:                d.ID,
+		Name:              d.RoomName,
+		Mode:              d.Mode,
+		SubType:           d.SubType,
+		KeyQuestions:      d.KeyQuestions,
+		Tags:              d.Tags,
+		TopicID:           d.TopicID,
+		SubtopicID:        d.SubtopicID,
+		CustomTopic:       d.Topic,
+		CustomSubtopic:    d.Subtopic,
+		Public:            d.Public,
+		ParticipantsCount: len(d.Participants),
+		Imported:          d.Imported,
+	}
+}
+
+// occurrences число вхождений слов в text; найденные слова отмечаются в matched
+func occurrences(text string, words []string, matched map[string]bool) int {
+	lower := strings.ToLower(text)
+	total := 0
+	for _, word := range words {
+		if n := strings.Count(lower, word); n > 0 {
+			matched[word] = true
+			total += n
+		}
+	}
+	return total
+}
+
+// mark экранирует text и оборачивает вхождения слов в <mark>, как сниппеты Postgres
+func mark(text string, words []string) string {
+	lower := strings.ToLower(text)
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		length := 0
+		for _, word := range words {
+			if strings.HasPrefix(lower[i:], word) && len(word) > length {
+				length = len(word)
+			}
+		}
+		if length == 0 {
+			end := i + 1
+			for end < len(text) && !utf8.RuneStart(text[end]) {
+				end++
+			}
+			b.WriteString(html.EscapeString(text[i:end]))
+			i = end
+			continue
+		}
+		b.WriteString("<mark>" + html.EscapeString(text[i:i+length]) + "</mark>")
+		i += length
+	}
+	return b.String()
+}
+
+func (r discussions) Save(room *structures.Room) (int, error) {
+	end := time.Now()
+	d := storage.Discussion{
+		DiscussionResponse: structures.DiscussionResponse{
+			RoomID:         room.ID,
+			RoomName:       room.Name,
+			Public:         room.Password == "" || room.Hidden,
+			OrganizationID: room.OrganizationID,
+			Mode:           room.Mode,
+			SubType:        room.SubType,
+			Duration:       formatInterval(room.Duration),
+			StartTime:      room.StartTime.Format(time.RFC3339Nano),
+			EndTime:        end.Format(time.RFC3339Nano),
+			Creator:        room.CreatorUsername,
+			KeyQuestions:   room.KeyQuestions,
+			Tags:           room.Tags,
+			ExportOptions:  room.ExportOptions,
+			Participants:   append([]string{}, room.Participants...),
+			Topic:          room.CustomTopic,
+			Subtopic:       room.CustomSubtopic,
+			Description:    room.Description,
+			Purpose:        room.Purpose,
+			Teams:          structures.MakeTeamList(room),
+			Phases:         append([]structures.PhaseBoundary(nil), room.PhaseLog...),
+		},
+		TopicID:    room.TopicID,
+		SubtopicID: room.SubtopicID,
+		AgendaLog:  append([]structures.AgendaBoundary(nil), room.AgendaLog...),
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	id := r.s.addDiscussion(d, room.Messages)
+	r.s.discussions[id-1].end = end
+	return id, nil
+}
+
+func (r discussions) Activity(username string, filter storage.ActivityFilter) ([]storage.Activity, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var activity []storage.Activity
+	for _, d := range r.s.discussions {
+		if !matches(d.Discussion, filter) || !contains(d.Participants, username) {
+			continue
+		}
+		item := storage.Activity{
+			DiscussionID: d.ID,
+			Duration:     parseInterval(d.Duration),
+			Participants: d.Participants,
+		}
+		for _, msg := range r.s.messages[d.ID] {
+			if msg.Username == username && msg.Type == "usual" {
+				item.Messages++
+				item.Likes += len(msg.LikedBy)
+			}
+		}
+		activity = append(activity, item)
+	}
+	return activity, nil
+}
+
+type ratings struct{ s *Store }
+
+func (r ratings) Save(list []storage.Rating) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, rating := range list {
+		duplicate := false
+		for _, existing := range r.s.ratings {
+			if existing.DiscussionID == rating.DiscussionID &&
+				existing.RaterID == rating.RaterID && existing.RatedID == rating.RatedID {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			r.s.ratings = append(r.s.ratings, rating)
+		}
+	}
+	return nil
+}
+
+func (r ratings) ForDiscussion(discussionID int) ([]storage.Rating, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+	var list []storage.Rating
+	for _, rating := range r.s.ratings {
+		if rating.DiscussionID == discussionID {
+			list = append(list, rating)
+		}
+	}
+	return list, nil
+}
+
+func (r ratings) Averages(ratedUserID int, filter storage.ActivityFilter) (storage.RatingAverages, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var averages storage.RatingAverages
+	count := 0
+	for _, rating := range r.s.ratings {
+		d, ok := r.s.discussion(rating.DiscussionID)
+		if rating.RatedID != ratedUserID || !ok || !matches(d.Discussion, filter) {
+			continue
+		}
+		averages.Professionalism += float64(rating.Professionalism)
+		averages.ArgumentsQuality += float64(rating.ArgumentsQuality)
+		averages.Politeness += float64(rating.Politeness)
+		count++
+	}
+	if count > 0 {
+		averages.Professionalism /= float64(count)
+		averages.ArgumentsQuality /= float64(count)
+		averages.Politeness /= float64(count)
+	}
+	return averages, nil
+}
+
+// matches повторяет условие Postgres: не импортирована, формат из filter.Modes («mode/subtype»
+// или «mode/*») и организация, если она задана
+func matches(d storage.Discussion, filter storage.ActivityFilter) bool {
+	if d.Imported {
+		return false
+	}
+	if filter.Organization != nil && d.OrganizationID != *filter.Organization {
+		return false
+	}
+	return contains(filter.Modes, d.Mode+"/"+d.SubType) || contains(filter.Modes, d.Mode+"/*")
+}
+
+// formatInterval и parseInterval — длительность в текстовом виде interval Postgres, «01:30:00»
+func formatInterval(duration time.Duration) string {
+	seconds := int64(duration.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+func parseInterval(value string) time.Duration {
+	var hours, minutes, seconds int64
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d:%d", &hours, &minutes, &seconds); err != nil {
+		return 0
+	}
+	return time.Duration(hours*3600+minutes*60+seconds) * time.Second
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, item := range list {
+		set[item] = true
+	}
+	return set
+}
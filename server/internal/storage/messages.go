@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"awesomeChat/internal/structures"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+// SaveMessage записывает сообщение сразу после отправки. Пока дискуссия не сохранена,
+// сообщение привязано к сессии комнаты; seq — номер в истории комнаты с 1
+func SaveMessage(db *sql.DB, session string, seq int, msg structures.Message) error {
+	params, err := paramsJSON(msg.Params)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		INSERT INTO discussion_messages
+			(id, room_session, seq, type, username, content, created_at, reply_to, agenda_item, key, params)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, NULLIF($10, ''), $11)`,
+		msg.ID, session, seq, msg.Type, msg.Username, msg.Content, msg.Timestamp,
+		msg.ReplyTo, msg.AgendaItem, msg.Key, params)
+	return err
+}
+
+// SaveVote записывает голос за сообщение; 0 снимает голос
+func SaveVote(db *sql.DB, messageID, username string, vote int) error {
+	if vote == 0 {
+		_, err := db.Exec(`DELETE FROM message_votes WHERE message_id = $1 AND username = $2`, messageID, username)
+		return err
+	}
+	_, err := db.Exec(`
+		INSERT INTO message_votes (message_id, username, vote)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (message_id, username) DO UPDATE SET vote = EXCLUDED.vote, voted_at = now()`,
+		messageID, username, vote)
+	return err
+}
+
+// DiscardSessionMessages удаляет сообщения комнаты, так и не ставшие дискуссией
+func DiscardSessionMessages(db *sql.DB, session string) error {
+	_, err := db.Exec(`DELETE FROM discussion_messages WHERE room_session = $1 AND discussion_id IS NULL`, session)
+	return err
+}
+
+// SaveMessages привязывает сообщения к дискуссии и сверяет их с messages: недостающие строки
+// добавляются, голоса переписываются по LikedBy и DislikedBy. Так история полна, даже если
+// запись по ходу дискуссии не удалась
+func SaveMessages(tx *sql.Tx, discussionID int, messages []structures.Message) error {
+	ids := make([]string, 0, len(messages))
+	for i, msg := range messages {
+		params, err := paramsJSON(msg.Params)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO discussion_messages
+				(id, discussion_id, seq, type, username, content, created_at, reply_to, agenda_item, key, params)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, NULLIF($10, ''), $11)
+			ON CONFLICT (id) DO UPDATE SET discussion_id = EXCLUDED.discussion_id, seq = EXCLUDED.seq`,
+			msg.ID, discussionID, i+1, msg.Type, msg.Username, msg.Content, msg.Timestamp,
+			msg.ReplyTo, msg.AgendaItem, msg.Key, params)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, msg.ID)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM message_votes WHERE message_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		for _, votes := range []struct {
+			usernames []string
+			vote      int
+		}{
+			{msg.LikedBy, 1},
+			{msg.DislikedBy, -1},
+		} {
+			for _, username := range votes.usernames {
+				_, err := tx.Exec(`
+					INSERT INTO message_votes (message_id, username, vote) VALUES ($1, $2, $3)
+					ON CONFLICT (message_id, username) DO NOTHING`,
+					msg.ID, username, votes.vote)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MessageCursor история дискуссии по порядку, с оценившими; строки читаются по одной
+type MessageCursor struct {
+	rows *sql.Rows
+	seq  int
+	msg  structures.Message
+	err  error
+}
+
+// OpenMessages открывает курсор по сообщениям дискуссии; его нужно закрыть
+func OpenMessages(db *sql.DB, discussionID int) (*MessageCursor, error) {
+	rows, err := db.Query(`
+		SELECT m.id, m.seq, m.type, m.username, m.content, m.created_at,
+			COALESCE(m.reply_to, ''), m.agenda_item, COALESCE(m.key, ''), m.params,
+			COALESCE(array_agg(v.username ORDER BY v.voted_at, v.username) FILTER (WHERE v.vote = 1), '{}'),
+			COALESCE(array_agg(v.username ORDER BY v.voted_at, v.username) FILTER (WHERE v.vote = -1), '{}')
+		FROM discussion_messages m
+		LEFT JOIN message_votes v ON v.message_id = m.id
+		WHERE m.discussion_id = $1
+		GROUP BY m.id
+		ORDER BY m.seq`, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageCursor{rows: rows}, nil
+}
+
+func (c *MessageCursor) Next() bool {
+	if c.err != nil || !c.rows.Next() {
+		return false
+	}
+
+	var msg structures.Message
+	var agendaItem sql.NullInt64
+	var params []byte
+	c.err = c.rows.Scan(&msg.ID, &c.seq, &msg.Type, &msg.Username, &msg.Content, &msg.Timestamp,
+		&msg.ReplyTo, &agendaItem, &msg.Key, &params,
+		pq.Array(&msg.LikedBy), pq.Array(&msg.DislikedBy))
+	if c.err != nil {
+		return false
+	}
+	if agendaItem.Valid {
+		item := int(agendaItem.Int64)
+		msg.AgendaItem = &item
+	}
+	if params != nil {
+		if c.err = json.Unmarshal(params, &msg.Params); c.err != nil {
+			return false
+		}
+	}
+	if msg.LikedBy == nil {
+		msg.LikedBy = []string{}
+	}
+	if msg.DislikedBy == nil {
+		msg.DislikedBy = []string{}
+	}
+	msg.LikeCount, msg.DislikeCount = len(msg.LikedBy), len(msg.DislikedBy)
+	c.msg = msg
+	return true
+}
+
+// Message текущее сообщение и его номер в истории
+func (c *MessageCursor) Message() (structures.Message, int) {
+	return c.msg, c.seq
+}
+
+func (c *MessageCursor) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.rows.Err()
+}
+
+func (c *MessageCursor) Close() error {
+	return c.rows.Close()
+}
+
+// LoadMessages вся история дискуссии
+func LoadMessages(db *sql.DB, discussionID int) ([]structures.Message, error) {
+	cursor, err := OpenMessages(db, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close()
+
+	messages := []structures.Message{}
+	for cursor.Next() {
+		msg, _ := cursor.Message()
+		messages = append(messages, msg)
+	}
+	return messages, cursor.Err()
+}
+
+func paramsJSON(params map[string]any) ([]byte, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(params)
+}
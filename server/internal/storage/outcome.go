@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"awesomeChat/internal/structures"
+	"awesomeChat/package/logger"
+	"database/sql"
+)
+
+// SaveOutcomes сохраняет решения и поручения, зафиксированные в комнате
+func SaveOutcomes(db *sql.DB, discussionID int, outcomes []structures.Outcome) {
+	for _, outcome := range outcomes {
+		_, err := db.Exec(`
+			INSERT INTO discussion_outcomes
+				(discussion_id, kind, content, message_id, agenda_item, author_username,
+				 assignee_user_id, due_date, created_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6,
+				(SELECT user_id FROM users WHERE username = NULLIF($7, '')), NULLIF($8, '')::date, $9)`,
+			discussionID,
+			outcome.Kind,
+			outcome.Content,
+			outcome.MessageID,
+			outcome.AgendaItem,
+			outcome.Author,
+			outcome.Assignee,
+			outcome.DueDate,
+			outcome.CreatedAt,
+		)
+		if err != nil {
+			logger.Log.Errorln("Save outcome error:", err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// Сортировки списков; все — по убыванию, при равенстве ключа новее те, у кого больше id
+const (
+	SortNewest       = "newest"
+	SortLongest      = "longest"
+	SortMessages     = "messages"
+	SortRating       = "rating"
+	SortParticipants = "participants"
+	SortRelevance    = "relevance" // только поиск
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor позиция в списке: ключ сортировки и id последнего отданного элемента. Клиент получает его
+// непрозрачной строкой и передает обратно без изменений
+type Cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"` // ключ в текстовом виде хранилища
+	ID   int    `json:"i"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(value string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.Sort == "" || cursor.Key == "" || cursor.ID < 1 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// PageRequest общий для списков способ листать: After из предыдущего ответа или, по-старому, Offset.
+// С курсором Offset не используется
+type PageRequest struct {
+	Sort   string
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+// Page страница списка: Total — все элементы под фильтром независимо от курсора,
+// Next — курсор следующей страницы или nil, если она последняя
+type Page[T any] struct {
+	Items []T
+	Total int
+	Next  *Cursor
+}
+
+// NewPage собирает страницу из выборки на один элемент больше лимита: лишний элемент означает,
+// что есть следующая страница, и курсор указывает на последний отданный
+func NewPage[T any](items []T, keys []string, total int, request PageRequest, id func(T) int) Page[T] {
+	page := Page[T]{Items: items, Total: total}
+	if len(items) > request.Limit {
+		page.Items = items[:request.Limit]
+		last := request.Limit - 1
+		page.Next = &Cursor{Sort: request.Sort, Key: keys[last], ID: id(items[last])}
+	}
+	return page
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// placeholdersDriver драйвер, который вместо базы сверяет плейсхолдеры запроса с аргументами.
+// Postgres отвергает запрос, если какой-то $N в нем не встречается ("could not determine data
+// type of parameter") или если аргументов меньше, чем плейсхолдеров, поэтому номера $1..$N должны
+// совпадать с аргументами один в один. Подсчет дискуссий отвечает нулем, остальные запросы — пустой выборкой
+type placeholdersDriver struct{}
+
+func init() {
+	sql.Register("placeholders", placeholdersDriver{})
+}
+
+func (placeholdersDriver) Open(string) (driver.Conn, error) { return placeholdersConn{}, nil }
+
+type placeholdersConn struct{}
+
+func (placeholdersConn) Prepare(query string) (driver.Stmt, error) {
+	return placeholdersStmt(query), nil
+}
+func (placeholdersConn) Close() error { return nil }
+func (placeholdersConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type placeholdersStmt string
+
+func (s placeholdersStmt) Close() error  { return nil }
+func (s placeholdersStmt) NumInput() int { return -1 }
+
+func (s placeholdersStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), checkPlaceholders(string(s), len(args))
+}
+
+func (s placeholdersStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := checkPlaceholders(string(s), len(args)); err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(s), "SELECT COUNT(*) FROM discussions d") {
+		return &placeholdersRows{values: [][]driver.Value{{int64(0)}}}, nil
+	}
+	return &placeholdersRows{}, nil
+}
+
+type placeholdersRows struct {
+	values [][]driver.Value
+}
+
+func (r *placeholdersRows) Columns() []string { return []string{"count"} }
+func (r *placeholdersRows) Close() error      { return nil }
+
+func (r *placeholdersRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+func checkPlaceholders(query string, args int) error {
+	used := make(map[int]bool)
+	for _, match := range placeholder.FindAllStringSubmatch(query, -1) {
+		n, _ := strconv.Atoi(match[1])
+		used[n] = true
+	}
+	var unused []int
+	for n := 1; n <= args; n++ {
+		if !used[n] {
+			unused = append(unused, n)
+		}
+		delete(used, n)
+	}
+	var missing []int
+	for n := range used {
+		missing = append(missing, n)
+	}
+	sort.Ints(missing)
+	if len(unused) > 0 || len(missing) > 0 {
+		return fmt.Errorf("%d args, unused %v, no args for %v in query:\n%s", args, unused, missing, query)
+	}
+	return nil
+}
+
+// TestQueryPlaceholders запросы архива и поиска со всеми фильтрами и сортировками, с курсором и без,
+// передают ровно столько аргументов, сколько плейсхолдеров использовано
+func TestQueryPlaceholders(t *testing.T) {
+	db, err := sql.Open("placeholders", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	discussions := NewDiscussionRepo(db)
+
+	filters := map[string]ArchiveFilter{
+		"no filters": {Username: "alice"},
+		"all filters": {
+			Username:    "alice",
+			Mode:        "personal",
+			SubType:     "blitz",
+			TopicID:     3,
+			Tags:        []string{"economy"},
+			Participant: "bob",
+			From:        time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			To:          time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			OnlyMine:    true,
+		},
+		"organization": {Username: "alice", OrganizationID: 7},
+	}
+	for name, filter := range filters {
+		for sortName := range searchSortKeys {
+			for _, after := range []*Cursor{nil, {Sort: sortName, Key: "1", ID: 5}} {
+				filter.PageRequest = PageRequest{Sort: sortName, Limit: 10, Offset: 20, After: after}
+				label := fmt.Sprintf("%s, %s, cursor %t", name, sortName, after != nil)
+
+				if sortName != SortRelevance {
+					if _, err := discussions.List(filter); err != nil {
+						t.Errorf("list with %s: %v", label, err)
+					}
+				}
+				if _, err := discussions.Search(SearchFilter{ArchiveFilter: filter, Query: "climate"}); err != nil {
+					t.Errorf("search with %s: %v", label, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+type pgRatings struct {
+	db *sql.DB
+}
+
+func NewRatingRepo(db *sql.DB) RatingRepo {
+	return &pgRatings{db: db}
+}
+
+func (r *pgRatings) Save(ratings []Rating) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rating := range ratings {
+		_, err = tx.Exec(`
+			INSERT INTO ratings (
+				discussion_id, rater_user_id, rated_user_id,
+				professionalism, arguments_quality, politeness,
+				rater_team, rated_team
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (discussion_id, rater_user_id, rated_user_id) DO NOTHING`,
+			rating.DiscussionID, rating.RaterID, rating.RatedID,
+			rating.Professionalism, rating.ArgumentsQuality, rating.Politeness,
+			rating.RaterTeam, rating.RatedTeam,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *pgRatings) ForDiscussion(discussionID int) ([]Rating, error) {
+	rows, err := r.db.Query(`
+		SELECT rater_user_id, rated_user_id, professionalism, arguments_quality, politeness, rater_team, rated_team
+		FROM ratings
+		WHERE discussion_id = $1
+		ORDER BY id`, discussionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []Rating
+	for rows.Next() {
+		rating := Rating{DiscussionID: discussionID}
+		var raterTeam, ratedTeam sql.NullInt64
+		err = rows.Scan(&rating.RaterID, &rating.RatedID,
+			&rating.Professionalism, &rating.ArgumentsQuality, &rating.Politeness, &raterTeam, &ratedTeam)
+		if err != nil {
+			return nil, err
+		}
+		rating.RaterTeam, rating.RatedTeam = nullInt(raterTeam), nullInt(ratedTeam)
+		ratings = append(ratings, rating)
+	}
+	return ratings, rows.Err()
+}
+
+func (r *pgRatings) Averages(ratedUserID int, filter ActivityFilter) (RatingAverages, error) {
+	var averages RatingAverages
+	err := r.db.QueryRow(`
+		SELECT
+			COALESCE(AVG(professionalism), 0),
+			COALESCE(AVG(arguments_quality), 0),
+			COALESCE(AVG(politeness), 0)
+		FROM ratings r
+		JOIN discussions d ON d.id = r.discussion_id
+		WHERE `+modeFilter("d", 2)+`
+		  AND ($3::int IS NULL OR COALESCE(d.organization_id, 0) = $3)
+		  AND NOT d.imported
+		  AND r.rated_user_id = $1`,
+		ratedUserID, pq.Array(filter.Modes), filter.Organization).Scan(
+		&averages.Professionalism,
+		&averages.ArgumentsQuality,
+		&averages.Politeness,
+	)
+	return averages, err
+}
+
+func nullInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	n := int(value.Int64)
+	return &n
+}
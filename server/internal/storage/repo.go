@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"awesomeChat/internal/structures"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Repos хранилища, с которыми работают обработчики: Postgres в сервере, память в тестах
+type Repos struct {
+	Users       UserRepo
+	Discussions DiscussionRepo
+	Ratings     RatingRepo
+}
+
+func NewPostgres(db *sql.DB) *Repos {
+	return &Repos{
+		Users:       NewUserRepo(db),
+		Discussions: NewDiscussionRepo(db),
+		Ratings:     NewRatingRepo(db),
+	}
+}
+
+type User struct {
+	ID           int
+	Username     string
+	Email        string
+	PasswordHash string
+	Locale       string // пусто, если язык не выбран
+	Placeholder  bool   // заглушка автора импортированной стенограммы
+}
+
+type UserRepo interface {
+	Create(user User) (int, error)
+	// ByUsername ErrNotFound, если пользователя нет
+	ByUsername(username string) (User, error)
+	// ByLogin ищет по имени или почте
+	ByLogin(login string) (User, error)
+	UsernameTaken(username string) (bool, error)
+	EmailTaken(email string) (bool, error)
+	// IDs id по именам; удаленных пользователей в ответе нет
+	IDs(usernames []string) (map[string]int, error)
+	// List все пользователи или, если organizationID не 0, участники организации; без заглушек импорта
+	List(organizationID int) ([]User, error)
+	IsMember(organizationID int, username string) (bool, error)
+}
+
+// Discussion сохраненная дискуссия: ответ архива без сообщений и то, из чего он собирается
+type Discussion struct {
+	structures.DiscussionResponse
+	TopicID    int
+	SubtopicID int
+	AgendaLog  []structures.AgendaBoundary
+}
+
+// ArchiveFilter страница архива. Без организации видны публичные дискуссии вне организаций
+// и дискуссии с участием Username, с организацией — все ее дискуссии
+type ArchiveFilter struct {
+	PageRequest
+	Username       string
+	OrganizationID int
+	Mode           string
+	SubType        string
+	TopicID        int
+	Tags           []string // все теги должны быть у дискуссии
+	Participant    string
+	From           time.Time // начало дискуссии, включительно
+	To             time.Time // начало дискуссии, не включая
+	OnlyMine       bool      // только дискуссии с участием Username
+}
+
+// SearchFilter полнотекстовый поиск среди дискуссий, видимых по ArchiveFilter. Query — в синтаксисе
+// websearch_to_tsquery: слова, "фразы", or и -исключения
+type SearchFilter struct {
+	ArchiveFilter
+	Query string
+}
+
+// ActivityFilter дискуссии, учитываемые в профиле и лидерборде; импортированные стенограммы не учитываются никогда
+type ActivityFilter struct {
+	Modes        []string // ключи modes.LeaderboardKeys
+	Organization *int     // nil — любые дискуссии, 0 — только вне организаций
+}
+
+// Activity участие пользователя в одной дискуссии
+type Activity struct {
+	DiscussionID int
+	Duration     time.Duration
+	Participants []string
+	Messages     int // обычные сообщения пользователя
+	Likes        int // лайки на них
+}
+
+type DiscussionRepo interface {
+	// Get дискуссия без сообщений; ErrNotFound, если ее нет
+	Get(id int) (*Discussion, error)
+	Messages(id int) ([]structures.Message, error)
+	// List страница архива в порядке filter.Sort; названия тем заполняет вызывающий
+	List(filter ArchiveFilter) (Page[structures.ArchiveItem], error)
+	// Search страница найденных дискуссий, SortRelevance — по убыванию релевантности
+	Search(filter SearchFilter) (Page[structures.SearchItem], error)
+	// Save сохраняет завершенную дискуссию комнаты; вызывающий держит room.Mu
+	Save(room *structures.Room) (int, error)
+	Activity(username string, filter ActivityFilter) ([]Activity, error)
+}
+
+type Rating struct {
+	DiscussionID     int
+	RaterID          int
+	RatedID          int
+	Professionalism  int
+	ArgumentsQuality int
+	Politeness       int
+	RaterTeam        *int // команды в командном блице
+	RatedTeam        *int
+}
+
+// RatingAverages средние оценки; нули, если оценок нет
+type RatingAverages struct {
+	Professionalism  float64
+	ArgumentsQuality float64
+	Politeness       float64
+}
+
+type RatingRepo interface {
+	// Save сохраняет оценки одной транзакцией; повторная оценка той же пары пропускается
+	Save(ratings []Rating) error
+	ForDiscussion(discussionID int) ([]Rating, error)
+	Averages(ratedUserID int, filter ActivityFilter) (RatingAverages, error)
+}
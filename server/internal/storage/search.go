@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"awesomeChat/internal/structures"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Границы совпадений в ts_headline: управляющие символы не встречаются в тексте, поэтому после
+// экранирования HTML их можно безопасно заменить на <mark>
+const (
+	markStart = "\x01"
+	markStop  = "\x02"
+)
+
+var headlineOptions = fmt.Sprintf(`StartSel=%s, StopSel=%s, MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "`,
+	markStart, markStop)
+
+// searchQuery запрос в обеих конфигурациях: русская стемминг-форма или английская
+const searchQuery = `WITH q AS (
+	SELECT websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1) AS query
+)`
+
+// searchMatch дискуссия подходит по своим полям или по сообщениям
+const searchMatch = `(d.search_vector @@ q.query OR EXISTS (
+	SELECT 1 FROM discussion_messages dm
+	WHERE dm.discussion_id = d.id AND dm.type = 'usual' AND dm.search_vector @@ q.query
+))`
+
+const archiveColumns = `d.id, d.room_name, d.mode, d.subtype, d.key_questions, d.tags,
+	d.topic_id, d.subtopic_id, d.custom_topic, d.custom_subtopic, d.public,
+	jsonb_array_length(d.participants), d.imported`
+
+func archiveTargets(item *structures.ArchiveItem, keyQuestionsJSON, tagsJSON *[]byte) []interface{} {
+	return []interface{}{
+		&item.ID,
+		&item.Name,
+		&item.Mode,
+		&item.SubType,
+		keyQuestionsJSON,
+		tagsJSON,
+		&item.TopicID,
+		&item.SubtopicID,
+		&item.CustomTopic,
+		&item.CustomSubtopic,
+		&item.Public,
+		&item.ParticipantsCount,
+		&item.Imported,
+	}
+}
+
+func parseArchiveItem(item *structures.ArchiveItem, keyQuestionsJSON, tagsJSON []byte) error {
+	if err := json.Unmarshal(keyQuestionsJSON, &item.KeyQuestions); err != nil {
+		return fmt.Errorf("parse key questions: %w", err)
+	}
+	if err := json.Unmarshal(tagsJSON, &item.Tags); err != nil {
+		return fmt.Errorf("parse tags: %w", err)
+	}
+	return nil
+}
+
+// sortKey ключ сортировки: выражение и его тип. В курсор ключ попадает текстом и приводится
+// обратно к типу, поэтому сравнение точное, а по end_time и duration работают индексы
+type sortKey struct {
+	expr string
+	typ  string
+}
+
+var archiveSortKeys = map[string]sortKey{
+	SortNewest:       {`d.end_time`, `timestamp`},
+	SortLongest:      {`d.duration`, `interval`},
+	SortMessages:     {`(SELECT COUNT(*) FROM discussion_messages dm WHERE dm.discussion_id = d.id AND dm.type = 'usual')`, `bigint`},
+	SortRating:       {`COALESCE((SELECT AVG(r.professionalism + r.arguments_quality + r.politeness) / 3 FROM ratings r WHERE r.discussion_id = d.id), 0)`, `numeric`},
+	SortParticipants: {`jsonb_array_length(d.participants)`, `int`},
+}
+
+// searchSortKeys поиск сортируется так же, как архив, и еще по релевантности
+var searchSortKeys = map[string]sortKey{
+	SortRelevance: {`ts_rank(d.search_vector, q.query) + COALESCE(m.rank, 0)`, `real`},
+}
+
+func init() {
+	for sort, key := range archiveSortKeys {
+		searchSortKeys[sort] = key
+	}
+}
+
+// keyset условие после курсора и порядок по ключу; параметры дописываются в args
+func keyset(key sortKey, request PageRequest, args *[]interface{}) (where, order string) {
+	order = ` ORDER BY ` + key.expr + ` DESC, d.id DESC`
+	if request.After == nil {
+		return "", order
+	}
+	*args = append(*args, request.After.Key, request.After.ID)
+	return fmt.Sprintf(` AND (%s, d.id) < ($%d::%s, $%d)`, key.expr, len(*args)-1, key.typ, len(*args)), order
+}
+
+// limitOffset на одну запись больше лимита, чтобы понять, есть ли следующая страница
+func limitOffset(request PageRequest, args *[]interface{}) string {
+	offset := request.Offset
+	if request.After != nil {
+		offset = 0
+	}
+	*args = append(*args, request.Limit+1, offset)
+	return fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(*args)-1, len(*args))
+}
+
+// CursorError ключ из подделанного курсора не приводится к типу сортировки — это ошибка клиента
+func CursorError(err error, request PageRequest) error {
+	var pqErr *pq.Error
+	if request.After != nil && errors.As(err, &pqErr) && pqErr.Code.Class() == "22" {
+		return ErrInvalidCursor
+	}
+	return err
+}
+
+// archiveWhere условия видимости и фильтров архива; параметры дописываются в args
+func archiveWhere(filter ArchiveFilter, args *[]interface{}) string {
+	arg := func(value interface{}) string {
+		*args = append(*args, value)
+		return "$" + strconv.Itoa(len(*args))
+	}
+
+	// общий архив — публичные дискуссии вне организаций и свои; архив организации — все ее дискуссии
+	var conditions []string
+	if filter.OrganizationID != 0 {
+		conditions = append(conditions, `d.organization_id = `+arg(filter.OrganizationID))
+	} else {
+		conditions = append(conditions,
+			`((d.public = true AND d.organization_id IS NULL) OR d.participants @> jsonb_build_array(`+arg(filter.Username)+`::text))`)
+	}
+	if filter.OnlyMine {
+		conditions = append(conditions, `d.participants @> jsonb_build_array(`+arg(filter.Username)+`::text)`)
+	}
+	if filter.Participant != "" {
+		conditions = append(conditions, `d.participants @> jsonb_build_array(`+arg(filter.Participant)+`::text)`)
+	}
+	if filter.Mode != "" {
+		conditions = append(conditions, `d.mode = `+arg(filter.Mode))
+	}
+	if filter.SubType != "" {
+		conditions = append(conditions, `COALESCE(d.subtype, '') = `+arg(filter.SubType))
+	}
+	if filter.TopicID != 0 {
+		conditions = append(conditions, `d.topic_id = `+arg(filter.TopicID))
+	}
+	if len(filter.Tags) > 0 {
+		tagsJSON, _ := json.Marshal(filter.Tags)
+		conditions = append(conditions, `d.tags @> `+arg(string(tagsJSON))+`::jsonb`)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, `d.start_time >= `+arg(filter.From))
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, `d.start_time < `+arg(filter.To))
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+func (r *pgDiscussions) Search(filter SearchFilter) (Page[structures.SearchItem], error) {
+	if filter.Sort == "" {
+		filter.Sort = SortRelevance
+	}
+	key, ok := searchSortKeys[filter.Sort]
+	if !ok {
+		return Page[structures.SearchItem]{}, fmt.Errorf("unknown sort %q", filter.Sort)
+	}
+
+	args := []interface{}{filter.Query}
+	where := archiveWhere(filter.ArchiveFilter, &args) + " AND " + searchMatch
+
+	var total int
+	err := r.db.QueryRow(searchQuery+`
+		SELECT COUNT(*) FROM discussions d CROSS JOIN q `+where, args...).Scan(&total)
+	if err != nil {
+		return Page[structures.SearchItem]{}, err
+	}
+
+	// сообщения весят как поля с весом D, поэтому совпадение в названии важнее совпадения в переписке.
+	// Параметры ts_headline идут последними: в подсчете их нет, а Postgres не принимает
+	// неиспользованный параметр
+	after, order := keyset(key, filter.PageRequest, &args)
+	limit := limitOffset(filter.PageRequest, &args)
+	args = append(args, headlineOptions)
+	options := "$" + strconv.Itoa(len(args))
+	rows, err := r.db.Query(searchQuery+`
+		SELECT `+archiveColumns+`, (`+key.expr+`)::text,
+			ts_rank(d.search_vector, q.query) + COALESCE(m.rank, 0),
+			ts_headline('russian', d.room_name, q.query, `+options+`),
+			ts_headline('russian', COALESCE(d.custom_topic, ''), q.query, `+options+`),
+			ts_headline('russian', COALESCE((SELECT string_agg(question, ' / ') FROM jsonb_array_elements_text(d.key_questions) AS question), ''), q.query, `+options+`),
+			ts_headline('russian', COALESCE(d.description, ''), q.query, `+options+`),
+			ts_headline('russian', COALESCE(d.purpose, ''), q.query, `+options+`),
+			COALESCE(m.id, ''),
+			COALESCE(ts_headline('russian', m.content, q.query, `+options+`), ''),
+			COALESCE(m.hits, 0)
+		FROM discussions d
+		CROSS JOIN q
+		LEFT JOIN LATERAL (
+			SELECT dm.id, dm.content, ts_rank(dm.search_vector, q.query) AS rank, COUNT(*) OVER () AS hits
+			FROM discussion_messages dm
+			WHERE dm.discussion_id = d.id AND dm.type = 'usual' AND dm.search_vector @@ q.query
+			ORDER BY rank DESC, dm.seq
+			LIMIT 1
+		) m ON true
+		`+where+after+order+limit,
+		args...,
+	)
+	if err != nil {
+		return Page[structures.SearchItem]{}, CursorError(err, filter.PageRequest)
+	}
+	defer rows.Close()
+
+	var items []structures.SearchItem
+	var keys []string
+	for rows.Next() {
+		var item structures.SearchItem
+		var keyQuestionsJSON, tagsJSON []byte
+		var sortKey, roomName, customTopic, keyQuestions, description, purpose, messageID, message string
+		var hits int
+
+		targets := append(archiveTargets(&item.ArchiveItem, &keyQuestionsJSON, &tagsJSON), &sortKey,
+			&item.Rank, &roomName, &customTopic, &keyQuestions, &description, &purpose, &messageID, &message, &hits)
+		if err = rows.Scan(targets...); err != nil {
+			return Page[structures.SearchItem]{}, err
+		}
+		if err = parseArchiveItem(&item.ArchiveItem, keyQuestionsJSON, tagsJSON); err != nil {
+			return Page[structures.SearchItem]{}, err
+		}
+
+		item.Snippets = []structures.SearchSnippet{}
+		for _, field := range []struct{ name, text string }{
+			{"room_name", roomName},
+			{"custom_topic", customTopic},
+			{"key_questions", keyQuestions},
+			{"description", description},
+			{"purpose", purpose},
+		} {
+			if strings.Contains(field.text, markStart) {
+				item.Snippets = append(item.Snippets, structures.SearchSnippet{Field: field.name, Text: highlight(field.text)})
+			}
+		}
+		if messageID != "" {
+			item.Snippets = append(item.Snippets, structures.SearchSnippet{
+				Field:     "message",
+				Text:      highlight(message),
+				MessageID: messageID,
+				Matches:   hits,
+			})
+		}
+
+		items = append(items, item)
+		keys = append(keys, sortKey)
+	}
+	if err = rows.Err(); err != nil {
+		return Page[structures.SearchItem]{}, err
+	}
+	return NewPage(items, keys, total, filter.PageRequest, func(item structures.SearchItem) int { return item.ID }), nil
+}
+
+// highlight экранирует фрагмент ts_headline и размечает совпадения тегом <mark>
+func highlight(fragment string) string {
+	escaped := html.EscapeString(fragment)
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(escaped)
+}
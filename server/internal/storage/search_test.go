@@ -0,0 +1,159 @@
+package storage_test
+
+import (
+	"awesomeChat/internal/storage"
+	"awesomeChat/internal/storage/memory"
+	"awesomeChat/internal/structures"
+	"awesomeChat/migrations"
+	"awesomeChat/package/migrate"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// TestSearch один сценарий поиска для хранилища в памяти и для Postgres: память обязана находить
+// и ранжировать так же, как SQL. Postgres проверяется, только если задан TEST_DATABASE_URL;
+// тест работает в своей временной схеме и удаляет ее после себя
+func TestSearch(t *testing.T) {
+	for _, store := range []struct {
+		name string
+		open func(t *testing.T) *storage.Repos
+	}{
+		{"memory", func(t *testing.T) *storage.Repos { return memory.New().Repos() }},
+		{"postgres", postgresRepos},
+	} {
+		store := store
+		t.Run(store.name, func(t *testing.T) {
+			searchScenario(t, store.open(t))
+		})
+	}
+}
+
+func searchScenario(t *testing.T, repos *storage.Repos) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	save := func(name string, edit func(room *structures.Room)) int {
+		t.Helper()
+		room := &structures.Room{
+			ID:              1,
+			Name:            name,
+			Open:            true,
+			Mode:            "personal",
+			SubType:         "free",
+			StartTime:       start,
+			Duration:        time.Hour,
+			CreatorUsername: "alice",
+			Participants:    []string{"alice", "bob"},
+			KeyQuestions:    []string{},
+			Tags:            []string{},
+			ExportOptions:   []string{},
+		}
+		if edit != nil {
+			edit(room)
+		}
+		id, err := repos.Discussions.Save(room)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	message := func(id, content string) structures.Message {
+		return structures.Message{ID: id, Type: "usual", Username: "alice", Content: content, Timestamp: start}
+	}
+
+	byMessage := save("Evening debate", func(room *structures.Room) {
+		room.Messages = []structures.Message{message("m1", "climate policy now"), message("m2", "policy")}
+	})
+	byQuestion := save("Morning debate", func(room *structures.Room) {
+		room.KeyQuestions = []string{"Is climate policy fair?"}
+	})
+	byName := save("Climate policy", nil)
+	// слова есть, но не в одном месте: в описании и в сообщении, в двух разных сообщениях
+	save("Split debate", func(room *structures.Room) {
+		room.Description = "climate"
+		room.Messages = []structures.Message{message("m3", "policy")}
+	})
+	save("Scattered debate", func(room *structures.Room) {
+		room.Messages = []structures.Message{message("m4", "climate"), message("m5", "policy")}
+	})
+
+	page, err := repos.Discussions.Search(storage.SearchFilter{
+		ArchiveFilter: storage.ArchiveFilter{PageRequest: storage.PageRequest{Limit: 10}, Username: "alice"},
+		Query:         "climate policy",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	for _, item := range page.Items {
+		got = append(got, item.ID)
+	}
+	if want := []int{byName, byQuestion, byMessage}; page.Total != 3 || fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("found %v of %d, want %v: room name (A) before key question (B) before message (D)", got, page.Total, want)
+	}
+	for i := 1; i < len(page.Items); i++ {
+		if page.Items[i-1].Rank <= page.Items[i].Rank {
+			t.Fatalf("ranks are not decreasing: %v then %v", page.Items[i-1].Rank, page.Items[i].Rank)
+		}
+	}
+
+	snippets := page.Items[2].Snippets
+	if len(snippets) != 1 || snippets[0].Field != "message" || snippets[0].MessageID != "m1" || snippets[0].Matches != 1 {
+		t.Fatalf("unexpected message snippets %+v", snippets)
+	}
+	if !strings.Contains(snippets[0].Text, "<mark>climate</mark>") {
+		t.Fatalf("match is not marked: %q", snippets[0].Text)
+	}
+}
+
+// postgresRepos хранилища в новой схеме базы TEST_DATABASE_URL со всеми миграциями
+func postgresRepos(t *testing.T) *storage.Repos {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	schema := fmt.Sprintf("test_search_%d", time.Now().UnixNano())
+	if _, err = admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+	})
+
+	// search_path — параметр соединения, поэтому схема одна на все соединения пула
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := u.Query()
+		query.Set("search_path", schema)
+		u.RawQuery = query.Encode()
+		dsn = u.String()
+	} else {
+		dsn += " search_path=" + schema
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err = migrate.Up(db, migrations.FS); err != nil {
+		t.Fatal(err)
+	}
+	return storage.NewPostgres(db)
+}
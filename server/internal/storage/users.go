@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"awesomeChat/internal/organizations"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+type pgUsers struct {
+	db *sql.DB
+}
+
+func NewUserRepo(db *sql.DB) UserRepo {
+	return &pgUsers{db: db}
+}
+
+func (r *pgUsers) Create(user User) (int, error) {
+	var id int
+	err := r.db.QueryRow(
+		"INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING user_id",
+		user.Username,
+		user.Email,
+		user.PasswordHash,
+	).Scan(&id)
+	return id, err
+}
+
+func (r *pgUsers) ByUsername(username string) (User, error) {
+	return r.find("username = $1", username)
+}
+
+func (r *pgUsers) ByLogin(login string) (User, error) {
+	return r.find("username = $1 OR email = $1", login)
+}
+
+func (r *pgUsers) find(where string, value string) (User, error) {
+	var user User
+	err := r.db.QueryRow(`
+		SELECT user_id, username, email, password_hash, COALESCE(locale, '')
+		FROM users
+		WHERE `+where, value).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+	return user, err
+}
+
+func (r *pgUsers) UsernameTaken(username string) (bool, error) {
+	return r.exists("SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)", username)
+}
+
+func (r *pgUsers) EmailTaken(email string) (bool, error) {
+	return r.exists("SELECT EXISTS (SELECT 1 FROM users WHERE email = $1)", email)
+}
+
+func (r *pgUsers) exists(query string, value string) (bool, error) {
+	var taken bool
+	err := r.db.QueryRow(query, value).Scan(&taken)
+	return taken, err
+}
+
+func (r *pgUsers) IDs(usernames []string) (map[string]int, error) {
+	userIDs := make(map[string]int, len(usernames))
+	if len(usernames) == 0 {
+		return userIDs, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT user_id, username
+		FROM users
+		WHERE username = ANY($1)`,
+		pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID int
+		var username string
+		if err = rows.Scan(&userID, &username); err != nil {
+			return nil, err
+		}
+		userIDs[username] = userID
+	}
+	return userIDs, rows.Err()
+}
+
+func (r *pgUsers) List(organizationID int) ([]User, error) {
+	query := `SELECT user_id, username, email, COALESCE(locale, '') FROM users WHERE NOT placeholder ORDER BY user_id`
+	var args []interface{}
+	if organizationID != 0 {
+		query = `SELECT u.user_id, u.username, u.email, COALESCE(u.locale, '') FROM users u
+			JOIN organization_members m ON m.user_id = u.user_id
+			WHERE m.organization_id = $1 AND NOT u.placeholder
+			ORDER BY u.user_id`
+		args = append(args, organizationID)
+	}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err = rows.Scan(&user.ID, &user.Username, &user.Email, &user.Locale); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+func (r *pgUsers) IsMember(organizationID int, username string) (bool, error) {
+	_, err := organizations.Role(r.db, organizationID, username)
+	if errors.Is(err, organizations.ErrNotMember) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,35 @@
+package structures
+
+import "time"
+
+// AgendaBoundary отрезок времени, когда ключевой вопрос обсуждался; вопрос можно открыть повторно
+type AgendaBoundary struct {
+	Index    int       `json:"index"`
+	Question string    `json:"question"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// AgendaCommand сообщение ведущего: перейти к вопросу Index; -1 — закрыть текущий вопрос
+type AgendaCommand struct {
+	Type     string `json:"type"`     // "agenda_set", "agenda_next"
+	Username string `json:"username"` // не используется: ведущий определяется по соединению
+	Index    int    `json:"index"`
+}
+
+type AgendaUpdateMessage struct {
+	Type     string     `json:"type"` // "agenda_update"
+	Index    int        `json:"index"`
+	Total    int        `json:"total"`
+	Question string     `json:"question,omitempty"`
+	TimeBox  int        `json:"timeBox,omitempty"` // в секундах
+	EndsAt   *time.Time `json:"endsAt,omitempty"`
+}
+
+// AgendaSection часть стенограммы, относящаяся к одному ключевому вопросу. Index == -1 — сообщения вне повестки
+type AgendaSection struct {
+	Index     int              `json:"index"`
+	Question  string           `json:"question"`
+	Intervals []AgendaBoundary `json:"intervals"`
+	Messages  []Message        `json:"messages"`
+}
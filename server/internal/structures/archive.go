@@ -1,11 +1,26 @@
 package structures
 
+// ArchiveResponse страница архива. NextCursor передается в ?cursor= за следующей страницей
+// и пуст на последней; page остается для клиентов, листающих по номерам
 type ArchiveResponse struct {
-	Data  []ArchiveItem `json:"data"`
-	Total int           `json:"total"`
-	Page  int           `json:"page"`
-	Limit int           `json:"limit"`
+	Data       []ArchiveItem `json:"data"`
+	Total      int           `json:"total"`
+	Page       int           `json:"page"`
+	Limit      int           `json:"limit"`
+	Sort       string        `json:"sort"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
+
+// PageResponse страница любого другого списка в том же виде, что ArchiveResponse
+type PageResponse[T any] struct {
+	Data       []T    `json:"data"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Sort       string `json:"sort"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 type ArchiveItem struct {
 	ID                int      `json:"id"`
 	Name              string   `json:"name"`
@@ -21,4 +36,28 @@ type ArchiveItem struct {
 	CustomSubtopic    string   `json:"custom_subtopic"`
 	Public            bool     `json:"public"`
 	ParticipantsCount int      `json:"participants_count"`
+	Imported          bool     `json:"imported"`
+}
+
+type SearchResponse struct {
+	Data       []SearchItem `json:"data"`
+	Total      int          `json:"total"`
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
+	Sort       string       `json:"sort"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// SearchItem найденная дискуссия; в сниппетах совпадения обернуты в <mark>, остальной текст экранирован
+type SearchItem struct {
+	ArchiveItem
+	Rank     float64         `json:"rank"`
+	Snippets []SearchSnippet `json:"snippets"`
+}
+
+type SearchSnippet struct {
+	Field     string `json:"field"` // room_name, custom_topic, key_questions, description, purpose или message
+	Text      string `json:"text"`
+	MessageID string `json:"message_id,omitempty"`
+	Matches   int    `json:"matches,omitempty"` // для message — сколько всего сообщений совпало
 }
@@ -0,0 +1,34 @@
+package structures
+
+const (
+	AudiencePollBefore = "before" // голосование зрителей до начала дискуссии
+	AudiencePollAfter  = "after"  // повторное голосование зрителей в последнюю минуту дискуссии
+)
+
+type AudienceVoteMessage struct {
+	Type     string `json:"type"`     // "audience_vote"
+	Username string `json:"username"` // не используется: голос засчитывается за соединение зрителя
+	Thesis   int    `json:"thesis"`   // индекс тезиса в AudiencePollMessage.Theses
+}
+
+type AudiencePollMessage struct {
+	Type   string   `json:"type"` // "audience_poll"
+	Stage  string   `json:"stage"`
+	Theses []string `json:"theses"`
+}
+
+type AudienceResult struct {
+	Theses       []string  `json:"theses"`
+	Before       []int     `json:"before"`
+	After        []int     `json:"after"`
+	BeforeShare  []float64 `json:"before_share"`
+	AfterShare   []float64 `json:"after_share"`
+	ShareChange  []float64 `json:"share_change"`
+	Winner       int       `json:"winner"` // индекс тезиса с наибольшим приростом, -1 если победителя нет
+	WinnerThesis string    `json:"winner_thesis,omitempty"`
+}
+
+type AudienceResultMessage struct {
+	Type   string         `json:"type"` // "audience_result"
+	Result AudienceResult `json:"result"`
+}
@@ -0,0 +1,26 @@
+package structures
+
+type CatalogTopic struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	Deleted   bool              `json:"deleted,omitempty"`
+	Subtopics []CatalogSubtopic `json:"subtopics"`
+}
+
+type CatalogSubtopic struct {
+	ID      int      `json:"id"`
+	TopicID int      `json:"topic_id"`
+	Name    string   `json:"name"`
+	Theses  []string `json:"theses"`
+	Deleted bool     `json:"deleted,omitempty"`
+}
+
+type TopicRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type SubtopicRequest struct {
+	TopicID int      `json:"topic_id" binding:"required"`
+	Name    string   `json:"name" binding:"required"`
+	Theses  []string `json:"theses" binding:"required"`
+}
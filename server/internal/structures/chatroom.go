@@ -14,6 +14,7 @@ type ChatUser struct {
 	ID         int
 	Name       string
 	Connection *websocket.Conn
+	Locale     string // язык системных сообщений для этого соединения
 }
 
 type Room struct {
@@ -41,7 +42,14 @@ type Room struct {
 	ExportOptions []string
 	DontJoin      bool
 
+	AgendaTimeBoxes []int            // лимит времени на ключевой вопрос в секундах; 0 — без лимита
+	AgendaIndex     int              // активный ключевой вопрос, -1 — ни один
+	AgendaLog       []AgendaBoundary // когда обсуждался каждый вопрос, для архива
+	Outcomes        []Outcome        // решения и поручения, сохраняются вместе с дискуссией
+	AgendaRun       int              // счетчик переключений: таймбокс прошлого вопроса не срабатывает
+
 	Hidden          bool
+	OrganizationID  int // 0 — общая комната, иначе видна и доступна только участникам организации
 	ReadyUsers      map[string]bool
 	CreatorUsername string
 	Participants    []string // для кого в архиве будет доступен диалог (пока что берутся просто юзеры в момент старта дискуссии)
@@ -51,11 +59,23 @@ type Room struct {
 	Duration         time.Duration
 
 	Messages []Message `json:"messages"`
+	Session  string    // uuid комнаты: под ним сообщения пишутся в базу, пока дискуссия не сохранена
 	Mu       sync.Mutex
 
 	AssignedTheses []string          // назначенные тезисы для дискуссии
 	UserTheses     map[string]string // маппинг пользователь -> тезис
+	UserTeams      map[string]int    // маппинг пользователь -> номер команды (индекс в AssignedTheses)
 	DiscussionID   int
+
+	Spectators     []*ChatUser    // зрители: видят чат и голосуют, но не пишут
+	AudiencePoll   string         // открытый опрос зрителей: "before", "after" или "" (закрыт)
+	AudienceBefore map[string]int // зритель -> индекс тезиса до дискуссии
+	AudienceAfter  map[string]int // зритель -> индекс тезиса после дискуссии
+
+	Phases        []Phase         // этапы структурированной дискуссии; пусто — свободный чат
+	PhaseIndex    int             // текущий этап, len(Phases) — все этапы завершены
+	PhaseMessages map[string]int  // пользователь -> сообщений в текущем этапе
+	PhaseLog      []PhaseBoundary // границы этапов для архива
 }
 
 type RoomForList struct {
@@ -63,6 +83,7 @@ type RoomForList struct {
 	Name             string   `json:"name"`
 	Open             bool     `json:"open"`
 	Users            int      `json:"users"`
+	Spectators       int      `json:"spectators"`
 	MaxUsers         int      `json:"maxUsers"`
 	Mode             string   `json:"mode"`
 	SubType          string   `json:"subType"`
@@ -79,6 +100,10 @@ type RoomForList struct {
 	DiscussionActive bool     `json:"discussionActive"`
 	Duration         int      `json:"duration"` // в минутах
 	StartTime        string   `json:"startTime,omitempty"`
+	Phases           []Phase  `json:"phases,omitempty"`
+	OrganizationID   int      `json:"organizationId,omitempty"`
+	AgendaIndex      int      `json:"agendaIndex"`
+	AgendaTimeBoxes  []int    `json:"agendaTimeBoxes,omitempty"`
 }
 
 type Message struct {
@@ -94,6 +119,10 @@ type Message struct {
 	DislikedBy   []string       `json:"dislikedBy"`
 	Votes        map[string]int `json:"-"` // username -> vote (-1, 0, 1)
 	TempID       string         `json:"tempId,omitempty"`
+	Key          string         `json:"key,omitempty"`        // ключ каталога i18n у системных сообщений
+	Params       map[string]any `json:"params,omitempty"`     // параметры для локализации на клиенте
+	AgendaItem   *int           `json:"agendaItem,omitempty"` // ключевой вопрос, активный в момент отправки
+	ReplyTo      string         `json:"replyTo,omitempty"`    // id сообщения, на которое отвечают
 }
 
 type RateMessage struct {
@@ -105,8 +134,9 @@ type RateMessage struct {
 }
 
 type FinalRateMessage struct {
-	DiscussionID int      `json:"discussionID"`
-	Type         string   `json:"type"`
-	Users        []string `json:"users"`
-	Criteria     []string `json:"criteria"`
+	DiscussionID int            `json:"discussionID"`
+	Type         string         `json:"type"`
+	Users        []string       `json:"users"`
+	Criteria     []string       `json:"criteria"`
+	Teams        map[string]int `json:"teams,omitempty"` // для командного блица: пользователь -> команда
 }
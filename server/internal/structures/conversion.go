@@ -2,11 +2,13 @@ package structures
 
 import "time"
 
-// MakeRoomList формирует список комнат для фронтенда, пропускает скрытые комнаты
-func MakeRoomList(rooms *map[int]*Room) *[]RoomForList {
-	roomList := make([]RoomForList, 0, len(*rooms))
+// MakeRoomList формирует список комнат для фронтенда, пропускает скрытые комнаты.
+// Комнаты организаций остаются в списке, их отсекает VisibleRooms для каждого клиента
+func MakeRoomList(rooms *Rooms) *[]RoomForList {
+	list := rooms.List()
+	roomList := make([]RoomForList, 0, len(list))
 
-	for _, room := range *rooms {
+	for _, room := range list {
 		if room.Hidden {
 			continue
 		}
@@ -21,6 +23,7 @@ func MakeRoomList(rooms *map[int]*Room) *[]RoomForList {
 			Name:             room.Name,
 			Open:             room.Open,
 			Users:            len(room.Users),
+			Spectators:       len(room.Spectators),
 			MaxUsers:         room.MaxUsers,
 			Mode:             room.Mode,
 			SubType:          room.SubType,
@@ -37,8 +40,33 @@ func MakeRoomList(rooms *map[int]*Room) *[]RoomForList {
 			DiscussionActive: room.DiscussionActive,
 			Duration:         int(room.Duration.Minutes()),
 			StartTime:        startTime,
+			Phases:           room.Phases,
+			OrganizationID:   room.OrganizationID,
+			AgendaIndex:      room.AgendaIndex,
+			AgendaTimeBoxes:  room.AgendaTimeBoxes,
 		})
 	}
 
 	return &roomList
 }
+
+// VisibleRooms комнаты, которые можно показать пользователю из организаций organizationIDs
+func VisibleRooms(rooms []RoomForList, organizationIDs []int) []RoomForList {
+	visible := make([]RoomForList, 0, len(rooms))
+	for _, room := range rooms {
+		if room.OrganizationID != 0 && !containsInt(organizationIDs, room.OrganizationID) {
+			continue
+		}
+		visible = append(visible, room)
+	}
+	return visible
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
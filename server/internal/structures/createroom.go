@@ -0,0 +1,30 @@
+package structures
+
+type CreateRoomRequest struct {
+	Name            string   `json:"name"`
+	Mode            string   `json:"mode"`
+	SubType         string   `json:"subType"`
+	Timer           int      `json:"timer"`
+	MaxParticipants int      `json:"maxUsers"`
+	Description     string   `json:"description"`
+	Password        string   `json:"password"`
+	Purpose         string   `json:"purpose"`
+	KeyQuestions    []string `json:"keyQuestions"`
+	AgendaTimeBoxes []int    `json:"keyQuestionTimeBoxes"` // секунды на каждый ключевой вопрос, 0 — без лимита
+	Tags            []string `json:"tags"`
+	Hidden          bool     `json:"hidden"`
+	ExportOptions   []string `json:"exportOptions"`
+	DontJoin        bool     `json:"dontJoin"`
+	Topic           int      `json:"topic"`          // blitz
+	Subtopic        int      `json:"subtopic"`       // blitz
+	CustomTopic     string   `json:"customTopic"`    // free
+	CustomSubtopic  string   `json:"customSubtopic"` // free
+	Open            bool     `json:"open"`
+	CreatorName     string   `json:"creatorName"`
+	OrganizationID  int      `json:"organizationId"` // комната организации: в лобби ее видят только участники
+
+	Phases []Phase `json:"phases"` // этапы структурированной дискуссии
+
+	// TemplateID шаблон, поверх которого применяются переданные в запросе поля
+	TemplateID int `json:"templateId,omitempty"`
+}
@@ -1,23 +1,31 @@
 package structures
 
 type DiscussionResponse struct {
-	ID            int       `json:"id"`
-	RoomID        int       `json:"room_id"`
-	RoomName      string    `json:"room_name"`
-	Public        bool      `json:"public"`
-	Mode          string    `json:"mode"`
-	SubType       string    `json:"subtype"`
-	Duration      string    `json:"duration"`
-	StartTime     string    `json:"start_time"`
-	EndTime       string    `json:"end_time"`
-	Messages      []Message `json:"messages"`
-	Creator       string    `json:"creator"`
-	KeyQuestions  []string  `json:"key_questions"`
-	Tags          []string  `json:"tags"`
-	ExportOptions []string  `json:"export_options"`
-	Participants  []string  `json:"participants"`
-	Topic         string    `json:"topic"`
-	Subtopic      string    `json:"subtopic"`
-	Description   string    `json:"description"`
-	Purpose       string    `json:"purpose"`
+	ID             int       `json:"id"`
+	RoomID         int       `json:"room_id"`
+	RoomName       string    `json:"room_name"`
+	Public         bool      `json:"public"`
+	OrganizationID int       `json:"organization_id,omitempty"`
+	Mode           string    `json:"mode"`
+	SubType        string    `json:"subtype"`
+	Duration       string    `json:"duration"`
+	StartTime      string    `json:"start_time"`
+	EndTime        string    `json:"end_time"`
+	Messages       []Message `json:"messages"`
+	Creator        string    `json:"creator"`
+	KeyQuestions   []string  `json:"key_questions"`
+	Tags           []string  `json:"tags"`
+	ExportOptions  []string  `json:"export_options"`
+	Participants   []string  `json:"participants"`
+	Topic          string    `json:"topic"`
+	Subtopic       string    `json:"subtopic"`
+	Description    string    `json:"description"`
+	Purpose        string    `json:"purpose"`
+	Imported       bool      `json:"imported"`
+	ImportSource   string    `json:"import_source,omitempty"` // archive, telegram или log
+
+	Teams        []TeamInfo      `json:"teams,omitempty"`
+	Phases       []PhaseBoundary `json:"phases,omitempty"`
+	Agenda       []AgendaSection `json:"agenda,omitempty"` // стенограмма, сгруппированная по ключевым вопросам
+	AudienceVote *AudienceResult `json:"audience_vote,omitempty"`
 }
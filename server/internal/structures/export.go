@@ -0,0 +1,17 @@
+package structures
+
+// ExportArtifact сгенерированная выгрузка дискуссии, доступная для скачивания по URL
+type ExportArtifact struct {
+	Kind        string `json:"kind"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	URL         string `json:"url"`
+	CreatedAt   string `json:"created_at,omitempty"`
+}
+
+type ExportsReadyMessage struct {
+	Type         string           `json:"type"` // "exports_ready"
+	DiscussionID int              `json:"discussionID"`
+	Exports      []ExportArtifact `json:"exports"`
+}
@@ -0,0 +1,29 @@
+package structures
+
+import "time"
+
+// Phase этап структурированной дискуссии со своей длительностью и правилами
+type Phase struct {
+	Name               string `json:"name"`                         // "opening", "rebuttal", "cross_examination", "closing" и т.п.
+	Title              string `json:"title"`                        // название для участников
+	Duration           int    `json:"duration"`                     // в секундах
+	Sides              []int  `json:"sides,omitempty"`              // команды, которым можно писать; пусто — всем
+	MessagesPerSpeaker int    `json:"messagesPerSpeaker,omitempty"` // лимит сообщений на участника за этап; 0 — без лимита
+}
+
+// PhaseBoundary границы прошедшего этапа для архива
+type PhaseBoundary struct {
+	Index int       `json:"index"`
+	Name  string    `json:"name"`
+	Title string    `json:"title"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type PhaseChangeMessage struct {
+	Type   string    `json:"type"` // "phase_change"
+	Index  int       `json:"index"`
+	Total  int       `json:"total"`
+	Phase  Phase     `json:"phase"`
+	EndsAt time.Time `json:"endsAt"`
+}
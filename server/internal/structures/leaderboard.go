@@ -4,6 +4,7 @@ type LeaderboardEntry struct {
 	Rank          int      `json:"rank"`
 	Username      string   `json:"username"`
 	Level         string   `json:"level"`
+	LevelKey      string   `json:"level_key"`
 	Score         float64  `json:"score"`
 	TotalMessages int      `json:"total_messages"`
 	AvgRating     float64  `json:"avg_rating"`
@@ -0,0 +1,22 @@
+package structures
+
+type MatchFoundMessage struct {
+	Type       string `json:"type"` // "match_found"
+	RoomID     int    `json:"roomID"`
+	WsURL      string `json:"wsUrl"`
+	Password   string `json:"password"`
+	Opponent   string `json:"opponent"`
+	TopicID    int    `json:"topic"`
+	SubtopicID int    `json:"subtopic"`
+}
+
+type MatchmakingTimeoutMessage struct {
+	Type    string `json:"type"` // "matchmaking_timeout"
+	Content string `json:"content"`
+}
+
+// MatchCancelledMessage найденный матч отменен, потому что соперник ушел из лобби; игрок снова в очереди
+type MatchCancelledMessage struct {
+	Type    string `json:"type"` // "match_cancelled"
+	Content string `json:"content"`
+}
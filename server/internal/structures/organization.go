@@ -0,0 +1,38 @@
+package structures
+
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type Organization struct {
+	ID        int                  `json:"id"`
+	Name      string               `json:"name"`
+	Role      string               `json:"role"` // роль запросившего пользователя
+	CreatedAt string               `json:"created_at"`
+	Members   []OrganizationMember `json:"members,omitempty"`
+}
+
+type OrganizationMember struct {
+	Username string `json:"username"`
+	Role     string `json:"role"` // "admin" или "member"
+	JoinedAt string `json:"joined_at"`
+}
+
+type InviteRequest struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role"` // по умолчанию "member"
+}
+
+type MemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type OrganizationInvite struct {
+	ID           int    `json:"id"`
+	Organization string `json:"organization"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	Token        string `json:"token,omitempty"`
+	InvitedBy    string `json:"invited_by"`
+	ExpiresAt    string `json:"expires_at"`
+}
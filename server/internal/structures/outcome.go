@@ -0,0 +1,50 @@
+package structures
+
+import "time"
+
+const (
+	OutcomeDecision   = "decision"
+	OutcomeActionItem = "action_item"
+)
+
+// OutcomeCommand команда участника: "outcome_decision", "outcome_action" или "outcome_mark_decision"
+type OutcomeCommand struct {
+	Type      string `json:"type"`
+	Username  string `json:"username"`
+	Content   string `json:"content"`
+	Assignee  string `json:"assignee"`  // для поручения — участник дискуссии
+	DueDate   string `json:"dueDate"`   // для поручения, "2006-01-02"
+	MessageID string `json:"messageID"` // для outcome_mark_decision
+}
+
+// Outcome решение или поручение, зафиксированное во время дискуссии
+type Outcome struct {
+	ID           int        `json:"id,omitempty"`
+	DiscussionID int        `json:"discussion_id,omitempty"`
+	Kind         string     `json:"kind"`
+	Content      string     `json:"content"`
+	MessageID    string     `json:"message_id,omitempty"`
+	AgendaItem   *int       `json:"agenda_item,omitempty"`
+	Author       string     `json:"author"`
+	Assignee     string     `json:"assignee,omitempty"`
+	DueDate      string     `json:"due_date,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+type OutcomeAddedMessage struct {
+	Type    string  `json:"type"` // "outcome_added"
+	Outcome Outcome `json:"outcome"`
+}
+
+type OutcomesResponse struct {
+	DiscussionID int       `json:"discussion_id"`
+	Decisions    []Outcome `json:"decisions"`
+	ActionItems  []Outcome `json:"action_items"`
+}
+
+// ActionItem поручение пользователя вместе с контекстом дискуссии
+type ActionItem struct {
+	Outcome
+	RoomName string `json:"room_name"`
+}
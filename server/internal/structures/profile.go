@@ -18,9 +18,12 @@ type Statistics struct {
 }
 
 type Gamification struct {
-	Level          string   `json:"level"`
-	LevelProgress  float64  `json:"level_progress"`
-	NextLevel      string   `json:"next_level"`
-	Achievements   []string `json:"achievements"`
-	RankingFactors []string `json:"ranking_factors"`
+	Level           string   `json:"level"`
+	LevelKey        string   `json:"level_key"`
+	LevelProgress   float64  `json:"level_progress"`
+	NextLevel       string   `json:"next_level"`
+	NextLevelKey    string   `json:"next_level_key"`
+	Achievements    []string `json:"achievements"`
+	AchievementKeys []string `json:"achievement_keys"`
+	RankingFactors  []string `json:"ranking_factors"`
 }
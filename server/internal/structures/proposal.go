@@ -0,0 +1,33 @@
+package structures
+
+type ProposalRequest struct {
+	TopicID int      `json:"topic_id" binding:"required"`
+	Name    string   `json:"name" binding:"required"`
+	Theses  []string `json:"theses" binding:"required"`
+}
+
+type Proposal struct {
+	ID           int      `json:"id"`
+	Author       string   `json:"author"`
+	TopicID      int      `json:"topic_id"`
+	Topic        string   `json:"topic"`
+	Name         string   `json:"name"`
+	Theses       []string `json:"theses"`
+	Status       string   `json:"status"` // "pending", "approved", "rejected", "promoted"
+	RejectReason string   `json:"reject_reason,omitempty"`
+	SubtopicID   int      `json:"subtopic_id,omitempty"`
+	Upvotes      int      `json:"upvotes"`
+	Downvotes    int      `json:"downvotes"`
+	Score        int      `json:"score"`
+	Promotable   bool     `json:"promotable"` // одобрено и набрало рейтинг для продвижения модератором
+	MyVote       int      `json:"my_vote"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+type RejectRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type ProposalVoteRequest struct {
+	Vote int `json:"vote"` // -1, 0, 1
+}
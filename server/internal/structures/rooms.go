@@ -0,0 +1,64 @@
+package structures
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+)
+
+var ErrNoFreeRoom = errors.New("all room numbers are taken")
+
+// Rooms активные комнаты по номеру. Их создают и удаляют HTTP-обработчики, вебсокеты комнат и
+// очередь матчмейкинга из разных горутин, поэтому к карте обращаются только через методы
+type Rooms struct {
+	mu    sync.RWMutex
+	rooms map[int]*Room
+}
+
+func NewRooms() *Rooms {
+	return &Rooms{rooms: make(map[int]*Room)}
+}
+
+func (r *Rooms) Get(id int) (*Room, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	room, ok := r.rooms[id]
+	return room, ok
+}
+
+// Add выбирает случайный свободный номер меньше limit, записывает его в room.ID и добавляет комнату
+func (r *Rooms) Add(room *Room, limit int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.rooms) >= limit {
+		return ErrNoFreeRoom
+	}
+	for {
+		id := rand.Intn(limit)
+		if _, ok := r.rooms[id]; !ok {
+			room.ID = id
+			r.rooms[id] = room
+			return nil
+		}
+	}
+}
+
+// Remove удаляет комнату, если под ее номером все еще она, а не новая комната с тем же номером
+func (r *Rooms) Remove(room *Room) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rooms[room.ID] == room {
+		delete(r.rooms, room.ID)
+	}
+}
+
+// List снимок всех комнат в произвольном порядке
+func (r *Rooms) List() []*Room {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*Room, 0, len(r.rooms))
+	for _, room := range r.rooms {
+		list = append(list, room)
+	}
+	return list
+}
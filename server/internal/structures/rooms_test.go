@@ -0,0 +1,62 @@
+package structures
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestRoomsConcurrent комнаты создаются, читаются и удаляются из разных горутин, как это делают
+// обработчики, вебсокеты и очередь матчмейкинга; гонки ловит go test -race
+func TestRoomsConcurrent(t *testing.T) {
+	rooms := NewRooms()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				room := &Room{}
+				if err := rooms.Add(room, 1000); err != nil {
+					t.Error(err)
+					return
+				}
+				if got, ok := rooms.Get(room.ID); !ok || got != room {
+					t.Errorf("room %d is not stored", room.ID)
+				}
+				MakeRoomList(rooms)
+				rooms.Remove(room)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if list := rooms.List(); len(list) != 0 {
+		t.Fatalf("%d rooms left", len(list))
+	}
+}
+
+func TestRoomsAdd(t *testing.T) {
+	rooms := NewRooms()
+	first, second := &Room{}, &Room{}
+	if err := rooms.Add(first, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := rooms.Add(second, 2); err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("both rooms got number %d", first.ID)
+	}
+	if err := rooms.Add(&Room{}, 2); !errors.Is(err, ErrNoFreeRoom) {
+		t.Fatalf("add to a full list: %v", err)
+	}
+
+	// старая комната не удаляет новую, занявшую ее номер
+	rooms.Remove(first)
+	stale := &Room{ID: second.ID}
+	rooms.Remove(stale)
+	if _, ok := rooms.Get(second.ID); !ok {
+		t.Fatal("another room with the same number was removed")
+	}
+}
@@ -0,0 +1,34 @@
+package structures
+
+// TeamInfo сторона в командном блице: все участники команды защищают один тезис
+type TeamInfo struct {
+	Team    int      `json:"team"`
+	Thesis  string   `json:"thesis"`
+	Members []string `json:"members"`
+}
+
+type TeamAssignmentMessage struct {
+	Type      string   `json:"type"` // "team_assignment"
+	Team      int      `json:"team"`
+	Thesis    string   `json:"thesis"`
+	Teammates []string `json:"teammates"`
+}
+
+// MakeTeamList собирает состав команд комнаты в порядке AssignedTheses
+func MakeTeamList(room *Room) []TeamInfo {
+	if len(room.UserTeams) == 0 {
+		return nil
+	}
+
+	teams := make([]TeamInfo, len(room.AssignedTheses))
+	for i, thesis := range room.AssignedTheses {
+		teams[i] = TeamInfo{Team: i, Thesis: thesis, Members: []string{}}
+	}
+	for _, username := range room.Participants {
+		if team, ok := room.UserTeams[username]; ok && team < len(teams) {
+			teams[team].Members = append(teams[team].Members, username)
+		}
+	}
+
+	return teams
+}
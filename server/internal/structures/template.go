@@ -0,0 +1,18 @@
+package structures
+
+type TemplateRequest struct {
+	Name           string            `json:"name" binding:"required"`
+	OrganizationID int               `json:"organization_id"` // 0 — личный шаблон, иначе общий для организации
+	Settings       CreateRoomRequest `json:"settings"`
+}
+
+// RoomTemplate сохраненные параметры создания комнаты; пароль в шаблоне не хранится
+type RoomTemplate struct {
+	ID             int               `json:"id"`
+	Owner          string            `json:"owner"`
+	Name           string            `json:"name"`
+	OrganizationID int               `json:"organization_id,omitempty"`
+	Settings       CreateRoomRequest `json:"settings"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+}
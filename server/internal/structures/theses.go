@@ -1,135 +0,0 @@
-package structures
-
-var ThesesDB = map[int][]string{
-	// технологии (id:1)
-	101: {
-		"ИИ создаст больше новых профессий, чем уничтожит, повысив качество жизни",
-		"ИИ приведет к массовой безработице и социальному расслоению",
-	},
-	102: {
-		"Этические стандарты должны быть обязательными для всех технологических компаний",
-		"Строгая этика тормозит инновации и прогресс в разработке технологий",
-	},
-	103: {
-		"Квантовые компьютеры произведут революцию в науке уже в ближайшие 5 лет",
-		"Практическое применение квантовых вычислений останется нишевым еще десятилетия",
-	},
-	104: {
-		"IoT сделает города умнее и безопаснее для жителей",
-		"Всеобщая подключенность устройств создает угрозу приватности",
-	},
-	105: {
-		"Кибербезопасность должна регулироваться на государственном уровне",
-		"Безопасность — личная ответственность каждого пользователя",
-	},
-
-	// здоровье (id:2)
-	201: {
-		"Веганство — самый здоровый и экологичный тип питания",
-		"Сбалансированное питание с мясом необходимо для здоровья",
-	},
-	202: {
-		"10 000 шагов в день — обязательный минимум для каждого",
-		"Интенсивные короткие тренировки эффективнее ежедневной ходьбы",
-	},
-	203: {
-		"Психические расстройства нужно лечить только медикаментозно",
-		"Терапия без лекарств эффективнее в долгосрочной перспективе",
-	},
-	204: {
-		"Медитация должна стать частью обязательной школьной программы",
-		"Медитация — религиозная практика, недопустимая в образовании",
-	},
-	205: {
-		"Всеобщая вакцинация — единственный способ предотвратить эпидемии",
-		"Массовая вакцинация опасна из-за побочных эффектов",
-	},
-
-	// образование (id:3)
-	301: {
-		"Онлайн-курсы полностью заменят университеты к 2030 году",
-		"Очное образование сохранит главную роль в подготовке специалистов",
-	},
-	302: {
-		"Классическая литература обязательна для развития критического мышления",
-		"Современные книги лучше отражают актуальные проблемы общества",
-	},
-	303: {
-		"Изучать языки эффективнее через живое общение, а не приложения",
-		"Цифровые сервисы делают изучение языков доступнее и быстрее",
-	},
-	304: {
-		"Программирование — обязательный навык для всех профессий будущего",
-		"Узкая специализация важнее базовых цифровых навыков",
-	},
-	305: {
-		"VR-технологии революционизируют образовательный процесс",
-		"VR — дорогая игрушка, не способная заменить реальные эксперименты",
-	},
-
-	// искусство (id:4)
-	401: {
-		"Современное искусство отражает дух времени лучше классики",
-		"Модерн-арт — провокация, не имеющая художественной ценности",
-	},
-	402: {
-		"Классическая музыка развивает интеллект и должна быть в школе",
-		"Популярная музыка лучше отвечает запросам современной молодежи",
-	},
-	403: {
-		"Кино стало важнее театра в современной культуре",
-		"Театр сохраняет подлинность, которую не заменит киноэффектами",
-	},
-	404: {
-		"Поэзия умерла как формат в цифровую эпоху",
-		"Соцсети дали поэзии новую жизнь через короткие формы",
-	},
-	405: {
-		"Реставрация памятников важнее нового строительства",
-		"Города должны развиваться, а не сохранять старые здания",
-	},
-
-	// путешествия (id:5)
-	501: {
-		"Популярные направления страдают от массового туризма",
-		"Туризм — главный источник развития для регионов",
-	},
-	502: {
-		"Путешествия должны быть образовательными, а не развлекательными",
-		"Отдых — главная цель туризма, а не изучение культуры",
-	},
-	503: {
-		"Экстремальный туризм должен быть законодательно ограничен",
-		"Человек сам вправе решать, какой риск допустим",
-	},
-	504: {
-		"Путешествия с детьми — лучший способ семейного воспитания",
-		"С малышами стоит отдыхать локально, без дальних поездок",
-	},
-	505: {
-		"Профессиональная техника — обязательное условие для тревел-фото",
-		"Спонтанные снимки на телефон лучше передают атмосферу",
-	},
-
-	// экология (id:6)
-	601: {
-		"Ветряки и солнечные панели должны полностью заменить ТЭС к 2040",
-		"ВИЭ нестабильны и не могут быть основным источником энергии",
-	},
-	602: {
-		"Раздельный сбор мусора должен быть законодательно обязательным",
-		"Переработка — дело бизнеса, а не отдельных людей",
-	},
-	603: {
-		"Зеленые технологии должны субсидироваться государством",
-		"Рынок сам определит наиболее эффективные экологичные решения",
-	},
-	604: {
-		"Человек должен вмешиваться для сохранения вымирающих видов",
-		"Природа сама регулирует биоразнообразие без нашего участия",
-	},
-	605: {
-		"Zero waste — обязанность каждого сознательного гражданина",
-		"Устойчивое потребление — утопия, недостижимая в капитализме",
-	},
-}
@@ -1,21 +0,0 @@
-package structures
-
-var TopicDB = map[int]string{
-	// технологии (1xx)
-	1: "Технологии",
-
-	// здоровье (2xx)
-	2: "Здоровье",
-
-	// образование (3xx)
-	3: "Образование",
-
-	// искусство (4xx)
-	4: "Искусство",
-
-	// путешествия (5xx)
-	5: "Путешествия",
-
-	// экология (6xx)
-	6: "Экология",
-}
@@ -2,21 +2,40 @@ package main
 
 import (
 	"awesomeChat/internal/auth"
+	"awesomeChat/internal/catalog"
 	"awesomeChat/internal/handlers"
+	"awesomeChat/internal/i18n"
+	"awesomeChat/internal/matchmaking"
+	_ "awesomeChat/internal/modes/blitz"
+	_ "awesomeChat/internal/modes/free"
+	_ "awesomeChat/internal/modes/professional"
 	"awesomeChat/internal/myws"
+	"awesomeChat/internal/organizations"
+	"awesomeChat/internal/storage"
 	"awesomeChat/internal/structures"
+	"awesomeChat/migrations"
 	"awesomeChat/package/config"
 	"awesomeChat/package/database"
 	"awesomeChat/package/logger"
+	"awesomeChat/package/migrate"
 	"awesomeChat/package/web"
 	"database/sql"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"io"
+	"net/http"
+	"os"
 	"sort"
+	"strconv"
 	"time"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
 	logger.Log.Infoln("Sleeping 10s for database to start...")
 	time.Sleep(10 * time.Second)
 	cfg := config.GetConfig()
@@ -29,6 +48,21 @@ func main() {
 		}
 	}(db)
 
+	applied, err := migrate.Up(db, migrations.FS)
+	if err != nil {
+		logger.Log.Fatalln("Error applying migrations: " + err.Error())
+	}
+	for _, migration := range applied {
+		logger.Log.Infof("Applied migration %02d_%s", migration.Version, migration.Name)
+	}
+
+	// хранилища пользователей, дискуссий и оценок для обработчиков
+	repos := storage.NewPostgres(db)
+
+	if err := catalog.Load(db); err != nil {
+		logger.Log.Fatalln("Error loading topic catalog: " + err.Error())
+	}
+
 	logger.Log.Infoln("Starting service...")
 	router := gin.Default()
 	gin.SetMode(gin.ReleaseMode)
@@ -36,58 +70,269 @@ func main() {
 	router.Use(web.CORSMiddleware())
 
 	server := myws.NewWebSocketServer()
-	var rooms = make(map[int]*structures.Room)
+	rooms := structures.NewRooms()
+
+	queue := matchmaking.NewQueue()
+	queue.PickSubtopic = handlers.PickRandomSubtopic
+	queue.OnMatch = func(match matchmaking.Match) {
+		room, err := handlers.CreateMatchedRoom(rooms, match)
+		if err != nil {
+			logger.Log.Errorln("Can not create matched room:", err)
+			return
+		}
+		var notified []matchmaking.Ticket
+		for _, pair := range [][2]matchmaking.Ticket{
+			{match.First, match.Second},
+			{match.Second, match.First},
+		} {
+			sent := server.SendToUser(pair[0].Username, structures.MatchFoundMessage{
+				Type:       "match_found",
+				RoomID:     room.ID,
+				WsURL:      "/ws/chat/" + strconv.Itoa(room.ID),
+				Password:   room.Password,
+				Opponent:   pair[1].Username,
+				TopicID:    room.TopicID,
+				SubtopicID: room.SubtopicID,
+			})
+			if sent {
+				notified = append(notified, pair[0])
+			}
+		}
+		if len(notified) == 2 {
+			return
+		}
+
+		// кого-то из пары нет в лобби: в скрытую комнату он не попадет, поэтому она удаляется,
+		// а оставшийся игрок возвращается в очередь со своим временем ожидания
+		rooms.Remove(room)
+		logger.Log.Tracef("Matched room %d removed: %s and %s are not both in the lobby",
+			room.ID, match.First.Username, match.Second.Username)
+		for _, ticket := range notified {
+			server.SendToUser(ticket.Username, structures.MatchCancelledMessage{
+				Type:    "match_cancelled",
+				Content: i18n.T(ticket.Locale, "match_cancelled", nil),
+			})
+			if err = queue.Join(ticket); err != nil {
+				logger.Log.Errorln("Can not requeue", ticket.Username, err)
+			}
+		}
+	}
+	queue.OnTimeout = func(ticket matchmaking.Ticket) {
+		server.SendToUser(ticket.Username, structures.MatchmakingTimeoutMessage{
+			Type:    "matchmaking_timeout",
+			Content: i18n.T(ticket.Locale, "matchmaking_timeout", nil),
+		})
+	}
 
 	logger.Log.Infoln("Serving handlers...")
 
 	router.POST("/login", func(c *gin.Context) {
-		handlers.Login(c, db)
+		handlers.Login(c, repos.Users)
 	})
 	router.GET("/logout", func(c *gin.Context) {
 		//login(c, db) //todo
 	})
 	router.POST("/register", func(c *gin.Context) {
-		handlers.Register(c, db)
+		handlers.Register(c, repos.Users)
 	})
-	router.GET("/ws/chat/:num", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.ConnectToChatroom(c, db, &rooms)
+	router.GET("/ws/chat/:num", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.ConnectToChatroom(c, db, repos, rooms)
 	})
-	router.POST("/createChatroom/", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.CreateChatroom(c, &rooms)
+	router.POST("/createChatroom/", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.CreateChatroom(c, db, rooms)
 	})
 	router.GET("/roomUpdates", func(c *gin.Context) {
-		server.HandleConnections(c.Writer, c.Request, &rooms)
+		server.HandleConnections(c.Writer, c.Request, rooms)
+	})
+	router.POST("/rate/final", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.RateOpponent(c, repos)
+	})
+	router.GET("/discussion/:id", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionByID(c, repos)
+	})
+	router.GET("/discussion/:id/export/markdown", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionMarkdown(c, db, repos)
+	})
+	router.GET("/discussion/:id/export/html", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionHTML(c, db, repos)
+	})
+	router.GET("/discussion/:id/export/pdf", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionPDF(c, db, repos)
+	})
+	router.GET("/discussion/:id/exports", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionExports(c, db, repos)
+	})
+	router.GET("/discussion/:id/exports/:kind", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionExport(c, db, repos)
+	})
+	router.GET("/discussion/:id/outcomes", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionOutcomes(c, db, repos)
+	})
+	router.GET("/action-items", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetMyActionItems(c, db)
+	})
+	router.POST("/action-items/:id/complete", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.CompleteActionItem(c, db)
+	})
+	router.GET("/discussion/:id/export/csv", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionCSVByID(c, repos)
+	})
+	router.GET("/discussion/:id/export/messages", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionMessages(c, db, repos)
+	})
+	router.GET("/discussion/:id/export/graph", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetDiscussionGraphByID(c, repos)
+	})
+	router.GET("/archive", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetArchives(c, repos)
+	})
+	router.GET("/archive/search", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.SearchArchives(c, repos)
+	})
+	router.GET("/archive/graph", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetArchiveGraph(c, db, repos)
+	})
+	router.GET("/profile", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetProfile(c, repos)
+	})
+	router.PUT("/profile/locale", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.SetLocale(c, db)
+	})
+	router.GET("/locales", func(c *gin.Context) {
+		handlers.GetLocales(c)
 	})
-	router.POST("/rate/final", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.RateOpponent(c, db)
+	router.GET("/leaderboard", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetLeaderboard(c, repos)
 	})
-	router.GET("/discussion/:id", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.GetDiscussionByID(c, db)
+	router.POST("/matchmaking/join", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.JoinMatchmaking(c, db, repos, queue)
 	})
-	router.GET("/discussion/:id/export/csv", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.GetDiscussionCSVByID(c, db)
+	router.POST("/matchmaking/cancel", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.CancelMatchmaking(c, queue)
 	})
-	router.GET("/discussion/:id/export/graph", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.GetDiscussionGraphByID(c, db)
+	router.GET("/matchmaking/status", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetMatchmakingStatus(c, queue)
 	})
-	router.GET("/archive", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.GetArchives(c, db)
+	router.GET("/catalog/topics", func(c *gin.Context) {
+		handlers.GetCatalog(c)
 	})
-	router.GET("/profile", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.GetProfile(c, db)
+	router.GET("/catalog/subtopics/:id", func(c *gin.Context) {
+		handlers.GetCatalogSubtopic(c)
 	})
-	router.GET("/leaderboard", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.GetLeaderboard(c, db)
+	admin := router.Group("/admin", auth.AuthMiddleware(db), auth.AdminMiddleware(db))
+	admin.POST("/catalog/topics", func(c *gin.Context) {
+		handlers.CreateTopic(c, db)
 	})
-	router.GET("/room/:id/details", auth.AuthMiddleware(), func(c *gin.Context) {
-		handlers.GetRoomDetails(c, &rooms)
+	admin.PUT("/catalog/topics/:id", func(c *gin.Context) {
+		handlers.UpdateTopic(c, db)
 	})
+	admin.DELETE("/catalog/topics/:id", func(c *gin.Context) {
+		handlers.DeleteTopic(c, db)
+	})
+	admin.POST("/catalog/subtopics", func(c *gin.Context) {
+		handlers.CreateSubtopic(c, db)
+	})
+	admin.PUT("/catalog/subtopics/:id", func(c *gin.Context) {
+		handlers.UpdateSubtopic(c, db)
+	})
+	admin.DELETE("/catalog/subtopics/:id", func(c *gin.Context) {
+		handlers.DeleteSubtopic(c, db)
+	})
+	research := router.Group("/research", auth.AuthMiddleware(db), auth.ResearcherMiddleware(db))
+	research.GET("/dataset", func(c *gin.Context) {
+		handlers.GetResearchDataset(c, db, repos)
+	})
+	router.GET("/templates", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetTemplates(c, db)
+	})
+	router.GET("/templates/:id", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetTemplate(c, db)
+	})
+	router.POST("/templates", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.CreateTemplate(c, db)
+	})
+	router.PUT("/templates/:id", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.UpdateTemplate(c, db)
+	})
+	router.DELETE("/templates/:id", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.DeleteTemplate(c, db)
+	})
+	router.POST("/organizations", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.CreateOrganization(c, db)
+	})
+	router.GET("/organizations", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetOrganizations(c, db)
+	})
+	router.GET("/organizations/:id", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetOrganization(c, db)
+	})
+	router.POST("/organizations/:id/invites", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.InviteMember(c, db)
+	})
+	router.GET("/organizations/:id/invites", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetOrganizationInvites(c, db)
+	})
+	router.PUT("/organizations/:id/members/:member", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.UpdateMemberRole(c, db)
+	})
+	router.DELETE("/organizations/:id/members/:member", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.RemoveMember(c, db)
+	})
+	router.GET("/invites", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetMyInvites(c, db)
+	})
+	router.POST("/invites/:token/accept", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.AcceptInvite(c, db)
+	})
+	router.POST("/proposals", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.ProposeSubtopic(c, db)
+	})
+	router.GET("/proposals", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetProposals(c, db)
+	})
+	router.POST("/proposals/:id/vote", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.VoteProposal(c, db)
+	})
+	admin.GET("/proposals", func(c *gin.Context) {
+		handlers.GetModerationQueue(c, db)
+	})
+	admin.POST("/proposals/:id/approve", func(c *gin.Context) {
+		handlers.ApproveProposal(c, db)
+	})
+	admin.POST("/proposals/:id/reject", func(c *gin.Context) {
+		handlers.RejectProposal(c, db)
+	})
+	admin.POST("/proposals/:id/promote", func(c *gin.Context) {
+		handlers.PromoteProposal(c, db)
+	})
+	admin.POST("/import", func(c *gin.Context) {
+		handlers.ImportDiscussion(c, db)
+	})
+	router.GET("/room/:id/details", auth.AuthMiddleware(db), func(c *gin.Context) {
+		handlers.GetRoomDetails(c, db, rooms)
+	})
+
+	server.Authenticate = func(r *http.Request) string {
+		username, err := auth.TokenUsername(r, db)
+		if err != nil && !errors.Is(err, auth.ErrUnauthenticated) {
+			logger.Log.Errorln("Token user query error:", err)
+		}
+		return username
+	}
+	server.Memberships = func(username string) []int {
+		ids, err := organizations.MemberOf(db, username)
+		if err != nil {
+			logger.Log.Errorln("Memberships query error:", err)
+		}
+		return ids
+	}
 
 	go server.HandleMessages()
+	go queue.Run()
 	go func() {
 		for {
 			time.Sleep(5 * time.Second)
-			roomsToSend := *structures.MakeRoomList(&rooms)
+			roomsToSend := *structures.MakeRoomList(rooms)
 			sort.Slice(roomsToSend, func(i, j int) bool {
 				return (roomsToSend)[i].ID < (roomsToSend)[j].ID
 			})
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"awesomeChat/migrations"
+	"awesomeChat/package/config"
+	"awesomeChat/package/database"
+	"awesomeChat/package/logger"
+	"awesomeChat/package/migrate"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: awesomeChat migrate up | down [steps] | status"
+
+// runMigrate подкоманда migrate: up применяет ожидающие миграции, down откатывает последние
+// (по умолчанию одну), status печатает состояние каждой
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		logger.Log.Fatalln(migrateUsage)
+	}
+
+	db := database.InitPostgres(config.GetConfig())
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrate.Up(db, migrations.FS)
+		for _, migration := range applied {
+			logger.Log.Infof("Applied migration %02d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			logger.Log.Fatalln("Error applying migrations: " + err.Error())
+		}
+		if len(applied) == 0 {
+			logger.Log.Infoln("Database is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				logger.Log.Fatalln(migrateUsage)
+			}
+		}
+		reverted, err := migrate.Down(db, migrations.FS, steps)
+		for _, migration := range reverted {
+			logger.Log.Infof("Reverted migration %02d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			logger.Log.Fatalln("Error reverting migrations: " + err.Error())
+		}
+	case "status":
+		states, err := migrate.Status(db, migrations.FS)
+		if err != nil {
+			logger.Log.Fatalln("Error reading migration status: " + err.Error())
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, state := range states {
+			status, appliedAt := "pending", ""
+			if state.AppliedAt != nil {
+				status, appliedAt = "applied", state.AppliedAt.Format(time.RFC3339)
+			}
+			if state.Missing {
+				status = "missing"
+			}
+			fmt.Fprintf(writer, "%02d\t%s\t%s\t%s\n", state.Version, state.Name, status, appliedAt)
+		}
+		writer.Flush()
+	default:
+		logger.Log.Fatalln(migrateUsage)
+	}
+}
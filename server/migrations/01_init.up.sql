@@ -6,7 +6,7 @@ CREATE TABLE IF NOT EXISTS users (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
-CREATE TABLE discussions (
+CREATE TABLE IF NOT EXISTS discussions (
     id SERIAL PRIMARY KEY,
     room_id INT NOT NULL,
     room_name VARCHAR(64) NOT NULL,
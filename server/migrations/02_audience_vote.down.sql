@@ -0,0 +1 @@
+ALTER TABLE discussions DROP COLUMN IF EXISTS audience_vote;
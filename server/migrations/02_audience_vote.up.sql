@@ -0,0 +1 @@
+ALTER TABLE discussions ADD COLUMN IF NOT EXISTS audience_vote JSONB;
@@ -0,0 +1,4 @@
+ALTER TABLE ratings DROP COLUMN IF EXISTS rated_team;
+ALTER TABLE ratings DROP COLUMN IF EXISTS rater_team;
+
+ALTER TABLE discussions DROP COLUMN IF EXISTS teams;
@@ -0,0 +1,4 @@
+ALTER TABLE discussions ADD COLUMN IF NOT EXISTS teams JSONB;
+
+ALTER TABLE ratings ADD COLUMN IF NOT EXISTS rater_team INT;
+ALTER TABLE ratings ADD COLUMN IF NOT EXISTS rated_team INT;
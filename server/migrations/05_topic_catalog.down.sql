@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+
+DROP TABLE IF EXISTS theses;
+DROP TABLE IF EXISTS subtopics;
+DROP TABLE IF EXISTS topics;
@@ -0,0 +1,135 @@
+CREATE TABLE IF NOT EXISTS topics (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS subtopics (
+    id SERIAL PRIMARY KEY,
+    topic_id INT NOT NULL REFERENCES topics(id),
+    name TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS theses (
+    id SERIAL PRIMARY KEY,
+    subtopic_id INT NOT NULL REFERENCES subtopics(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    content TEXT NOT NULL,
+    UNIQUE (subtopic_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_subtopics_topic ON subtopics(topic_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT false;
+
+INSERT INTO topics (id, name) VALUES
+    (1, 'Технологии'),
+    (2, 'Здоровье'),
+    (3, 'Образование'),
+    (4, 'Искусство'),
+    (5, 'Путешествия'),
+    (6, 'Экология')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO subtopics (id, topic_id, name) VALUES
+    (101, 1, 'Как ИИ влияет на рынок труда?'),
+    (102, 1, 'Этика в разработке новых технологий'),
+    (103, 1, 'Развитие квантовых вычислений'),
+    (104, 1, 'Интернет вещей (IoT)'),
+    (105, 1, 'Кибербезопасность'),
+    (201, 2, 'Здоровое питание'),
+    (202, 2, 'Физическая активность'),
+    (203, 2, 'Психическое здоровье'),
+    (204, 2, 'Медитация и релаксация'),
+    (205, 2, 'Профилактика заболеваний'),
+    (301, 3, 'Онлайн-курсы'),
+    (302, 3, 'Чтение и литература'),
+    (303, 3, 'Языковое обучение'),
+    (304, 3, 'Навыки будущего'),
+    (305, 3, 'Образовательные технологии'),
+    (401, 4, 'Современное искусство'),
+    (402, 4, 'Классическая музыка'),
+    (403, 4, 'Кино и театр'),
+    (404, 4, 'Литература и поэзия'),
+    (405, 4, 'Культурное наследие'),
+    (501, 5, 'Популярные направления'),
+    (502, 5, 'Культурные путешествия'),
+    (503, 5, 'Приключенческий туризм'),
+    (504, 5, 'Путешествия с семьей'),
+    (505, 5, 'Фотография путешествий'),
+    (601, 6, 'Возобновляемая энергия'),
+    (602, 6, 'Эко-инициативы'),
+    (603, 6, 'Зеленые технологии'),
+    (604, 6, 'Сохранение биоразнообразия'),
+    (605, 6, 'Устойчивое потребление')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO theses (subtopic_id, position, content) VALUES
+    (101, 0, 'ИИ создаст больше новых профессий, чем уничтожит, повысив качество жизни'),
+    (101, 1, 'ИИ приведет к массовой безработице и социальному расслоению'),
+    (102, 0, 'Этические стандарты должны быть обязательными для всех технологических компаний'),
+    (102, 1, 'Строгая этика тормозит инновации и прогресс в разработке технологий'),
+    (103, 0, 'Квантовые компьютеры произведут революцию в науке уже в ближайшие 5 лет'),
+    (103, 1, 'Практическое применение квантовых вычислений останется нишевым еще десятилетия'),
+    (104, 0, 'IoT сделает города умнее и безопаснее для жителей'),
+    (104, 1, 'Всеобщая подключенность устройств создает угрозу приватности'),
+    (105, 0, 'Кибербезопасность должна регулироваться на государственном уровне'),
+    (105, 1, 'Безопасность — личная ответственность каждого пользователя'),
+    (201, 0, 'Веганство — самый здоровый и экологичный тип питания'),
+    (201, 1, 'Сбалансированное питание с мясом необходимо для здоровья'),
+    (202, 0, '10 000 шагов в день — обязательный минимум для каждого'),
+    (202, 1, 'Интенсивные короткие тренировки эффективнее ежедневной ходьбы'),
+    (203, 0, 'Психические расстройства нужно лечить только медикаментозно'),
+    (203, 1, 'Терапия без лекарств эффективнее в долгосрочной перспективе'),
+    (204, 0, 'Медитация должна стать частью обязательной школьной программы'),
+    (204, 1, 'Медитация — религиозная практика, недопустимая в образовании'),
+    (205, 0, 'Всеобщая вакцинация — единственный способ предотвратить эпидемии'),
+    (205, 1, 'Массовая вакцинация опасна из-за побочных эффектов'),
+    (301, 0, 'Онлайн-курсы полностью заменят университеты к 2030 году'),
+    (301, 1, 'Очное образование сохранит главную роль в подготовке специалистов'),
+    (302, 0, 'Классическая литература обязательна для развития критического мышления'),
+    (302, 1, 'Современные книги лучше отражают актуальные проблемы общества'),
+    (303, 0, 'Изучать языки эффективнее через живое общение, а не приложения'),
+    (303, 1, 'Цифровые сервисы делают изучение языков доступнее и быстрее'),
+    (304, 0, 'Программирование — обязательный навык для всех профессий будущего'),
+    (304, 1, 'Узкая специализация важнее базовых цифровых навыков'),
+    (305, 0, 'VR-технологии революционизируют образовательный процесс'),
+    (305, 1, 'VR — дорогая игрушка, не способная заменить реальные эксперименты'),
+    (401, 0, 'Современное искусство отражает дух времени лучше классики'),
+    (401, 1, 'Модерн-арт — провокация, не имеющая художественной ценности'),
+    (402, 0, 'Классическая музыка развивает интеллект и должна быть в школе'),
+    (402, 1, 'Популярная музыка лучше отвечает запросам современной молодежи'),
+    (403, 0, 'Кино стало важнее театра в современной культуре'),
+    (403, 1, 'Театр сохраняет подлинность, которую не заменит киноэффектами'),
+    (404, 0, 'Поэзия умерла как формат в цифровую эпоху'),
+    (404, 1, 'Соцсети дали поэзии новую жизнь через короткие формы'),
+    (405, 0, 'Реставрация памятников важнее нового строительства'),
+    (405, 1, 'Города должны развиваться, а не сохранять старые здания'),
+    (501, 0, 'Популярные направления страдают от массового туризма'),
+    (501, 1, 'Туризм — главный источник развития для регионов'),
+    (502, 0, 'Путешествия должны быть образовательными, а не развлекательными'),
+    (502, 1, 'Отдых — главная цель туризма, а не изучение культуры'),
+    (503, 0, 'Экстремальный туризм должен быть законодательно ограничен'),
+    (503, 1, 'Человек сам вправе решать, какой риск допустим'),
+    (504, 0, 'Путешествия с детьми — лучший способ семейного воспитания'),
+    (504, 1, 'С малышами стоит отдыхать локально, без дальних поездок'),
+    (505, 0, 'Профессиональная техника — обязательное условие для тревел-фото'),
+    (505, 1, 'Спонтанные снимки на телефон лучше передают атмосферу'),
+    (601, 0, 'Ветряки и солнечные панели должны полностью заменить ТЭС к 2040'),
+    (601, 1, 'ВИЭ нестабильны и не могут быть основным источником энергии'),
+    (602, 0, 'Раздельный сбор мусора должен быть законодательно обязательным'),
+    (602, 1, 'Переработка — дело бизнеса, а не отдельных людей'),
+    (603, 0, 'Зеленые технологии должны субсидироваться государством'),
+    (603, 1, 'Рынок сам определит наиболее эффективные экологичные решения'),
+    (604, 0, 'Человек должен вмешиваться для сохранения вымирающих видов'),
+    (604, 1, 'Природа сама регулирует биоразнообразие без нашего участия'),
+    (605, 0, 'Zero waste — обязанность каждого сознательного гражданина'),
+    (605, 1, 'Устойчивое потребление — утопия, недостижимая в капитализме')
+ON CONFLICT (subtopic_id, position) DO NOTHING;
+
+-- новые записи получают id после засеянных, чтобы не пересекаться с ними
+SELECT setval('topics_id_seq', GREATEST((SELECT MAX(id) FROM topics), 100));
+SELECT setval('subtopics_id_seq', GREATEST((SELECT MAX(id) FROM subtopics), 1000));
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS topic_proposal_votes;
+DROP TABLE IF EXISTS topic_proposals;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS topic_proposals (
+    id SERIAL PRIMARY KEY,
+    author_user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    topic_id INT NOT NULL REFERENCES topics(id),
+    name TEXT NOT NULL,
+    theses JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected, promoted
+    reject_reason TEXT,
+    moderator_user_id INT REFERENCES users(user_id),
+    subtopic_id INT REFERENCES subtopics(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    moderated_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_topic_proposals_status ON topic_proposals(status);
+
+CREATE TABLE IF NOT EXISTS topic_proposal_votes (
+    proposal_id INT NOT NULL REFERENCES topic_proposals(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    vote INT NOT NULL CHECK (vote IN (-1, 1)),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (proposal_id, user_id)
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS room_templates (
+    id SERIAL PRIMARY KEY,
+    owner_user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    settings JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (owner_user_id, name)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE room_templates DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE discussions DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS organization_invites;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
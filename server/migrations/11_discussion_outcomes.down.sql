@@ -0,0 +1 @@
+DROP TABLE IF EXISTS discussion_outcomes;
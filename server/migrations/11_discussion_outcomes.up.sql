@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS discussion_outcomes (
+    id SERIAL PRIMARY KEY,
+    discussion_id INT NOT NULL REFERENCES discussions(id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL CHECK (kind IN ('decision', 'action_item')),
+    content TEXT NOT NULL,
+    message_id TEXT,
+    agenda_item INT,
+    author_username VARCHAR(64) NOT NULL,
+    assignee_user_id INT REFERENCES users(user_id) ON DELETE SET NULL,
+    due_date DATE,
+    created_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_discussion_outcomes_discussion ON discussion_outcomes(discussion_id);
+CREATE INDEX IF NOT EXISTS idx_discussion_outcomes_assignee ON discussion_outcomes(assignee_user_id) WHERE completed_at IS NULL;
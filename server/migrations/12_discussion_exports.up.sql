@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS discussion_exports (
+    id SERIAL PRIMARY KEY,
+    discussion_id INT NOT NULL REFERENCES discussions(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    content_type TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (discussion_id, kind)
+);
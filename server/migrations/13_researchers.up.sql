@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_researcher BOOLEAN NOT NULL DEFAULT false;
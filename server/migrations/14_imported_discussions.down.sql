@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS placeholder;
+
+ALTER TABLE discussions DROP COLUMN IF EXISTS import_source;
+ALTER TABLE discussions DROP COLUMN IF EXISTS imported;
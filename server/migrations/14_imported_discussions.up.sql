@@ -0,0 +1,4 @@
+ALTER TABLE discussions ADD COLUMN IF NOT EXISTS imported BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE discussions ADD COLUMN IF NOT EXISTS import_source VARCHAR(20);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS placeholder BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,45 @@
+ALTER TABLE discussions ADD COLUMN IF NOT EXISTS messages JSONB NOT NULL DEFAULT '[]';
+
+-- история собирается обратно в JSON в формате structures.Message; несохраненные сообщения комнат теряются
+UPDATE discussions d
+SET messages = history.messages
+FROM (
+    SELECT m.discussion_id, jsonb_agg(
+        jsonb_strip_nulls(jsonb_build_object(
+            'id', m.id,
+            'type', m.type,
+            'content', m.content,
+            'username', m.username,
+            'userID', '',
+            'timestamp', m.created_at,
+            'likeCount', COALESCE(v.liked, 0),
+            'dislikeCount', COALESCE(v.disliked, 0),
+            'key', m.key,
+            'params', m.params,
+            'agendaItem', m.agenda_item,
+            'replyTo', m.reply_to
+        )) || jsonb_build_object(
+            'likedBy', COALESCE(v.liked_by, '[]'::jsonb),
+            'dislikedBy', COALESCE(v.disliked_by, '[]'::jsonb)
+        )
+        ORDER BY m.seq
+    ) AS messages
+    FROM discussion_messages m
+    LEFT JOIN LATERAL (
+        SELECT
+            COUNT(*) FILTER (WHERE vote = 1) AS liked,
+            COUNT(*) FILTER (WHERE vote = -1) AS disliked,
+            jsonb_agg(username ORDER BY voted_at, username) FILTER (WHERE vote = 1) AS liked_by,
+            jsonb_agg(username ORDER BY voted_at, username) FILTER (WHERE vote = -1) AS disliked_by
+        FROM message_votes
+        WHERE message_id = m.id
+    ) v ON true
+    WHERE m.discussion_id IS NOT NULL
+    GROUP BY m.discussion_id
+) history
+WHERE d.id = history.discussion_id;
+
+ALTER TABLE discussions ALTER COLUMN messages DROP DEFAULT;
+
+DROP TABLE IF EXISTS message_votes;
+DROP TABLE IF EXISTS discussion_messages;
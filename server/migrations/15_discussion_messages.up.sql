@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS discussion_messages (
+    id TEXT PRIMARY KEY,
+    discussion_id INT REFERENCES discussions(id) ON DELETE CASCADE,
+    room_session UUID,
+    seq INT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    username TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    reply_to TEXT,
+    agenda_item INT,
+    key TEXT,
+    params JSONB
+);
+
+CREATE INDEX IF NOT EXISTS idx_discussion_messages_discussion ON discussion_messages(discussion_id, seq);
+CREATE INDEX IF NOT EXISTS idx_discussion_messages_username ON discussion_messages(username, discussion_id);
+CREATE INDEX IF NOT EXISTS idx_discussion_messages_session ON discussion_messages(room_session) WHERE discussion_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS message_votes (
+    message_id TEXT NOT NULL REFERENCES discussion_messages(id) ON DELETE CASCADE,
+    username TEXT NOT NULL,
+    vote SMALLINT NOT NULL CHECK (vote IN (-1, 1)),
+    voted_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (message_id, username)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_votes_username ON message_votes(username);
+
+-- перенос истории из discussions.messages; сообщения без id получают новый.
+-- Колонки может уже не быть, если миграцию применил initdb до появления schema_migrations
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'discussions' AND column_name = 'messages'
+    ) THEN
+        INSERT INTO discussion_messages
+            (id, discussion_id, seq, type, username, content, created_at, reply_to, agenda_item, key, params)
+        SELECT
+            COALESCE(NULLIF(e.value->>'id', ''), gen_random_uuid()::text),
+            d.id,
+            e.seq,
+            COALESCE(NULLIF(e.value->>'type', ''), 'usual'),
+            COALESCE(e.value->>'username', ''),
+            COALESCE(e.value->>'content', ''),
+            COALESCE((e.value->>'timestamp')::timestamptz, d.start_time),
+            NULLIF(e.value->>'replyTo', ''),
+            (e.value->>'agendaItem')::int,
+            NULLIF(e.value->>'key', ''),
+            NULLIF(e.value->'params', 'null'::jsonb)
+        FROM discussions d
+        CROSS JOIN LATERAL jsonb_array_elements(d.messages) WITH ORDINALITY AS e(value, seq)
+        WHERE jsonb_typeof(d.messages) = 'array'
+        ON CONFLICT (id) DO NOTHING;
+
+        INSERT INTO message_votes (message_id, username, vote)
+        SELECT m.id, voter.username, votes.vote
+        FROM discussions d
+        CROSS JOIN LATERAL jsonb_array_elements(d.messages) AS e(value)
+        JOIN discussion_messages m ON m.id = e.value->>'id' AND m.discussion_id = d.id
+        CROSS JOIN LATERAL (VALUES ('likedBy', 1), ('dislikedBy', -1)) AS votes(field, vote)
+        CROSS JOIN LATERAL jsonb_array_elements_text(
+            CASE WHEN jsonb_typeof(e.value->votes.field) = 'array' THEN e.value->votes.field ELSE '[]'::jsonb END
+        ) AS voter(username)
+        WHERE jsonb_typeof(d.messages) = 'array'
+        ON CONFLICT (message_id, username) DO NOTHING;
+
+        ALTER TABLE discussions DROP COLUMN IF EXISTS messages;
+    END IF;
+END
+$$;
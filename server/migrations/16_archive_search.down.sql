@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_discussions_end_time;
+DROP INDEX IF EXISTS idx_discussion_messages_search;
+DROP INDEX IF EXISTS idx_discussions_search;
+
+ALTER TABLE discussion_messages DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE discussions DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,18 @@
+-- полнотекстовый поиск по архиву: русская и английская конфигурации одновременно,
+-- веса — название и своя тема (A), ключевые вопросы (B), описание и цель (C)
+ALTER TABLE discussions ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('russian', COALESCE(room_name, '') || ' ' || COALESCE(custom_topic, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(room_name, '') || ' ' || COALESCE(custom_topic, '')), 'A') ||
+    setweight(to_tsvector('russian', COALESCE(key_questions::text, '')), 'B') ||
+    setweight(to_tsvector('english', COALESCE(key_questions::text, '')), 'B') ||
+    setweight(to_tsvector('russian', COALESCE(description, '') || ' ' || COALESCE(purpose, '')), 'C') ||
+    setweight(to_tsvector('english', COALESCE(description, '') || ' ' || COALESCE(purpose, '')), 'C')
+) STORED;
+
+ALTER TABLE discussion_messages ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('russian', content) || to_tsvector('english', content)
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_discussions_search ON discussions USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_discussion_messages_search ON discussion_messages USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_discussions_end_time ON discussions(end_time DESC);
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_discussions_end_time ON discussions(end_time DESC);
+DROP INDEX IF EXISTS idx_discussions_duration_id;
+DROP INDEX IF EXISTS idx_discussions_end_time_id;
@@ -0,0 +1,4 @@
+-- keyset-пагинация архива: порядок по ключу сортировки с добором по id
+CREATE INDEX IF NOT EXISTS idx_discussions_end_time_id ON discussions(end_time DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_discussions_duration_id ON discussions(duration DESC, id DESC);
+DROP INDEX IF EXISTS idx_discussions_end_time;
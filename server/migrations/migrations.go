@@ -0,0 +1,7 @@
+// Package migrations схема базы: пары NN_name.up.sql и NN_name.down.sql, встроенные в бинарник сервера
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,259 @@
+// Package migrate применяет пронумерованные SQL-миграции и ведет их учет в таблице schema_migrations.
+// Файлы называются NN_name.up.sql и NN_name.down.sql; каждая миграция выполняется в своей транзакции
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// lockKey ключ pg_advisory_lock: пока одна реплика мигрирует, остальные ждут
+const lockKey = 7204316842
+
+var (
+	ErrNoDown        = errors.New("migration has no down script")
+	ErrMissingScript = errors.New("applied migration is missing from the binary")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// State миграция и время ее применения; AppliedAt пуст у ожидающих
+type State struct {
+	Migration
+	AppliedAt *time.Time
+	Missing   bool // применена, но файла в сборке нет
+}
+
+// Load читает миграции из корня fsys, упорядоченные по номеру
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up применяет все ожидающие миграции и возвращает примененные
+func Up(db *sql.DB, fsys fs.FS) ([]Migration, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err = locked(db, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			err = apply(conn, migration.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+				migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down откатывает steps последних примененных миграций и возвращает откаченные
+func Down(db *sql.DB, fsys fs.FS, steps int) ([]Migration, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int]Migration, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = migration
+	}
+
+	var done []Migration
+	err = locked(db, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		for i := 0; i < steps && i < len(versions); i++ {
+			migration, ok := known[versions[i]]
+			if !ok {
+				return fmt.Errorf("%w: version %d", ErrMissingScript, versions[i])
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrNoDown, migration.Version, migration.Name)
+			}
+			err = apply(conn, migration.Down, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status все известные миграции по порядку, включая примененные, файлов которых нет в сборке
+func Status(db *sql.DB, fsys fs.FS) ([]State, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	var states []State
+	err = locked(db, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			state := State{Migration: migration}
+			if at, ok := applied[migration.Version]; ok {
+				state.AppliedAt = &at.appliedAt
+				delete(applied, migration.Version)
+			}
+			states = append(states, state)
+		}
+		for version, at := range applied {
+			at := at
+			states = append(states, State{
+				Migration: Migration{Version: version, Name: at.name},
+				AppliedAt: &at.appliedAt,
+				Missing:   true,
+			})
+		}
+		return nil
+	})
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+	return states, err
+}
+
+// locked выполняет fn на отдельном соединении под advisory lock; блокировка сессионная,
+// поэтому и запросы fn должны идти через то же соединение
+func locked(db *sql.DB, fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockKey)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+type appliedMigration struct {
+	name      string
+	appliedAt time.Time
+}
+
+func appliedVersions(conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var migration appliedMigration
+		if err = rows.Scan(&version, &migration.name, &migration.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = migration
+	}
+	return applied, rows.Err()
+}
+
+// apply выполняет скрипт и запись в schema_migrations одной транзакцией
+func apply(conn *sql.Conn, script string, record string, args ...any) error {
+	ctx := context.Background()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}